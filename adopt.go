@@ -0,0 +1,123 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// AdoptCmd represents the command to track an existing directory as an environment
+type AdoptCmd struct {
+	Path string   `arg:"" help:"The existing directory" type:"existingdir"`
+	Name string   `short:"n" help:"The name of environment, defaults to the directory name"`
+	Type SpecType `short:"t" help:"The type of environment, detected if not set"`
+}
+
+// recordedSpec returns what the directory at path records about its environment:
+// the spec of its marker file, or else the metadata of a README written by new
+func recordedSpec(path string) (Spec, error) {
+	spec, ok, err := ReadMarker(path)
+	if err != nil || ok {
+		return spec, err
+	}
+	meta, ok, err := ReadReadme(path)
+	if err != nil || !ok {
+		return Spec{Path: path}, err
+	}
+	spec = Spec{Name: meta.Name, Type: meta.Type, Path: path, Description: meta.Description, Created: meta.Created}
+	if meta.TTL > 0 && !meta.Created.IsZero() {
+		spec.Expires = meta.Created.Add(meta.TTL)
+	}
+	return spec, nil
+}
+
+// spec builds the Spec for the directory without provisioning it, taking what
+// flags leave unset from what the directory records about itself
+func (a AdoptCmd) spec() (Spec, error) {
+	path, err := filepath.Abs(a.Path)
+	if err != nil {
+		return Spec{}, err
+	}
+	spec, err := recordedSpec(path)
+	if err != nil {
+		return Spec{}, err
+	}
+	if spec.Name != "" {
+		slog.Debug("Read environment metadata", slog.String("name", spec.Name), slog.String("type", string(spec.Type)))
+	}
+
+	spec.Name = cmp.Or(a.Name, spec.Name, filepath.Base(path))
+	if err := ValidateName(spec.Name); err != nil {
+		return Spec{}, err
+	}
+
+	spec.Type = cmp.Or(a.Type, spec.Type)
+	if spec.Type == "" {
+		spec.Type, err = DetectType(path)
+		if err != nil {
+			return Spec{}, fmt.Errorf("%w, specify --type", err)
+		}
+		slog.Debug("Detected environment type", slog.String("type", string(spec.Type)))
+	}
+	if _, err := NewProvisioner(spec.Type); err != nil {
+		return Spec{}, err
+	}
+
+	spec.UID = cmp.Or(spec.UID, NewULID(time.Now()))
+	return spec, nil
+}
+
+// saveAdopted tracks spec of an existing directory, under a new UID if the
+// directory is a copy of an environment that is still tracked
+func saveAdopted(store Storer, spec Spec) (Spec, error) {
+	if _, err := LookupID(store, spec.UID); err == nil {
+		spec.UID = NewULID(time.Now())
+	} else if !errors.Is(err, ErrNotFound) {
+		return Spec{}, err
+	}
+	if err := spec.Save(store); err != nil {
+		return Spec{}, err
+	}
+	refreshMarker(spec)
+	recordEvent(NewEvent(ActionAdopt, spec))
+	return spec, nil
+}
+
+// Run saves the spec for the existing directory
+func (a AdoptCmd) Run(ctx *CLIContext) error {
+	spec, err := a.spec()
+	if err != nil {
+		return err
+	}
+
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+
+	tracked, ok, err := FindSpecByPath(store, spec.Path)
+	if err != nil {
+		return err
+	}
+	if ok && tracked.Path == spec.Path {
+		return fmt.Errorf("directory is already tracked as %q", tracked.ID())
+	}
+
+	policy, err := ctx.Policy()
+	if err != nil {
+		return err
+	}
+	if errs := policy.Check(spec); len(errs) > 0 {
+		return PreflightError{errs}
+	}
+
+	spec, err = saveAdopted(store, spec)
+	if err != nil {
+		return err
+	}
+	output.Success("Adopted %s at %s", spec.ID(), spec.Path)
+	return nil
+}
@@ -0,0 +1,29 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectType(t *testing.T) {
+	tdir := t.TempDir()
+	_, err := main.DetectType(tdir)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(tdir, "pyproject.toml"), nil, 0644))
+	specType, err := main.DetectType(tdir)
+	require.NoError(t, err)
+	require.Equal(t, main.PythonSpec, specType)
+
+	for file, expected := range map[string]main.SpecType{"deno.json": main.DenoSpec, "bun.lock": main.BunSpec} {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), nil, 0644))
+		specType, err := main.DetectType(dir)
+		require.NoError(t, err)
+		require.Equal(t, expected, specType)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultArchiveDir returns the directory archived environments are moved to
+func DefaultArchiveDir() (string, error) {
+	dir, err := DefaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ".archive"), nil
+}
+
+// objectsDir returns the content-addressed store for deduplicated archive files
+func objectsDir(archiveDir string) string {
+	return filepath.Join(archiveDir, ".objects")
+}
+
+// MoveTree moves the directory src to dst, copying if they are on different filesystems
+func MoveTree(src string, dst string) error {
+	if err := EnsureDirectory(filepath.Dir(dst)); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	slog.Debug("Rename failed, copying instead", slog.String("from", src), slog.String("to", dst))
+	if _, err := CopyTree(src, dst, ReflinkAuto); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// DedupStats describes the result of deduplicating a directory
+type DedupStats struct {
+	Linked int
+	Saved  ByteSize
+}
+
+// DedupTree replaces regular files in dir with hard links to identical files
+// in the content-addressed objects directory, adding new content as it goes.
+// Files must not be modified in place afterwards since links share content.
+func DedupTree(dir string, objects string) (DedupStats, error) {
+	var stats DedupStats
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		// Links share permissions so they are part of the object identity
+		name := fmt.Sprintf("%s-%o", sum, info.Mode().Perm())
+		object := filepath.Join(objects, name[:2], name)
+
+		existing, err := os.Stat(object)
+		if errors.Is(err, os.ErrNotExist) {
+			if err := EnsureDirectory(filepath.Dir(object)); err != nil {
+				return err
+			}
+			return os.Link(path, object)
+		}
+		if err != nil {
+			return err
+		}
+		if os.SameFile(info, existing) {
+			return nil
+		}
+
+		// Link next to the file first so it is never missing
+		tmp := path + ".scratch-link"
+		if err := os.Link(object, tmp); err != nil {
+			return fmt.Errorf("link %s: %w", path, err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("replace %s: %w", path, err)
+		}
+		stats.Linked++
+		stats.Saved += ByteSize(info.Size())
+		return nil
+	})
+	return stats, err
+}
+
+// PruneObjects removes objects that are no longer linked from any archive
+// and returns the space reclaimed
+func PruneObjects(objects string) (ByteSize, error) {
+	var freed ByteSize
+	err := filepath.WalkDir(objects, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if links, ok := linkCount(info); ok && links <= 1 {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			freed += ByteSize(info.Size())
+		}
+		return nil
+	})
+	return freed, err
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ArchiveCmd represents the command to move environments out of the way
+type ArchiveCmd struct {
+	IdentifyFlags
+	FilterFlags
+	All   bool `help:"Archive all environments matching the filters"`
+	Dedup bool `help:"Hard link files identical to ones in other archived environments"`
+}
+
+// bulk checks if the flags can select several environments
+func (a ArchiveCmd) bulk() bool {
+	return a.All || a.Narrows() || (a.Type != "" && !a.IsSet())
+}
+
+// Validate checks a specific environment, --all or filters were given
+func (a ArchiveCmd) Validate() error {
+	if a.bulk() {
+		if a.IsSet() {
+			return fmt.Errorf("--all and filters cannot be used with a specific environment")
+		}
+		return nil
+	}
+	return a.IdentifyFlags.Validate()
+}
+
+// Run moves the selected environments into the archive directory
+func (a ArchiveCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	archiveDir, err := DefaultArchiveDir()
+	if err != nil {
+		return err
+	}
+
+	if !a.bulk() {
+		spec, err := a.Resolve(store)
+		if err != nil {
+			return err
+		}
+		if spec.IsArchived() {
+			return fmt.Errorf("environment %q is already archived", spec.ID())
+		}
+		if !spec.Exists() {
+			return fmt.Errorf("directory %s does not exist", spec.Path)
+		}
+		return a.archive(store, spec, archiveDir)
+	}
+
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return err
+	}
+	specs = a.Filter(a.Type).Apply(specs, time.Now())
+	archived := 0
+	for _, spec := range specs {
+		if spec.IsArchived() || !spec.Exists() {
+			continue
+		}
+		if err := a.archive(store, spec, archiveDir); err != nil {
+			return err
+		}
+		archived++
+	}
+	if archived == 0 {
+		output.Info("No live environments match the filters")
+	}
+	return nil
+}
+
+// archive moves the environment into archiveDir
+func (a ArchiveCmd) archive(store Writer, spec Spec, archiveDir string) error {
+	if spec.InPlace {
+		return fmt.Errorf("%s was provisioned in place at %s and cannot be archived", spec.ID(), spec.Path)
+	}
+	dst := filepath.Join(archiveDir, string(spec.Type), spec.Name)
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("archive %s already exists", dst)
+	}
+
+	if err := MoveTree(spec.Path, dst); err != nil {
+		return fmt.Errorf("archive environment: %w", err)
+	}
+
+	spec.Archive = dst
+	if err := spec.Save(store); err != nil {
+		return err
+	}
+
+	if a.Dedup {
+		stats, err := DedupTree(dst, objectsDir(archiveDir))
+		if err != nil {
+			return fmt.Errorf("dedup archive: %w", err)
+		}
+		output.Info("Deduplicated %d files, saving %s", stats.Linked, stats.Saved)
+	}
+
+	output.Success("Archived %s to %s", spec.ID(), dst)
+	return nil
+}
+
+// UnarchiveCmd represents the command to restore an archived environment
+type UnarchiveCmd struct {
+	IdentifyFlags
+}
+
+// Run copies the archive back to its original location and removes it
+func (u UnarchiveCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+
+	spec, err := u.Resolve(store)
+	if err != nil {
+		return err
+	}
+	if !spec.IsArchived() {
+		return fmt.Errorf("environment %q is not archived", spec.ID())
+	}
+	if spec.Exists() {
+		return fmt.Errorf("directory %s already exists", spec.Path)
+	}
+
+	// Copy rather than move so restored files don't share hard links with other archives
+	if _, err := CopyTree(spec.Archive, spec.Path, ReflinkAuto); err != nil {
+		os.RemoveAll(spec.Path)
+		return fmt.Errorf("restore environment: %w", err)
+	}
+	if err := removeArchive(spec.Archive); err != nil {
+		return err
+	}
+
+	spec.Archive = ""
+	if err := spec.Save(store); err != nil {
+		return err
+	}
+
+	output.Success("Restored %s to %s", spec.ID(), spec.Path)
+	return nil
+}
+
+// removeArchive removes an archived environment and objects only it used
+func removeArchive(dir string) error {
+	if err := removeTree(dir); err != nil {
+		return fmt.Errorf("remove archive: %w", err)
+	}
+
+	archiveDir, err := DefaultArchiveDir()
+	if err != nil {
+		return err
+	}
+	freed, err := PruneObjects(objectsDir(archiveDir))
+	if err != nil {
+		return fmt.Errorf("prune archive objects: %w", err)
+	}
+	slog.Debug("Pruned archive objects", slog.String("freed", freed.String()))
+	return nil
+}
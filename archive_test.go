@@ -0,0 +1,51 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupTree(t *testing.T) {
+	tdir := t.TempDir()
+	objects := filepath.Join(tdir, ".objects")
+	for _, name := range []string{"a", "b"} {
+		dir := filepath.Join(tdir, name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "shared.py"), []byte("import os"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "own.py"), []byte(name), 0644))
+	}
+
+	stats, err := main.DedupTree(filepath.Join(tdir, "a"), objects)
+	require.NoError(t, err)
+	require.Zero(t, stats.Linked)
+
+	stats, err = main.DedupTree(filepath.Join(tdir, "b"), objects)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Linked)
+	require.Equal(t, main.ByteSize(len("import os")), stats.Saved)
+
+	a, err := os.Stat(filepath.Join(tdir, "a", "shared.py"))
+	require.NoError(t, err)
+	b, err := os.Stat(filepath.Join(tdir, "b", "shared.py"))
+	require.NoError(t, err)
+	require.True(t, os.SameFile(a, b))
+
+	data, err := os.ReadFile(filepath.Join(tdir, "b", "own.py"))
+	require.NoError(t, err)
+	require.Equal(t, "b", string(data))
+
+	// Objects stay while any archive links to them
+	require.NoError(t, os.RemoveAll(filepath.Join(tdir, "a")))
+	freed, err := main.PruneObjects(objects)
+	require.NoError(t, err)
+	require.Equal(t, main.ByteSize(1), freed)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(tdir, "b")))
+	freed, err = main.PruneObjects(objects)
+	require.NoError(t, err)
+	require.Equal(t, main.ByteSize(len("import os")+1), freed)
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Backups are JSON dumps of every key in the store, in the format of the JSON store
+// backend, so they can be restored into either backend
+const (
+	backupPrefix     = "scratch-"
+	backupExt        = ".json"
+	backupTimeFormat = "20060102-150405.000"
+	defaultKeep      = 10
+)
+
+// BackupConfig configures backups of the store
+type BackupConfig struct {
+	// Keep is the number of backups kept before the oldest are removed
+	Keep int `json:"keep,omitempty"`
+}
+
+// keep returns the configured number of backups to keep, defaulting to 10
+func (c BackupConfig) keep() int {
+	if c.Keep <= 0 {
+		return defaultKeep
+	}
+	return c.Keep
+}
+
+// DefaultBackupDir returns the directory backups of the current workspace are written to
+func DefaultBackupDir() (string, error) {
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, backupDirName()), nil
+}
+
+// Backup writes every key-value pair in store to a timestamped file in dir
+func Backup(store Lister, dir string, now time.Time) (string, error) {
+	data := map[string]json.RawMessage{}
+	err := store.ListFunc(func(key string, value []byte) error {
+		if !json.Valid(value) {
+			return fmt.Errorf("key %q is not valid JSON", key)
+		}
+		data[key] = value
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("read store: %w", err)
+	}
+
+	raw, err := json.MarshalIndent(data, "", " ")
+	if err != nil {
+		return "", fmt.Errorf("marshal backup: %w", err)
+	}
+	if err := EnsureDirectory(dir); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, backupPrefix+now.UTC().Format(backupTimeFormat)+backupExt)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return "", fmt.Errorf("write backup: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("write backup: %w", err)
+	}
+	return path, nil
+}
+
+// ListBackups returns the backups in dir from oldest to newest
+func ListBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list backups: %w", err)
+	}
+
+	backups := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupExt) {
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+	// Timestamps sort lexically
+	slices.Sort(backups)
+	return backups, nil
+}
+
+// RotateBackups removes the oldest backups in dir so at most keep remain
+func RotateBackups(dir string, keep int) ([]string, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) <= keep {
+		return nil, nil
+	}
+
+	removed := backups[:len(backups)-keep]
+	for _, path := range removed {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove old backup: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+// FindBackup finds a backup by path, by file name in dir, or the newest for "latest"
+func FindBackup(dir string, snapshot string) (string, error) {
+	if snapshot == "latest" {
+		backups, err := ListBackups(dir)
+		if err != nil {
+			return "", err
+		}
+		if len(backups) == 0 {
+			return "", fmt.Errorf("no backups in %s: %w", dir, ErrNotFound)
+		}
+		return backups[len(backups)-1], nil
+	}
+
+	for _, path := range []string{snapshot, filepath.Join(dir, snapshot), filepath.Join(dir, snapshot+backupExt)} {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("backup %q: %w", snapshot, ErrNotFound)
+}
+
+// LoadBackup reads the backup at path into memory
+func LoadBackup(path string) (*JSONStore, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("read backup: %w", err)
+	}
+	return ReadJSONStore(path)
+}
+
+// Restore replaces every key-value pair in store with those of backup
+func Restore(store Storer, backup Lister) (int, error) {
+	keys := []string{}
+	for key, err := range store.List() {
+		if err != nil {
+			return 0, fmt.Errorf("list keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	// A failed restore must not leave the store emptied or half restored
+	count := 0
+	err := store.Batch(func(w Writer) error {
+		for _, key := range keys {
+			if err := w.Delete(key); err != nil {
+				return err
+			}
+		}
+		var err error
+		count, err = CopyStore(backup, w)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// backup backs up store to the default backup directory and removes old backups
+func (c *CLIContext) backup(store Lister) (string, error) {
+	config, err := c.Config()
+	if err != nil {
+		return "", err
+	}
+	dir, err := DefaultBackupDir()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := Backup(store, dir, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if _, err := RotateBackups(dir, config.Backup.keep()); err != nil {
+		output.Warn("%v", err)
+	}
+	return path, nil
+}
+
+// backupBefore backs up store before a destructive operation
+func (c *CLIContext) backupBefore(store Lister, operation string) error {
+	path, err := c.backup(store)
+	if err != nil {
+		return fmt.Errorf("back up before %s: %w", operation, err)
+	}
+	output.Info("Backed up environments to %s", path)
+	return nil
+}
+
+// BackupCmd represents the command to back up the store
+type BackupCmd struct {
+	List bool `short:"l" help:"List backups instead of creating one"`
+}
+
+// Run writes a backup or lists the existing ones
+func (b BackupCmd) Run(ctx *CLIContext) error {
+	if b.List {
+		dir, err := DefaultBackupDir()
+		if err != nil {
+			return err
+		}
+		backups, err := ListBackups(dir)
+		if err != nil {
+			return err
+		}
+		for _, path := range backups {
+			fmt.Println(filepath.Base(path))
+		}
+		return nil
+	}
+
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	path, err := ctx.backup(store)
+	if err != nil {
+		return err
+	}
+	output.Success("Backed up environments to %s", path)
+	return nil
+}
+
+// RestoreCmd represents the command to restore the store from a backup
+type RestoreCmd struct {
+	Snapshot string `arg:"" help:"Path or file name of the backup, or latest"`
+	Force    bool   `short:"f" help:"Restore without confirmation"`
+}
+
+// Run backs up the current store and replaces it with the backup
+func (r RestoreCmd) Run(ctx *CLIContext) error {
+	dir, err := DefaultBackupDir()
+	if err != nil {
+		return err
+	}
+	path, err := FindBackup(dir, r.Snapshot)
+	if err != nil {
+		return err
+	}
+	// Loaded before backing up the current store, which may rotate it away
+	backup, err := LoadBackup(path)
+	if err != nil {
+		return err
+	}
+
+	if !r.Force {
+		ok, err := askForConfirmation(fmt.Sprintf("Replace all tracked environments with %s?", filepath.Base(path)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			output.Info("Not restoring")
+			return nil
+		}
+	}
+
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	if err := ctx.backupBefore(store, "restore"); err != nil {
+		return err
+	}
+
+	count, err := Restore(store, backup)
+	if err != nil {
+		return fmt.Errorf("restore %s: %w", path, err)
+	}
+	output.Success("Restored %d keys from %s", count, filepath.Base(path))
+	return nil
+}
@@ -0,0 +1,66 @@
+package main_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewMemoryStore()
+	a := main.NewSpec("a", main.PythonSpec, "/tmp")
+	b := main.NewSpec("b", main.PythonSpec, "/tmp")
+	require.NoError(t, a.Save(store))
+	require.NoError(t, b.Save(store))
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	path, err := main.Backup(store, dir, now)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "scratch-20260102-030405.000.json"), path)
+
+	require.NoError(t, a.Delete(store))
+	c := main.NewSpec("c", main.PythonSpec, "/tmp")
+	require.NoError(t, c.Save(store))
+
+	found, err := main.FindBackup(dir, "latest")
+	require.NoError(t, err)
+	require.Equal(t, path, found)
+	backup, err := main.LoadBackup(found)
+	require.NoError(t, err)
+	_, err = main.Restore(store, backup)
+	require.NoError(t, err)
+
+	specs, err := main.LoadSpecs(store)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []main.Spec{a, b}, specs)
+}
+
+func TestRotateBackups(t *testing.T) {
+	dir := t.TempDir()
+	store := NewMemoryStore()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	paths := []string{}
+	for i := range 4 {
+		path, err := main.Backup(store, dir, now.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, err)
+		paths = append(paths, path)
+	}
+
+	removed, err := main.RotateBackups(dir, 3)
+	require.NoError(t, err)
+	require.Equal(t, paths[:1], removed)
+
+	backups, err := main.ListBackups(dir)
+	require.NoError(t, err)
+	require.Equal(t, paths[1:], backups)
+
+	found, err := main.FindBackup(dir, filepath.Base(paths[2]))
+	require.NoError(t, err)
+	require.Equal(t, paths[2], found)
+	_, err = main.FindBackup(dir, "missing")
+	require.ErrorIs(t, err, main.ErrNotFound)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// BatchFlags select one or all environments for maintenance commands
+type BatchFlags struct {
+	IdentifyFlags
+	All  bool `help:"Apply to all environments"`
+	Jobs int  `short:"j" help:"Number of environments processed in parallel" default:"4"`
+}
+
+// Validate checks the combination of flags
+func (b BatchFlags) Validate() error {
+	if b.Jobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	if b.All {
+		if b.IsSet() {
+			return fmt.Errorf("--all cannot be used with a specific environment")
+		}
+		return nil
+	}
+	return b.IdentifyFlags.Validate()
+}
+
+// Specs loads the selected environments
+func (b BatchFlags) Specs(store Storer) ([]Spec, error) {
+	if b.All {
+		specs, err := LoadSpecs(store)
+		if err != nil {
+			return nil, err
+		}
+		// Archived environments are not maintained
+		return slices.DeleteFunc(specs, Spec.IsArchived), nil
+	}
+	spec, err := b.Resolve(store)
+	if err != nil {
+		return nil, err
+	}
+	return []Spec{spec}, nil
+}
+
+// BatchResult is the outcome of an operation on one environment
+type BatchResult struct {
+	Spec     Spec
+	Err      error
+	Duration time.Duration
+}
+
+// RunBatch runs fn on each spec with up to jobs in parallel, returning
+// results in the same order as specs
+func RunBatch(specs []Spec, jobs int, fn func(Spec) error) []BatchResult {
+	results := make([]BatchResult, len(specs))
+	sem := make(chan struct{}, max(jobs, 1))
+	var wg sync.WaitGroup
+	for i, spec := range specs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			start := time.Now()
+			slog.Debug("Processing environment", slog.String("id", spec.ID()))
+			err := fn(spec)
+			results[i] = BatchResult{spec, err, time.Since(start)}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// ReportBatch prints a consolidated report of results and returns an error if any failed
+func ReportBatch(action string, results []BatchResult) error {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("FAIL  %s (%s): %v\n", r.Spec.ID(), r.Duration.Round(time.Millisecond), r.Err)
+			continue
+		}
+		fmt.Printf("OK    %s (%s)\n", r.Spec.ID(), r.Duration.Round(time.Millisecond))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s failed for %d of %d environments", action, failed, len(results))
+	}
+	return nil
+}
+
+// batchOperation loads the selected environments, applies fn and reports results
+func batchOperation(ctx *CLIContext, flags BatchFlags, action string, fn func(Spec) error) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+
+	specs, err := flags.Specs(store)
+	if err != nil {
+		return err
+	}
+
+	return ReportBatch(action, RunBatch(specs, flags.Jobs, fn))
+}
+
+// CheckCmd represents the command to check environments are intact
+type CheckCmd struct {
+	BatchFlags
+}
+
+// Run checks the directory, provisioner readiness and provisioner specific files
+func (c CheckCmd) Run(ctx *CLIContext) error {
+	return batchOperation(ctx, c.BatchFlags, "check", CheckSpec)
+}
+
+// CheckSpec checks an environment is intact
+func CheckSpec(spec Spec) error {
+	if !spec.Exists() {
+		return fmt.Errorf("directory %s does not exist", spec.Path)
+	}
+	p, err := NewProvisioner(spec.Type)
+	if err != nil {
+		return err
+	}
+	if err := checkReady(p); err != nil {
+		return err
+	}
+	if c, ok := p.(Checker); ok {
+		return c.Check(spec.Path)
+	}
+	return nil
+}
+
+// RebuildCmd represents the command to restore environments in place
+type RebuildCmd struct {
+	BatchFlags
+}
+
+// Run rebuilds the selected environments
+func (r RebuildCmd) Run(ctx *CLIContext) error {
+	return batchOperation(ctx, r.BatchFlags, "rebuild", func(spec Spec) error {
+		p, err := readyProvisioner(spec)
+		if err != nil {
+			return err
+		}
+		rb, ok := p.(Rebuilder)
+		if !ok {
+			return fmt.Errorf("rebuild not supported for %q", spec.Type)
+		}
+		return rb.Rebuild(spec.Path)
+	})
+}
+
+// ReprovisionCmd represents the command to repair environments by re-running their provisioner
+type ReprovisionCmd struct {
+	BatchFlags
+}
+
+// Run reprovisions the selected environments, tracking the ones whose files were
+// removed with delete --files-only as provisioned again
+func (r ReprovisionCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	specs, err := r.Specs(store)
+	if err != nil {
+		return err
+	}
+
+	results := RunBatch(specs, r.Jobs, ReprovisionSpec)
+	for _, result := range results {
+		if result.Err != nil || !result.Spec.IsDeprovisioned() {
+			continue
+		}
+		spec := result.Spec
+		spec.Deprovisioned = time.Time{}
+		if err := spec.Save(store); err != nil {
+			return err
+		}
+		refreshMarker(spec)
+	}
+	return ReportBatch("reprovision", results)
+}
+
+// ReprovisionSpec repairs an environment in place. A missing directory is provisioned
+// from scratch, otherwise the provisioner decides which steps are safe to re-run.
+func ReprovisionSpec(spec Spec) error {
+	if spec.IsArchived() {
+		return fmt.Errorf("environment is archived, unarchive it first")
+	}
+	p, err := NewProvisioner(spec.Type)
+	if err != nil {
+		return err
+	}
+	if err := checkReady(p); err != nil {
+		return err
+	}
+
+	if !spec.Exists() {
+		return p.Provision(spec.Path)
+	}
+	rp, ok := p.(Reprovisioner)
+	if !ok {
+		return fmt.Errorf("reprovision not supported for %q", spec.Type)
+	}
+	return rp.Reprovision(spec.Path)
+}
+
+// UpgradeCmd represents the command to upgrade dependencies of environments
+type UpgradeCmd struct {
+	BatchFlags
+}
+
+// Run upgrades the selected environments
+func (u UpgradeCmd) Run(ctx *CLIContext) error {
+	return batchOperation(ctx, u.BatchFlags, "upgrade", func(spec Spec) error {
+		p, err := readyProvisioner(spec)
+		if err != nil {
+			return err
+		}
+		up, ok := p.(Upgrader)
+		if !ok {
+			return fmt.Errorf("upgrade not supported for %q", spec.Type)
+		}
+		return up.Upgrade(spec.Path)
+	})
+}
+
+// readyProvisioner returns the provisioner for an existing environment once it is ready
+func readyProvisioner(spec Spec) (Provisioner, error) {
+	if !spec.Exists() {
+		return nil, fmt.Errorf("directory %s does not exist", spec.Path)
+	}
+	p, err := NewProvisioner(spec.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReady(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
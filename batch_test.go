@@ -0,0 +1,58 @@
+package main_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBatch(t *testing.T) {
+	specs := []main.Spec{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		specs = append(specs, main.NewSpec(name, main.PythonSpec, t.TempDir()))
+	}
+
+	var running, peak atomic.Int32
+	results := main.RunBatch(specs, 2, func(spec main.Spec) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if spec.Name == "c" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	require.Len(t, results, len(specs))
+	require.LessOrEqual(t, peak.Load(), int32(2))
+	for i, r := range results {
+		require.Equal(t, specs[i], r.Spec)
+		if r.Spec.Name == "c" {
+			require.Error(t, r.Err)
+		} else {
+			require.NoError(t, r.Err)
+		}
+	}
+
+	require.Error(t, main.ReportBatch("check", results))
+	require.NoError(t, main.ReportBatch("check", results[:2]))
+}
+
+func TestReprovisionSpec(t *testing.T) {
+	archived := main.NewSpec("a", main.PythonSpec, t.TempDir())
+	archived.Archive = t.TempDir()
+	require.ErrorContains(t, main.ReprovisionSpec(archived), "archived")
+
+	unknown := main.NewSpec("b", "unknown", t.TempDir())
+	require.ErrorIs(t, main.ReprovisionSpec(unknown), main.ErrUnknownType)
+}
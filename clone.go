@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// CloneCmd represents the command to copy an existing environment under a new name
+type CloneCmd struct {
+	Source    string      `arg:"" help:"The name of environment to clone"`
+	Name      string      `arg:"" help:"The name of the new environment"`
+	Type      SpecType    `short:"t" help:"The type of environment" default:"python"`
+	Reflink   ReflinkMode `help:"Clone files with copy-on-write reflinks (auto, always or never)" enum:"auto,always,never" default:"auto"`
+	NoInstall bool        `help:"Copy ignored files like virtual environments instead of reinstalling dependencies"`
+}
+
+// Run copies the source directory next to it and registers the new environment
+func (c CloneCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+
+	source, err := ResolveName(store, c.Type, c.Source)
+	if err != nil {
+		return err
+	}
+	if !source.Exists() {
+		return fmt.Errorf("directory %s does not exist", source.Path)
+	}
+
+	spec := source
+	spec.UID = NewULID(time.Now())
+	spec.Name = c.Name
+	spec.Path = filepath.Join(filepath.Dir(source.Path), c.Name)
+	spec.Usage = nil
+	spec.Created = time.Now()
+
+	config, err := ctx.Config()
+	if err != nil {
+		return err
+	}
+	s := NewScaffolder(spec).WithConfig(config)
+	policy, err := ctx.Policy()
+	if err != nil {
+		return err
+	}
+	errs := s.Preflight(store)
+	errs = append(errs, policy.Check(spec)...)
+	if len(errs) > 0 {
+		return PreflightError{errs}
+	}
+	p, err := s.Provisioner(spec.Type)
+	if err != nil {
+		return err
+	}
+
+	var ignore []string
+	if ignorer, ok := p.(Ignorer); ok && !c.NoInstall {
+		ignore = ignorer.Ignore()
+	}
+
+	slog.Debug("Copying environment", slog.String("from", source.Path), slog.String("to", spec.Path))
+	done := timePhase("copy files")
+	stats, err := CopyTree(source.Path, spec.Path, c.Reflink, ignore...)
+	done()
+	if err != nil {
+		return fmt.Errorf("copy environment: %w", err)
+	}
+	slog.Debug("Copied environment", slog.Int("reflinked", stats.Reflinked), slog.Int("copied", stats.Copied), slog.Int("skipped", stats.Skipped))
+
+	log := ProvisionLog{}
+	if rebuilder, ok := p.(Rebuilder); ok && len(ignore) > 0 {
+		slog.Debug("Reinstalling dependencies")
+		stop := RecordCommands(&log)
+		log.Tools = ToolVersions(p)
+		err := rebuilder.Rebuild(spec.Path)
+		stop()
+		if err != nil {
+			return fmt.Errorf("reinstall dependencies: %w", err)
+		}
+	}
+
+	if err := spec.Save(store); err != nil {
+		return err
+	}
+	// The copied marker is the one of the source
+	refreshMarker(spec)
+	if err := SaveProvisionLog(store, spec, log); err != nil {
+		output.Warn("%s", err)
+	}
+
+	recordEvent(NewEvent(ActionClone, spec).With("from", source.ID()))
+
+	output.Success("Cloned %s to %s at %s", source.ID(), spec.ID(), spec.Path)
+	return nil
+}
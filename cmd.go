@@ -1,334 +1,1612 @@
 package main
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func askForConfirmation(prompt string) (bool, error) {
-	for {
-		fmt.Printf("%s [y/n]: ", prompt)
-		var input string
-		_, err := fmt.Scanln(&input)
+// CLIContext has common structs for commands
+type CLIContext struct {
+	// backend overrides the storage backend from the config
+	backend StoreBackend
+	config  *Config
+	policy  *Policy
+	store   Storer
+}
+
+// Config lazily loads the user configuration
+func (c *CLIContext) Config() (Config, error) {
+	if c.config != nil {
+		return *c.config, nil
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return Config{}, err
+	}
+	policy, err := c.Policy()
+	if err != nil {
+		return Config{}, err
+	}
+	config = policy.Constrain(config)
+
+	c.config = &config
+
+	return config, nil
+}
+
+// Policy lazily loads the system-wide policy
+func (c *CLIContext) Policy() (Policy, error) {
+	if c.policy != nil {
+		return *c.policy, nil
+	}
+
+	policy, err := LoadPolicy()
+	if err != nil {
+		return Policy{}, err
+	}
+
+	c.policy = &policy
+
+	return policy, nil
+}
+
+// Backend resolves the storage backend from flags or config
+func (c *CLIContext) Backend() (StoreBackend, error) {
+	if c.backend != "" {
+		return c.backend, nil
+	}
+	config, err := c.Config()
+	if err != nil {
+		return "", err
+	}
+	return config.Store, nil
+}
+
+// openStore opens the store of backend, going through the daemon when it holds the pebble store
+func openStore(backend StoreBackend) (Storer, error) {
+	if backend == PebbleBackend || backend == "" {
+		socket, err := DefaultSocketPath()
+		if err != nil {
+			return nil, err
+		}
+		if remote, ok := DialDaemon(socket); ok {
+			slog.Debug("Using store held by daemon", slog.String("socket", socket))
+			return remote, nil
+		}
+	}
+	return OpenStore(backend)
+}
+
+// Store lazily retrieves Storer
+func (c *CLIContext) Store() (Storer, error) {
+	if c.store != nil {
+		return c.store, nil
+	}
+
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	EnsureDirectory(dir)
+
+	backend, err := c.Backend()
+	if err != nil {
+		return nil, err
+	}
+
+	stop := timePhase("open store")
+	defer stop()
+	db, err := openStore(backend)
+	if errors.Is(err, ErrStoreLocked) {
+		return nil, WithHint(err, "wait for it to finish, or run read-only commands like scratch list meanwhile")
+	}
+	if err != nil {
+		return nil, WithHint(fmt.Errorf("get db: %w: %w", ErrStore, err), "run 'scratch doctor' to check the store")
+	}
+	if err := UnlockStore(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	db = NewWorkspaceStore(db, workspace)
+	migrated, err := MigrateSpecKeys(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate keys: %w", err)
+	}
+	if migrated > 0 {
+		output.Info("Migrated %d environments to ULID keys", migrated)
+	}
+
+	c.store = db
+
+	return db, nil
+}
+
+// ReadOnlyStore lazily retrieves Storer for commands that only read,
+// falling back to a snapshot if another instance holds the store
+func (c *CLIContext) ReadOnlyStore() (Storer, error) {
+	store, err := c.Store()
+	if !errors.Is(err, ErrStoreLocked) {
+		return store, err
+	}
+
+	backend, err := c.Backend()
+	if err != nil {
+		return nil, err
+	}
+
+	output.Warn("Another scratch instance is running, reading from a snapshot")
+	db, err := OpenSnapshot(backend)
+	if err != nil {
+		return nil, WithHint(fmt.Errorf("get db snapshot: %w: %w", ErrStore, err), "run 'scratch doctor' to check the store")
+	}
+	if err := UnlockStore(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	c.store = NewWorkspaceStore(db, workspace)
+
+	return c.store, nil
+}
+
+// Close releases the Storer if it was retrieved
+func (c *CLIContext) Close() error {
+	if c.store == nil {
+		return nil
+	}
+	store := c.store
+	c.store = nil
+	return store.Close()
+}
+
+// NewCmd represents the command to create a new environment
+type NewCmd struct {
+	Name         string            `arg:"" help:"The name of environment" required:""`
+	Type         SpecType          `short:"t" help:"The type of environment" default:"python"`
+	Directory    string            `short:"d" xor:"dir" help:"The parent output directory"`
+	Here         bool              `xor:"dir" help:"Provision the environment in the current directory, like a subfolder of a repository, which delete keeps"`
+	For          string            `help:"The project the environment is an experiment for, listed with list --for" type:"existingdir"`
+	Open         string            `short:"o" help:"Open folder in program, default for the file manager of the platform, or tmux or zellij for a terminal session" default:"code"`
+	NoOpen       bool              `help:"Don't open folder"`
+	NoReadme     bool              `help:"Don't write README.md describing the environment, with its metadata for adopt"`
+	Description  string            `help:"A note describing the environment"`
+	Vars         map[string]string `name:"var" help:"Variable for templates or answer for cookiecutter and copier prompts as key=value"`
+	Env          map[string]string `name:"env" help:"Environment variable written to .env as KEY=VALUE, loaded by run and shell"`
+	TTL          Duration          `name:"ttl" help:"Expire the environment after this long, like 14d"`
+	Slugify      bool              `help:"Replace characters not allowed in names with - instead of failing"`
+	From         string            `xor:"from" help:"Create a template environment from a git repository like gh:user/repo or a local directory"`
+	Template     string            `xor:"from" help:"The template of cookiecutter and copier environments, like gh:user/repo"`
+	Answers      string            `type:"existingfile" help:"A cookiecutter config file or copier data file answering the template's prompts"`
+	Framework    string            `help:"The framework of web environments: vanilla, react, vue or svelte" enum:"vanilla,react,vue,svelte" default:"vanilla"`
+	TorchIndex   string            `help:"The index torch is installed from in ml environments, like https://download.pytorch.org/whl/cu124"`
+	Opts         map[string]string `name:"opt" help:"Option of the type as key=value, like --opt framework=react. ${type_options}"`
+	HelpType     HelpTypeFlag      `help:"Describe what a type creates, the tools it needs, its options and examples, and exit" placeholder:"TYPE"`
+	InstallTools bool              `help:"Offer to install programs the type needs when they are missing"`
+	// OpenExisting and EditorConfig are unset unless passed, so the flags can override config either way
+	OpenExisting *bool `negatable:"" aliases:"if-not-exists" help:"Open the environment if it already exists instead of failing"`
+	EditorConfig *bool `negatable:"" help:"Write VS Code settings for the type, like the interpreter of the virtual environment"`
+	Locked       bool  `help:"Protect the environment from delete and prune, see scratch unlock"`
+	Strict       bool  `help:"Refuse to create the environment when environments would exceed the disk quota instead of warning"`
+}
+
+// options returns the options of the provisioner of specType passed with --opt,
+// and with the flags that are shorthands for them
+func (c NewCmd) options(specType SpecType) map[string]string {
+	opts := maps.Clone(c.Opts)
+	if opts == nil {
+		opts = map[string]string{}
+	}
+	if _, ok := opts["framework"]; !ok && specType == WebSpec {
+		opts["framework"] = c.Framework
+	}
+	if c.TorchIndex != "" {
+		opts["torch-index"] = c.TorchIndex
+	}
+	return opts
+}
+
+// openExisting checks if an environment that already exists should be opened
+func (c NewCmd) openExisting(config Config) bool {
+	if c.OpenExisting != nil {
+		return *c.OpenExisting
+	}
+	return config.OpenExisting
+}
+
+// editorConfig checks if editor settings should be written
+func (c NewCmd) editorConfig(config Config) bool {
+	if c.EditorConfig != nil {
+		return *c.EditorConfig
+	}
+	return config.EditorConfig
+}
+
+// ttl returns the requested TTL, defaulting to the maximum allowed by policy
+func (c NewCmd) ttl(policy Policy) time.Duration {
+	if c.TTL == 0 {
+		return time.Duration(policy.MaxTTL)
+	}
+	return time.Duration(c.TTL)
+}
+
+// resolveOutputDir resolves the absolute path to which the new environment is created in
+// and the root rule that chose it, if any
+func (c NewCmd) resolveOutputDir(config Config) (string, string, error) {
+	var dir = c.Directory
+	var root string
+	if dir == "" {
+		if rule, ok := config.MatchRoot(c.Name, c.Type); ok {
+			dir = rule.Path
+			root = rule.Path
+		} else {
+			ddir, err := DefaultDataDir()
+			if err != nil {
+				return "", "", err
+			}
+			dir = ddir
+		}
+	}
+
+	dir, err := ExpandHome(dir)
+	if err != nil {
+		return "", "", err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	if root != "" {
+		root = abs
+	}
+	return abs, root, nil
+}
+
+// Spec creates a Spec based on user input
+func (c NewCmd) spec(config Config) (Spec, error) {
+	outputDir, root, err := c.resolveOutputDir(config)
+	if err != nil {
+		return Spec{}, err
+	}
+	spec := NewSpec(c.Name, c.Type, outputDir)
+	if c.Here {
+		if spec.Path, err = os.Getwd(); err != nil {
+			return Spec{}, err
+		}
+		spec.InPlace = true
+	}
+	spec.Created = time.Now()
+	spec.Root = root
+	spec.Description = c.Description
+	spec.Locked = c.Locked
+	spec.Parent = c.For
+	return spec, nil
+}
+
+// Run provisions the new environment and saves the spec
+func (c NewCmd) Run(ctx *CLIContext) error {
+	_, err := c.Create(ctx)
+	return err
+}
+
+// Create provisions the new environment, or opens the existing one when asked to,
+// and returns its spec
+func (c NewCmd) Create(ctx *CLIContext) (Spec, error) {
+	config, err := ctx.Config()
+	if err != nil {
+		return Spec{}, err
+	}
+
+	policy, err := ctx.Policy()
+	if err != nil {
+		return Spec{}, err
+	}
+
+	if c.Slugify {
+		slug := Slugify(c.Name)
+		if slug != c.Name {
+			output.Info("Normalized name %q to %q", c.Name, slug)
+		}
+		c.Name = slug
+	}
+
+	var source TemplateSource
+	if c.From != "" {
+		if source, err = ParseTemplateSource(c.From); err != nil {
+			return Spec{}, err
+		}
+		c.Type = TemplateSpec
+	}
+
+	spec, err := c.spec(config)
+	if err != nil {
+		return Spec{}, err
+	}
+	spec.Template = source
+	generator := spec.Type == CookiecutterSpec || spec.Type == CopierSpec
+	if c.Template != "" {
+		if !generator {
+			return Spec{}, fmt.Errorf("--template requires --type cookiecutter or copier")
+		}
+		spec.Template = TemplateSource(c.Template)
+	}
+	if c.TorchIndex != "" && spec.Type != MLSpec {
+		return Spec{}, fmt.Errorf("--torch-index requires --type ml")
+	}
+	opts := c.options(spec.Type)
+	if spec.Type == WebSpec {
+		spec.Framework = opts["framework"]
+	}
+	ttl := c.ttl(policy)
+	if ttl > 0 {
+		spec.Expires = time.Now().Add(ttl)
+	}
+
+	store, err := ctx.Store()
+	if err != nil {
+		return Spec{}, err
+	}
+
+	if c.openExisting(config) {
+		existing, ok, err := FindExisting(store, spec)
+		if err != nil {
+			return Spec{}, err
+		}
+		if ok {
+			output.Info("Opening existing %s at %s", existing.ID(), existing.Path)
+			if c.NoOpen {
+				return existing, nil
+			}
+			return existing, openEnvironment(ctx, store, NewOpener(c.Open), existing)
+		}
+	}
+
+	if c.InstallTools {
+		if p, err := config.Provisioner(spec.Type); err == nil {
+			if err := installMissingTools(p); err != nil {
+				return Spec{}, err
+			}
+		}
+	}
+
+	data, err := NewTemplateData(spec, c.Vars, time.Now())
+	if err != nil {
+		return Spec{}, err
+	}
+	s := NewScaffolder(spec).WithConfig(config)
+	if source != "" {
+		s = s.WithProvisioner(TemplateEnvironment{Source: source, Data: data})
+	}
+	if len(opts) > 0 {
+		p, err := config.Provisioner(spec.Type)
+		if err != nil {
+			return Spec{}, err
+		}
+		if p, err = ApplyOptions(spec.Type, p, opts); err != nil {
+			return Spec{}, err
+		}
+		s = s.WithProvisioner(p)
+	}
+	if generator {
+		s = s.WithProvisioner(GeneratorEnvironment{Generator: string(spec.Type), Template: c.Template, Answers: c.Vars, AnswersFile: c.Answers})
+	}
+	if dir, ok := config.Templates[spec.Type]; ok {
+		s = s.WithTemplate(dir, data)
+	}
+	errs := s.Preflight(store)
+	errs = append(errs, policy.Check(spec)...)
+	if err := ValidateEnvKeys(c.Env); err != nil {
+		errs = append(errs, err)
+	}
+	if err := policy.CheckTTL(ttl); err != nil {
+		errs = append(errs, err)
+	}
+	if err := CheckDiskSpace(spec.Path, config.RequiredSpace(spec.Type)); err != nil {
+		errs = append(errs, err)
+	}
+	if config.Disk.Quota > 0 {
+		specs, err := LoadSpecs(store)
+		if err != nil {
+			return Spec{}, err
+		}
+		if err := checkQuota(store, specs, config); err != nil {
+			if c.Strict {
+				errs = append(errs, err)
+			} else {
+				output.Warn("%s", err)
+			}
+		}
+	}
+	if !c.NoOpen {
+		if err := OpenerExists(c.Open); err != nil {
+			errs = append(errs, fmt.Errorf("cannot open folder: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		return Spec{}, PreflightError{errs}
+	}
+
+	start := time.Now()
+	log, err := s.Build()
+	if err != nil {
+		return Spec{}, err
+	}
+	took := time.Since(start)
+	if len(c.Env) > 0 {
+		if err := WriteDotEnv(spec.Path, c.Env); err != nil {
+			return Spec{}, err
+		}
+		spec.Env = slices.Sorted(maps.Keys(c.Env))
+	}
+	if c.editorConfig(config) {
+		if _, err := WriteEditorConfig(spec.Path, spec.Type); err != nil {
+			output.Warn("%s", err)
+		}
+	}
+	refreshMarker(spec)
+	if !c.NoReadme {
+		if _, err := WriteReadme(spec); err != nil {
+			output.Warn("%s", err)
+		}
+	}
+	manifest, err := s.Manifest()
+	if err != nil {
+		output.Warn("%s", err)
+	}
+
+	if err := spec.Save(store); err != nil {
+		return Spec{}, err
+	}
+	if err := SaveProvisionLog(store, spec, log); err != nil {
+		output.Warn("%s", err)
+	}
+	if manifest != nil {
+		if err := SaveManifest(store, spec, manifest); err != nil {
+			output.Warn("%s", err)
+		}
+	}
+	event := NewEvent(ActionCreate, spec).With("from", c.From).With("template", c.Template).With("description", redactSealed(spec).Description)
+	if ttl > 0 {
+		event = event.With("ttl", Duration(ttl).String())
+	}
+	event.Seconds = took.Round(time.Millisecond).Seconds()
+	recordEvent(event)
+	output.Success("Created %s at %s", spec.ID(), spec.Path)
+
+	if !c.NoOpen {
+		if err := openFolder(ctx, NewOpener(c.Open), spec); err != nil {
+			return Spec{}, err
+		}
+	}
+
+	return spec, nil
+}
+
+// ListCmd represents the command to list all available environments
+type ListCmd struct {
+	DirectoryOnly bool     `short:"d" name:"directories" xor:"only" help:"List directories only"`
+	Names         bool     `xor:"only" help:"List names only, once each, for shell completion"`
+	Orphans       bool     `help:"List directories in the data directory and roots not tracked by any environment"`
+	Size          bool     `short:"s" help:"Show disk usage of each environment"`
+	Columns       []string `short:"c" help:"Columns to show (${enum})" enum:"uid,name,type,age,used,opens,size,path,status,tags,description,expires,for" default:"name,type,age,path"`
+	Recent        bool     `short:"r" help:"Sort by most recently used and number rows for open @N"`
+	Sort          string   `help:"Sort by name, created for the newest first, or used for the most opened first" enum:"name,created,used" default:"name"`
+	Type          SpecType `short:"t" help:"Only environments of this type"`
+	All           bool     `short:"a" help:"Include hidden environments"`
+	Format        string   `short:"f" help:"Print a table, or JSON for the Alfred or Raycast launchers (${enum})" enum:"table,alfred,raycast" default:"table"`
+	// GroupBy is empty unless passed, as kong enums need a default
+	GroupBy string `placeholder:"type|tag|parent-dir" help:"Print environments in sections by type, tag or parent directory, with the number in each"`
+	FilterFlags
+}
+
+// listColumn formats the value of a column for spec
+func listColumn(column string, spec Spec, now time.Time) string {
+	switch column {
+	case "uid":
+		return spec.UID
+	case "name":
+		return spec.Name
+	case "type":
+		return string(spec.Type)
+	case "age":
+		if spec.Created.IsZero() {
+			return "-"
+		}
+		return FormatDuration(now.Sub(spec.Created))
+	case "used":
+		if spec.LastActive().IsZero() {
+			return "-"
+		}
+		return FormatDuration(now.Sub(spec.LastActive()))
+	case "opens":
+		return strconv.Itoa(spec.Opens)
+	case "size":
+		if spec.Usage == nil {
+			return "-"
+		}
+		return spec.Usage.Size.String()
+	case "path":
+		return spec.Path
+	case "status":
+		switch {
+		case spec.IsArchived():
+			return "archived"
+		case spec.IsDeprovisioned():
+			return "deprovisioned"
+		case spec.IsExpired(now):
+			return "expired"
+		default:
+			return "active"
+		}
+	case "tags":
+		return strings.Join(spec.Tags, ",")
+	case "description":
+		return spec.Description
+	case "expires":
+		if spec.Expires.IsZero() {
+			return "-"
+		}
+		return spec.Expires.Local().Format(time.DateTime)
+	case "for":
+		return cmp.Or(spec.Parent, "-")
+	}
+	return ""
+}
+
+// Validate checks sorting does not conflict with the numbering of --recent
+func (l ListCmd) Validate() error {
+	if l.Recent && l.Sort != "name" {
+		return fmt.Errorf("--recent cannot be used with --sort")
+	}
+	if l.Format != TableFormat && (l.DirectoryOnly || l.Names || l.Orphans) {
+		return fmt.Errorf("--format cannot be used with --directories, --names or --orphans")
+	}
+	if l.GroupBy != "" {
+		if !slices.Contains(listGroups, l.GroupBy) {
+			return fmt.Errorf("--group-by must be type, tag or parent-dir")
+		}
+		if l.Format != TableFormat || l.DirectoryOnly || l.Names || l.Orphans {
+			return fmt.Errorf("--group-by cannot be used with --format, --directories, --names or --orphans")
+		}
+	}
+	return nil
+}
+
+// listGroups are what list --group-by groups environments by
+var listGroups = []string{"type", "tag", "parent-dir"}
+
+// groupKeys returns the groups of --group-by spec is listed in, every tag for tags and
+// none for untagged environments
+func (l ListCmd) groupKeys(spec Spec) []string {
+	switch l.GroupBy {
+	case "type":
+		return []string{string(spec.Type)}
+	case "tag":
+		if len(spec.Tags) == 0 {
+			return []string{""}
+		}
+		return spec.Tags
+	case "parent-dir":
+		return []string{filepath.Dir(spec.Path)}
+	}
+	return nil
+}
+
+// listOrphans prints directories not tracked by any environment with the type they look like
+func (l ListCmd) listOrphans(ctx *CLIContext, store Storer) error {
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return err
+	}
+	orphans, err := findOrphans(ctx, specs)
+	if err != nil {
+		return err
+	}
+	for _, dir := range orphans {
+		fmt.Printf("%s\t%s\n", dir, describeDetection(dir))
+	}
+	return nil
+}
+
+// Run retrieves all available environments and prints them out
+func (l ListCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.ReadOnlyStore()
+	if err != nil {
+		return err
+	}
+
+	if l.Orphans {
+		return l.listOrphans(ctx, store)
+	}
+
+	columns := l.Columns
+	if l.Size && !slices.Contains(columns, "size") {
+		columns = append(columns, "size")
+	}
+	table := Table{Header: columns}
+	groups := map[string][][]string{}
+
+	now := time.Now()
+	expired := 0
+	specs := []Spec{}
+	listFunc := func(spec Spec) error {
+		if spec.IsExpired(now) {
+			expired++
+		}
+
+		if !spec.Exists() && !spec.IsArchived() && !spec.IsDeprovisioned() {
+			slog.Error("Environment does not exist",
+				slog.String("name", spec.Name),
+				slog.String("path", spec.Path),
+				slog.String("id", spec.ID()),
+			)
+			return nil
+		}
+
+		specs = append(specs, spec)
+		return nil
+	}
+
+	// @N counts environments of every type, so only lists without it are scoped by type
+	scope := l.Type
+	if l.Recent {
+		scope = ""
+	}
+	if err := ForEachSpecOfType(store, scope, listFunc); err != nil {
+		return err
+	}
+
+	slices.SortFunc(specs, func(a, b Spec) int {
+		return cmp.Or(cmp.Compare(a.ID(), b.ID()), cmp.Compare(a.Path, b.Path))
+	})
+	switch l.Sort {
+	case "created":
+		slices.SortStableFunc(specs, func(a, b Spec) int {
+			return b.Created.Compare(a.Created)
+		})
+	case "used":
+		SortByOpens(specs)
+	}
+	if l.Recent {
+		SortByRecent(specs)
+		table.Header = append([]string{"@"}, columns...)
+	}
+	filter := l.Filter(l.Type)
+	names := map[string]bool{}
+	launched := []Spec{}
+	n := 0
+	for _, spec := range specs {
+		// Archived environments cannot be opened by @N
+		ref := "-"
+		if !spec.IsArchived() {
+			n++
+			ref = fmt.Sprintf("@%d", n)
+		}
+		// Filtered and hidden rows keep the numbers open @N uses
+		if !filter.Match(spec, now) || (spec.Hidden && !l.All) {
+			continue
+		}
+
+		if l.DirectoryOnly {
+			fmt.Println(spec.Path)
+			continue
+		}
+		if l.Names {
+			if !names[spec.Name] {
+				fmt.Println(spec.Name)
+			}
+			names[spec.Name] = true
+			continue
+		}
+		if l.Format != TableFormat {
+			launched = append(launched, spec)
+			continue
+		}
+
+		if slices.Contains(columns, "size") && !spec.IsDeprovisioned() {
+			if measured := measureSpecs(store, []Spec{spec}, false); len(measured) > 0 {
+				spec = measured[0]
+			}
+		}
+		row := []string{}
+		if l.Recent {
+			row = append(row, ref)
+		}
+		for _, column := range columns {
+			row = append(row, listColumn(column, spec, now))
+		}
+		if l.GroupBy != "" {
+			for _, key := range l.groupKeys(spec) {
+				groups[key] = append(groups[key], row)
+			}
+			continue
+		}
+		table.Append(row...)
+	}
+	switch {
+	case l.GroupBy != "":
+		if err := WriteGroups(os.Stdout, table.Header, groups, "untagged", IsTerminal(os.Stdout)); err != nil {
+			return err
+		}
+	case l.Format != TableFormat:
+		if err := WriteLauncherItems(os.Stdout, l.Format, launched, now); err != nil {
+			return err
+		}
+	case !l.DirectoryOnly && !l.Names:
+		if err := table.Write(os.Stdout, IsTerminal(os.Stdout)); err != nil {
+			return err
+		}
+	}
+	if expired > 0 {
+		output.Warn("%d environments are past their TTL, run 'scratch prune --expired' to delete them", expired)
+	}
+	return l.warnOverQuota(ctx, store, specs)
+}
+
+// warnOverQuota names the largest environments when they exceed the disk quota,
+// measuring every environment when the listing was scoped to a type
+func (l ListCmd) warnOverQuota(ctx *CLIContext, store Storer, specs []Spec) error {
+	config, err := ctx.Config()
+	if err != nil || config.Disk.Quota == 0 {
+		return err
+	}
+	if l.Type != "" && !l.Recent {
+		if specs, err = LoadSpecs(store); err != nil {
+			return err
+		}
+	}
+	if err := checkQuota(store, specs, config); err != nil {
+		output.Warn("%s", err)
+	}
+	return nil
+}
+
+// Flags that identify an environment
+type IdentifyFlags struct {
+	Query string   `arg:"" optional:"" help:"All or part of the name of environment, or @N for the Nth most recently used"`
+	ID    string   `help:"The ULID or type:name ID of environment"`
+	Name  string   `short:"n" help:"The exact name of environment"`
+	Type  SpecType `short:"t" help:"The type of environment, python by default with --name"`
+}
+
+// nameType returns the type the environment given by --name has
+func (f IdentifyFlags) nameType() SpecType {
+	return cmp.Or(f.Type, PythonSpec)
+}
+
+// IsSet checks if an environment was identified
+func (f IdentifyFlags) IsSet() bool {
+	return f.Query != "" || f.ID != "" || f.Name != ""
+}
+
+// Resolve finds the identified environment, asking which one is meant when several match
+func (f IdentifyFlags) Resolve(store ReadLister) (Spec, error) {
+	switch {
+	case f.ID != "":
+		return LookupID(store, f.ID)
+	case f.Name != "":
+		return ResolveName(store, f.nameType(), f.Name)
+	default:
+		return ResolveQuery(store, f.Query)
+	}
+}
+
+func (f IdentifyFlags) Validate() error {
+	set := 0
+	for _, v := range []string{f.Query, f.ID, f.Name} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("specify only one of a name query, --id or --name")
+	}
+	if set == 0 {
+		return fmt.Errorf("must specify a name query, --id or --name")
+	}
+	return nil
+}
+
+// DeleteCmd represents the command to delete an environment or environments
+type DeleteCmd struct {
+	IdentifyFlags
+	FilterFlags
+	Path      string `help:"The directory of environment" type:"path"`
+	Force     bool   `short:"f" help:"Delete without confirmation"`
+	All       bool   `help:"Delete all environments"`
+	Permanent bool   `xor:"removal" help:"Remove environments immediately instead of moving them to the trash"`
+	Trash     bool   `xor:"removal" help:"Move environments to the trash of the system, the Finder Trash, Recycle Bin or freedesktop.org trash, instead of the trash of scratch"`
+	// KeepFiles and FilesOnly delete only the record or only the directory of environments
+	KeepFiles bool `xor:"removal,scope" help:"Stop tracking environments but leave their directories"`
+	FilesOnly bool `xor:"scope" help:"Remove the directories of environments but keep tracking them, to recreate with scratch reprovision"`
+	// IncludeLocked deletes locked environments too, which are refused or skipped otherwise
+	IncludeLocked bool `help:"Delete locked environments too"`
+	// Unsafe deletes directories outside the data directory and roots, which are refused or skipped otherwise
+	Unsafe bool `help:"Delete environments whose directories are not inside the data directory or a configured root"`
+	// NoGuard deletes directories that do not look like environments scratch created
+	NoGuard bool `help:"Delete environments whose directories have neither their marker file nor the files scratch scaffolds"`
+}
+
+// filter returns the filter of --type, --tag and --older-than
+func (d DeleteCmd) filter() Filter {
+	return d.Filter(d.Type)
+}
+
+// Validate checks the combination of flags
+func (d DeleteCmd) Validate() error {
+	if d.All && d.Path != "" {
+		return fmt.Errorf("--all cannot be used with --path")
+	}
+	if d.bulk() {
+		if d.Query != "" || d.ID != "" || (d.Name != "" && (d.All || d.Path != "")) {
+			return fmt.Errorf("--all, --path and filters cannot be used with a specific environment")
+		}
+		return nil
+	}
+
+	if !d.IsSet() {
+		return fmt.Errorf("must specify a name query, --id, --name, --path, filters or --all")
+	}
+	return d.IdentifyFlags.Validate()
+}
+
+// bulk checks if the flags can select several environments
+func (d DeleteCmd) bulk() bool {
+	return d.All || d.Path != "" || IsNamePattern(d.Name) || d.Narrows() || (d.Type != "" && !d.IsSet())
+}
+
+// specs finds every environment selected by --all, --path, a --name pattern or filters
+func (d DeleteCmd) specs(store ReadLister) ([]Spec, error) {
+	var specs []Spec
+	var err error
+	switch {
+	case d.Path != "":
+		specs, err = FindSpecsAtPath(store, d.Path)
+	case d.Name != "":
+		specs, err = MatchNamePattern(store, d.Type, d.Name)
+	default:
+		specs, err = LoadSpecsOfType(store, d.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d.filter().Apply(specs, time.Now()), nil
+}
+
+// checkRemovable checks spec can be deleted with the flags, and that its directory is
+// inside roots and looks like an environment, unless --unsafe and --no-guard skip the checks
+func (d DeleteCmd) checkRemovable(spec Spec, config Config, roots []string) error {
+	switch {
+	case (d.KeepFiles || d.FilesOnly) && spec.IsArchived():
+		return fmt.Errorf("%s is archived, unarchive it first", spec.ID())
+	case d.FilesOnly && spec.InPlace:
+		return fmt.Errorf("%s was provisioned in place, whose directory is never removed", spec.ID())
+	case d.FilesOnly && spec.IsDeprovisioned():
+		return fmt.Errorf("the files of %s were already removed", spec.ID())
+	case d.KeepFiles:
+		return nil
+	}
+	if !d.Unsafe {
+		if err := checkSpecDeletable(spec, roots); err != nil {
+			return WithHint(err, "pass --unsafe if it is meant to be deleted")
+		}
+	}
+	if !d.NoGuard && !spec.InPlace {
+		if err := CheckGuard(spec, config); err != nil {
+			return WithHint(err, "check what it holds, and pass --no-guard if it is meant to be deleted")
+		}
+	}
+	return nil
+}
+
+// target describes the environment to delete in confirmations, with the files that would be removed
+func (d DeleteCmd) target(spec Spec) string {
+	target := fmt.Sprintf("%s at %s", spec.ID(), spec.Path)
+	switch {
+	case d.KeepFiles:
+		return target + ", keeping its files"
+	case spec.InPlace || !spec.Exists():
+		return target
+	}
+	if summary, err := SummarizeDir(spec.Path); err == nil {
+		target += fmt.Sprintf(" (%s)", summary)
+	}
+	if d.FilesOnly {
+		target += ", keeping its record"
+	}
+	return target
+}
+
+// deleteEnv deletes the environment after confirmation
+func (d DeleteCmd) deleteEnv(store Writer, spec Spec) error {
+	if !d.Force {
+		ok, err := askForConfirmation(fmt.Sprintf("Delete %s?", d.target(spec)))
 		if err != nil {
-			return false, err
+			return err
+		}
+		if !ok {
+			output.Info("Not deleting %s", spec.ID())
+			return nil
+		}
+	}
+
+	return d.remove(store, spec)
+}
+
+// remove moves the environment to the trash, removes it with --permanent or moves
+// it to the trash of the system with --trash. --keep-files and --files-only delete
+// only its record or only its directory.
+func (d DeleteCmd) remove(store Writer, spec Spec) error {
+	switch {
+	case d.KeepFiles:
+		return discardEnvironment(store, spec, keepFiles)
+	case d.FilesOnly && d.Trash:
+		return deprovisionEnvironment(store, spec, osTrashFiles)
+	case d.FilesOnly:
+		return deprovisionEnvironment(store, spec, removeFiles)
+	case d.Permanent:
+		return removeEnvironment(store, spec)
+	case d.Trash:
+		return discardEnvironment(store, spec, osTrashFiles)
+	}
+	return trashEnvironment(store, spec)
+}
+
+// fileRemoval is what deleting an environment does with its directory
+type fileRemoval int
+
+const (
+	removeFiles fileRemoval = iota
+	osTrashFiles
+	keepFiles
+)
+
+// removeEnvironment removes the environment directory, its archive and its keys
+func removeEnvironment(store Writer, spec Spec) error {
+	return discardEnvironment(store, spec, removeFiles)
+}
+
+// removeDirectory removes the directory of spec, or moves it to the trash of the system
+func removeDirectory(spec Spec, files fileRemoval) error {
+	l := slog.With(slog.String("id", spec.ID()))
+	if files == osTrashFiles {
+		l.Info("Moving environment directory to the trash of the system")
+		if err := MoveToOSTrash(spec.Path); err != nil {
+			return fmt.Errorf("delete environment %q: %w", spec.ID(), err)
 		}
+		return nil
+	}
+	l.Info("Removing environment directory")
+	if err := removeTree(spec.Path); err != nil {
+		return fmt.Errorf("remove environment %q: %w", spec.ID(), err)
+	}
+	return nil
+}
+
+// discardEnvironment removes the keys of the environment, its archive and, unless it
+// was provisioned in place or files is keepFiles, its directory. Kept directories lose
+// their marker so they are not taken for lost environments.
+func discardEnvironment(store Writer, spec Spec, files fileRemoval) error {
+	l := slog.With(slog.String("id", spec.ID()))
+	event := NewEvent(ActionDelete, spec).With("description", redactSealed(spec).Description).With("tags", strings.Join(spec.Tags, ",")).WithContents(spec.Location())
 
-		if input == "y" || input == "yes" {
-			return true, nil
+	// Directories moved to the trash of the system are not removed permanently
+	osTrashed := false
+	keep := spec.InPlace || files == keepFiles
+	switch {
+	case keep:
+		l.Info("Keeping environment directory")
+		if err := removeMarker(spec.Path); err != nil {
+			return err
+		}
+		if !spec.InPlace {
+			event = event.With("keep_files", "true")
 		}
-		if input == "n" || input == "no" {
-			return false, nil
+	case spec.Exists():
+		if err := removeDirectory(spec, files); err != nil {
+			return err
 		}
+		osTrashed = files == osTrashFiles
+	}
+	if osTrashed {
+		event = event.With("trash", "system")
+	} else {
+		event = event.With("permanent", "true")
 	}
-}
 
-// CLIContext has common structs for commands
-type CLIContext struct {
-	store Storer
-}
+	if spec.IsArchived() {
+		l.Info("Removing archived environment")
+		if err := removeArchive(spec.Archive); err != nil {
+			return err
+		}
+	}
 
-// Store lazily retrieves Storer
-func (c CLIContext) Store() (Storer, error) {
-	if c.store != nil {
-		return c.store, nil
+	l.Debug("Deleting environment key")
+	if err := spec.Delete(store); err != nil {
+		return err
 	}
 
-	dir, err := DefaultConfigDir()
-	if err != nil {
-		return nil, err
+	recordEvent(event)
+	switch {
+	case keep:
+		output.Success("Deleted %s, keeping its directory %s", spec.ID(), spec.Path)
+	case event.Params["trash"] == "system":
+		output.Success("Deleted %s, its directory is in the trash of the system", spec.ID())
+	default:
+		output.Success("Deleted %s", spec.ID())
 	}
-	EnsureDirectory(dir)
+	return nil
+}
 
-	db, err := NewPebbleStore()
-	if err != nil {
-		return nil, fmt.Errorf("get db: %w", err)
+// deprovisionEnvironment removes the directory of the environment but keeps its record,
+// marked deprovisioned until scratch reprovision recreates the files
+func deprovisionEnvironment(store Writer, spec Spec, files fileRemoval) error {
+	event := NewEvent(ActionDelete, spec).With("files_only", "true").WithContents(spec.Location())
+	if spec.Exists() {
+		if err := removeDirectory(spec, files); err != nil {
+			return err
+		}
 	}
 
-	c.store = db
+	spec.Deprovisioned = time.Now()
+	spec.Usage = nil
+	if err := spec.Save(store); err != nil {
+		return err
+	}
 
-	return db, nil
+	recordEvent(event)
+	output.Success("Removed the files of %s, recreate them with scratch reprovision %s", spec.ID(), spec.Name)
+	return nil
 }
 
-// NewCmd represents the command to create a new environment
-type NewCmd struct {
-	Name      string   `arg:"" help:"The name of environment" required:""`
-	Type      SpecType `short:"t" help:"The type of environment" default:"python"`
-	Directory string   `short:"d" help:"The parent output directory"`
-	Open      string   `short:"o" help:"Open folder in program" default:"code"`
-	NoOpen    bool     `help:"Don't open folder"`
+// Run deletes environment by key, name and type or all enviroments
+func (d DeleteCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	if err := ctx.purgeTrash(store); err != nil {
+		return err
+	}
+	config, err := ctx.Config()
+	if err != nil {
+		return err
+	}
+	roots, err := EnvironmentRoots(config)
+	if err != nil {
+		return err
+	}
+	if !d.bulk() {
+		spec, err := d.Resolve(store)
+		if err != nil {
+			return err
+		}
+		if !d.IncludeLocked {
+			if err := spec.CheckUnlocked(); err != nil {
+				return err
+			}
+		}
+		if err := d.checkRemovable(spec, config, roots); err != nil {
+			return err
+		}
+		return d.deleteEnv(store, spec)
+	}
+
+	specs, err := d.specs(store)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(specs) > 0:
+	case d.All:
+		output.Info("No environments to delete")
+		return nil
+	case d.Path != "":
+		return fmt.Errorf("no environment at %s: %w", d.Path, ErrNotFound)
+	case d.Name != "":
+		return fmt.Errorf("no environments match %q: %w", d.Name, ErrNotFound)
+	default:
+		output.Info("No environments match the filters")
+		return nil
+	}
+	if !d.IncludeLocked {
+		if specs = withoutLocked(specs, "delete"); len(specs) == 0 {
+			return nil
+		}
+	}
+	check := func(spec Spec) error { return d.checkRemovable(spec, config, roots) }
+	if specs = withoutRefused(specs, check); len(specs) == 0 {
+		return nil
+	}
+	return d.deleteAll(ctx, store, specs)
 }
 
-// resolveOutputDir resolves the absolute path to which the new environment is created in
-func (c NewCmd) resolveOutputDir() (string, error) {
-	var dir = c.Directory
-	if dir == "" {
-		ddir, err := DefaultDataDir()
+// deleteAll deletes specs after listing them and confirming once
+func (d DeleteCmd) deleteAll(ctx *CLIContext, store Storer, specs []Spec) error {
+	if !d.Force {
+		items := make([]string, len(specs))
+		for i, spec := range specs {
+			items[i] = d.target(spec)
+		}
+		ok, err := confirmAll(fmt.Sprintf("Delete these %d environments?", len(specs)), items)
 		if err != nil {
-			return "", err
+			return err
+		}
+		if !ok {
+			output.Info("Not deleting environments")
+			return nil
 		}
-		dir = ddir
+	}
+	if err := ctx.backupBefore(store, "delete"); err != nil {
+		return err
 	}
 
-	abs, err := filepath.Abs(dir)
-	if err != nil {
-		return "", err
+	slog.Debug("Deleting environments", slog.Int("count", len(specs)))
+	for _, spec := range specs {
+		if err := d.remove(store, spec); err != nil {
+			return err
+		}
 	}
-	return abs, nil
+	return nil
 }
 
-// Spec creates a Spec based on user input
-func (c NewCmd) spec() (Spec, error) {
-	outputDir, err := c.resolveOutputDir()
-	if err != nil {
-		return Spec{}, err
+type OpenCmd struct {
+	IdentifyFlags
+	Last bool   `help:"Open the most recently used environment"`
+	Open string `short:"o" help:"Open environment in program, default for the file manager of the platform, or tmux or zellij for a terminal session" default:"code"`
+}
+
+func (o OpenCmd) Validate() error {
+	if !o.Last {
+		return o.IdentifyFlags.Validate()
+	}
+	if o.IsSet() {
+		return fmt.Errorf("--last cannot be used with a specific environment")
 	}
-	return NewSpec(c.Name, c.Type, outputDir), nil
+	return nil
 }
 
-// Run provisions the new environment and saves the spec
-func (c NewCmd) Run(ctx *CLIContext) error {
-	spec, err := c.spec()
-	if err != nil {
-		return err
+// spec finds the environment by recent use or by query
+func (o OpenCmd) spec(store Storer) (Spec, error) {
+	if o.Last {
+		return ResolveQuery(store, "@1")
 	}
+	return o.Resolve(store)
+}
 
+// Run opens environment by key, name and type or recent use
+func (o OpenCmd) Run(ctx *CLIContext) error {
 	store, err := ctx.Store()
 	if err != nil {
 		return err
 	}
 
-	exists, err := store.Exists(spec.ID())
+	spec, err := o.spec(store)
 	if err != nil {
 		return err
 	}
-	if exists {
-		return fmt.Errorf("environment %q already exists elsewhere", spec.ID())
+
+	return openEnvironment(ctx, store, NewOpener(o.Open), spec)
+}
+
+// openEnvironment opens spec with opener and records it was used. Sessions taking
+// over the terminal are recorded before attaching, as the store is released first.
+func openEnvironment(ctx *CLIContext, store Writer, opener Opener, spec Spec) error {
+	if !opener.Attaches() {
+		if err := openFolder(ctx, opener, spec); err != nil {
+			return err
+		}
 	}
 
-	s := Scaffolder{spec}
-	if err := s.Build(); err != nil {
+	spec, err := markUsed(store, spec, opener.Program())
+	if err != nil {
 		return err
 	}
+	if opener.Attaches() {
+		return openFolder(ctx, opener, spec)
+	}
+	return nil
+}
 
+// markUsed saves that spec was used now and records it in the history
+func markUsed(store Writer, spec Spec, program string) (Spec, error) {
+	spec = spec.MarkUsed(time.Now())
 	if err := spec.Save(store); err != nil {
-		return err
+		return spec, err
 	}
+	recordEvent(NewEvent(ActionOpen, spec).With("with", program))
+	return spec, nil
+}
 
-	if !c.NoOpen {
-		if err := OpenFolder(c.Open, spec.Path); err != nil {
+// openFolder opens spec with opener, releasing the store when the opener takes
+// over the terminal so scratch can be used inside the session
+func openFolder(ctx *CLIContext, opener Opener, spec Spec) error {
+	defer timePhase("open folder")()
+	if opener.Attaches() {
+		if err := ctx.Close(); err != nil {
 			return err
 		}
 	}
+	return opener.Open(spec.Name, spec.Path)
+}
+
+// EditCmd represents the command to edit tags and metadata of many environments
+type EditCmd struct {
+	Match     string            `short:"m" help:"Glob pattern matching environment names" required:""`
+	AddTag    []string          `help:"Tags to add"`
+	RemoveTag []string          `help:"Tags to remove"`
+	SetMeta   map[string]string `help:"Metadata to set as key=value"`
+	UnsetMeta []string          `help:"Metadata keys to remove"`
+	DryRun    bool              `help:"Preview affected environments without writing"`
+	Force     bool              `short:"f" help:"Apply without confirmation"`
+}
 
+// Validate checks the pattern and that there is something to edit
+func (e EditCmd) Validate() error {
+	if _, err := path.Match(e.Match, ""); err != nil {
+		return fmt.Errorf("invalid --match pattern: %w", err)
+	}
+	if e.edit().String() == "" {
+		return fmt.Errorf("must specify at least one of --add-tag, --remove-tag, --set-meta or --unset-meta")
+	}
 	return nil
 }
 
-// ListCmd represents the command to list all available environments
-type ListCmd struct {
-	DirectoryOnly bool `short:"d" name:"directories" help:"List directories only"`
+func (e EditCmd) edit() SpecEdit {
+	return SpecEdit{
+		AddTags:    e.AddTag,
+		RemoveTags: e.RemoveTag,
+		SetMeta:    e.SetMeta,
+		UnsetMeta:  e.UnsetMeta,
+	}
 }
 
-// Run retrieves all available environments and prints them out
-func (l ListCmd) Run(ctx *CLIContext) error {
-	listFunc := func(key string, data []byte) error {
-		spec, err := LoadSpec(data)
-		if err != nil {
-			return err
-		}
+// Run previews the edit for all matching environments and applies it after confirmation
+func (e EditCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
 
-		if !spec.Exists() {
-			slog.Error("Environment does not exist",
-				slog.String("name", spec.Name),
-				slog.String("path", spec.Path),
-				slog.String("id", spec.ID()),
-			)
+	edit := e.edit()
+	edited := []Spec{}
+	err = ForEachSpec(store, func(spec Spec) error {
+		if ok, _ := path.Match(e.Match, spec.Name); !ok {
 			return nil
 		}
-
-		if l.DirectoryOnly {
-			fmt.Println(spec.Path)
-		} else {
-			fmt.Println(spec)
+		if spec, changed := edit.Apply(spec); changed {
+			edited = append(edited, spec)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
+	if len(edited) == 0 {
+		output.Info("No environments match %q", e.Match)
 		return nil
 	}
 
-	store, err := ctx.Store()
-	if err != nil {
-		return err
+	fmt.Printf("Changes: %s\n", edit)
+	for _, spec := range edited {
+		fmt.Printf("  %s\n", spec.ID())
 	}
 
-	return store.ListFunc(listFunc)
-}
+	if e.DryRun {
+		return nil
+	}
 
-// Flags that identify an environment
-type IdentifyFlags struct {
-	ID   string   `help:"The ID of environment"`
-	Name string   `short:"n" help:"The name of environment"`
-	Type SpecType `short:"t" help:"The type of environment" default:"python"`
-}
+	if !e.Force {
+		ok, err := askForConfirmation(fmt.Sprintf("Edit %d environments?", len(edited)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			output.Info("Not editing environments")
+			return nil
+		}
+	}
 
-func (f IdentifyFlags) Validate() error {
-	if f.ID != "" {
-		if f.Name != "" {
-			return fmt.Errorf("specify either --id OR --name, not both")
+	for _, spec := range edited {
+		if err := spec.Save(store); err != nil {
+			return err
 		}
-		return nil
 	}
 
-	if f.Name != "" {
-		return nil
+	output.Success("Edited %d environments", len(edited))
+	return nil
+}
+
+// MigrateCmd represents the command to upgrade stored specs to the current schema
+type MigrateCmd struct{}
+
+// Run saves every spec stored with an older schema version in the current one
+func (m MigrateCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
 	}
 
-	return fmt.Errorf("must specify --id or --name")
+	count, err := MigrateSpecs(store)
+	if err != nil {
+		return fmt.Errorf("migrate specs: %w", err)
+	}
+	if count == 0 {
+		output.Info("All environments are at schema version %d", SpecSchema)
+		return nil
+	}
+	output.Success("Migrated %d environments to schema version %d", count, SpecSchema)
+	return nil
 }
 
-// DeleteCmd represents the command to delete an environment or environments
-type DeleteCmd struct {
-	IdentifyFlags
-	Force bool `short:"f" help:"Delete without confirmation"`
-	All   bool `help:"Delete all environments"`
+// MigrateStoreCmd represents the command to copy environments between storage backends
+type MigrateStoreCmd struct {
+	From   StoreBackend `help:"The backend to copy from" enum:"pebble,json" default:"pebble"`
+	To     StoreBackend `help:"The backend to copy to" enum:"pebble,json" default:"json"`
+	Switch bool         `help:"Use the new backend by default"`
 }
 
-// Validate checks the combination of flags
-func (d DeleteCmd) Validate() error {
-	if d.All {
-		if d.ID != "" || d.Name != "" {
-			return fmt.Errorf("--all cannot be used with a specific ID or Name")
-		}
-		return nil
+// Validate checks the backends differ
+func (m MigrateStoreCmd) Validate() error {
+	if m.From == m.To {
+		return fmt.Errorf("--from and --to must be different backends")
 	}
+	return nil
+}
 
-	if err := d.IdentifyFlags.Validate(); err != nil {
+// Run copies every key from one backend to the other
+func (m MigrateStoreCmd) Run(ctx *CLIContext) error {
+	dir, err := DefaultConfigDir()
+	if err != nil {
 		return err
 	}
+	EnsureDirectory(dir)
 
-	return fmt.Errorf("must specify --id, --name, or --all")
-}
-
-// deleteKeyEnv deletes key and environment if it exists
-func (d DeleteCmd) deleteKeyEnv(store Storer, key string, force bool) error {
-	l := slog.With(slog.String("id", key))
-	l.Debug("Get environment data")
-	data, err := store.Get(key)
+	src, err := OpenStore(m.From)
 	if err != nil {
-		return fmt.Errorf("get environment %q data: %w", key, err)
+		return fmt.Errorf("open %s store: %w", m.From, err)
 	}
+	defer src.Close()
 
-	spec, err := LoadSpec(data)
+	dst, err := OpenStore(m.To)
 	if err != nil {
+		return fmt.Errorf("open %s store: %w", m.To, err)
+	}
+	defer dst.Close()
+
+	count := 0
+	err = dst.Batch(func(w Writer) error {
+		count, err = CopyStore(src, w)
 		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migrate store: %w", err)
 	}
+	output.Success("Copied %d environments from %s to %s", count, m.From, m.To)
 
-	if !force {
-		ok, err := askForConfirmation(fmt.Sprintf("Delete %s?", key))
+	if m.Switch {
+		config, err := ctx.Config()
 		if err != nil {
 			return err
 		}
-		if !ok {
-			l.Info("Not deleting environment")
-			return nil
+		config.Store = m.To
+		if err := config.Save(); err != nil {
+			return err
 		}
+		output.Success("Switched default store backend to %s", m.To)
 	}
 
-	if spec.Exists() {
-		l.Info("Removing environment directory")
-		if err := os.RemoveAll(spec.Path); err != nil {
-			return fmt.Errorf("remove environment %q: %w", key, err)
-		}
+	return nil
+}
+
+// NoteCmd represents the command to show or set the description of an environment
+type NoteCmd struct {
+	Name  string   `arg:"" help:"The name of environment"`
+	Text  []string `arg:"" optional:"" help:"The new description"`
+	Type  SpecType `short:"t" help:"The type of environment" default:"python"`
+	Clear bool     `help:"Remove the description"`
+}
+
+// Run prints the description or replaces it when text is given
+func (n NoteCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
 	}
 
-	l.Debug("Deleting environment key")
-	if err := store.Delete(key); err != nil {
+	spec, err := ResolveName(store, n.Type, n.Name)
+	if err != nil {
 		return err
 	}
 
-	slog.Info("Deleted environment", slog.String("id", key))
-	return nil
+	if len(n.Text) == 0 && !n.Clear {
+		if spec.Description != "" {
+			fmt.Println(spec.Description)
+		}
+		return nil
+	}
+
+	spec.Description = strings.Join(n.Text, " ")
+	return spec.Save(store)
 }
 
-// Run deletes environment by key, name and type or all enviroments
-func (d DeleteCmd) Run(ctx *CLIContext) error {
-	store, err := ctx.Store()
+// InfoCmd represents the command to show details of an environment
+type InfoCmd struct {
+	IdentifyFlags
+	Log     bool `xor:"show" help:"Show the commands run to provision the environment, their output and tool versions"`
+	AsFlags bool `xor:"show" help:"Print the scratch commands recreating the environment, to share how to reproduce it"`
+}
+
+// Run prints every field of the environment or its provisioning log
+func (i InfoCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.ReadOnlyStore()
 	if err != nil {
 		return err
 	}
-	if !d.All {
-		key := d.ID
-		if key == "" {
-			key = Spec{Name: d.Name, Type: SpecType(d.Type)}.ID()
-		}
 
-		if err := d.deleteKeyEnv(store, key, d.Force); err != nil {
-			return err
-		}
-		return nil
+	spec, err := i.Resolve(store)
+	if err != nil {
+		return err
 	}
 
-	slog.Info("Deleting all environments")
-	keys := []string{}
-	for key, err := range store.List() {
+	if i.Log {
+		log, err := LoadProvisionLog(store, spec)
 		if err != nil {
-			return fmt.Errorf("get all keys: %w", err)
+			return err
 		}
-		keys = append(keys, key)
+		log.Write(os.Stdout)
+		return nil
 	}
-
-	for _, key := range keys {
-		if err := d.deleteKeyEnv(store, key, d.Force); err != nil {
+	if i.AsFlags {
+		// Environments provisioned before logs were recorded are recreated without tool versions
+		log, err := LoadProvisionLog(store, spec)
+		if err != nil && !errors.Is(err, ErrNotFound) {
 			return err
 		}
+		fmt.Print(spec.Reproduce(log))
+		return nil
 	}
+
+	fmt.Print(spec.Details())
 	return nil
 }
 
-type OpenCmd struct {
-	IdentifyFlags
-	Open string `short:"o" help:"Open environment in program" default:"code"`
+// PromptInfo describes the current environment for prompt frameworks
+type PromptInfo struct {
+	Name   string   `json:"name"`
+	Type   SpecType `json:"type"`
+	Status string   `json:"status"`
+	// ExpiresIn is nil when the environment does not expire
+	ExpiresIn *string `json:"expires_in"`
 }
 
-func (o OpenCmd) Validate() error {
-	return o.IdentifyFlags.Validate()
+// PromptCmd represents the command to describe the environment of the working directory
+type PromptCmd struct {
+	JSON bool `help:"Output fields as JSON for prompt frameworks like starship"`
 }
 
-// Run opens environment by key or name and type
-func (o OpenCmd) Run(ctx *CLIContext) error {
-	store, err := ctx.Store()
+// Run prints the environment containing the working directory, if any
+func (p PromptCmd) Run(ctx *CLIContext) error {
+	wd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
-	key := o.ID
-	if key == "" {
-		key = SpecID(SpecType(o.Type), o.Name)
+	store, err := ctx.ReadOnlyStore()
+	if err != nil {
+		return err
 	}
 
-	data, err := store.Get(key)
+	spec, ok, err := FindSpecByPath(store, wd)
 	if err != nil {
-		return fmt.Errorf("get environment: %w", err)
+		return err
+	}
+	// Prompts call this on every render so stay silent outside of environments
+	if !ok {
+		return nil
 	}
 
-	spec, err := LoadSpec(data)
-	if err != nil {
-		return fmt.Errorf("load spec: %w", err)
+	info := PromptInfo{
+		Name:   spec.Name,
+		Type:   spec.Type,
+		Status: "active",
+	}
+	if !spec.Expires.IsZero() {
+		remaining := FormatDuration(max(time.Until(spec.Expires), 0))
+		info.ExpiresIn = &remaining
+		if spec.IsExpired(time.Now()) {
+			info.Status = "expired"
+		}
 	}
 
-	if err := OpenFolder(o.Open, spec.Path); err != nil {
-		return err
+	if !p.JSON {
+		switch {
+		case info.Status == "expired":
+			fmt.Printf("%s (%s) expired\n", info.Name, info.Type)
+		case info.ExpiresIn != nil:
+			fmt.Printf("%s (%s) expires in %s\n", info.Name, info.Type, *info.ExpiresIn)
+		default:
+			fmt.Printf("%s (%s)\n", info.Name, info.Type)
+		}
+		return nil
 	}
 
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("marshal prompt info: %w", err)
+	}
+	fmt.Println(string(data))
 	return nil
 }
 
 // CLI describes available commands and flags
 var CLI struct {
-	Verbose bool      `short:"v" help:"Enable verbose logging"`
-	New     NewCmd    `cmd:"" help:"Create a new environment"`
-	List    ListCmd   `cmd:"" help:"List environments"`
-	Delete  DeleteCmd `cmd:"" help:"Delete environments"`
-	Open    OpenCmd   `cmd:"" help:"Open environment"`
+	Verbose     bool           `short:"v" xor:"verbosity" help:"Enable verbose logging"`
+	Quiet       bool           `short:"q" xor:"verbosity" help:"Only report errors"`
+	LogFormat   LogFormat      `help:"Format of logs (text or json)" enum:"text,json" default:"text"`
+	Store       StoreBackend   `help:"Storage backend to use instead of the configured one (pebble or json)"`
+	Workspace   string         `short:"w" env:"SCRATCH_WORKSPACE" help:"Workspace to use instead of the configured one"`
+	Profile     bool           `help:"Print how long each phase of the command took, like opening the store and each provisioning command"`
+	New         NewCmd         `cmd:"" help:"Create a new environment"`
+	List        ListCmd        `cmd:"" help:"List environments"`
+	Delete      DeleteCmd      `cmd:"" help:"Delete environments"`
+	Open        OpenCmd        `cmd:"" help:"Open environment"`
+	Adopt       AdoptCmd       `cmd:"" help:"Track an existing directory as an environment"`
+	Clone       CloneCmd       `cmd:"" help:"Copy an environment under a new name"`
+	Rename      RenameCmd      `cmd:"" help:"Give an environment a new name"`
+	Archive     ArchiveCmd     `cmd:"" help:"Move an environment to the archive"`
+	Unarchive   UnarchiveCmd   `cmd:"" help:"Restore an archived environment"`
+	Edit        EditCmd        `cmd:"" help:"Edit tags and metadata of environments"`
+	Note        NoteCmd        `cmd:"" help:"Show or set the description of an environment"`
+	Info        InfoCmd        `cmd:"" help:"Show details of an environment"`
+	Types       TypesCmd       `cmd:"" help:"List the types of environments"`
+	Du          DuCmd          `cmd:"" help:"Show disk usage of environments"`
+	Gc          GcCmd          `cmd:"" help:"Reclaim space from data no environment uses"`
+	Prune       PruneCmd       `cmd:"" help:"Delete environments that are no longer used"`
+	Check       CheckCmd       `cmd:"" help:"Check environments are intact"`
+	Verify      VerifyCmd      `cmd:"" help:"Report environments that drifted from their directories"`
+	Rebuild     RebuildCmd     `cmd:"" help:"Rebuild environments in place"`
+	Reprovision ReprovisionCmd `cmd:"" help:"Repair environments by re-running their provisioner"`
+	Upgrade     UpgradeCmd     `cmd:"" help:"Upgrade dependencies of environments"`
+	Prompt      PromptCmd      `cmd:"" help:"Describe environment of working directory for shell prompts"`
+	Sync        SyncCmd        `cmd:"" help:"Sync environment registry with a remote"`
+	Doctor      DoctorCmd      `cmd:"" help:"Diagnose problems with scratch and environments"`
+	Report      ReportCmd      `cmd:"" help:"Summarize the state of environments"`
+	History     HistoryCmd     `cmd:"" help:"Show when environments were created, opened, renamed and deleted"`
+	Stats       StatsCmd       `cmd:"" help:"Show statistics about environments and their use"`
+
+	Run          RunCmd          `cmd:"" help:"Run a program in an environment with its .env loaded"`
+	Shell        ShellCmd        `cmd:"" help:"Start a shell in an environment with its .env loaded"`
+	Path         PathCmd         `cmd:"" help:"Print the path of an environment"`
+	Serve        ServeCmd        `cmd:"" help:"Serve environments over HTTP for editor extensions and launchers"`
+	Daemon       DaemonCmd       `cmd:"" help:"Hold the store open for faster commands and watch directories of environments"`
+	Watch        WatchCmd        `cmd:"" help:"Adopt project folders other tools create in a directory"`
+	ShellInit    ShellInitCmd    `cmd:"" help:"Print shell integration defining scd to change to an environment"`
+	Lock         LockCmd         `cmd:"" help:"Protect an environment from delete and prune"`
+	Unlock       UnlockCmd       `cmd:"" help:"Remove the protection of a locked environment"`
+	Hide         HideCmd         `cmd:"" help:"Leave an environment out of list unless --all is passed"`
+	Unhide       UnhideCmd       `cmd:"" help:"Show a hidden environment in list again"`
+	Workspaces   WorkspaceCmd    `cmd:"" name:"workspace" help:"Manage workspaces keeping environments apart"`
+	Undelete     UndeleteCmd     `cmd:"" help:"Restore a deleted environment from the trash"`
+	Undo         UndoCmd         `cmd:"" help:"Reverse the last delete, rename or creation of an environment"`
+	Backup       BackupCmd       `cmd:"" help:"Back up all tracked environments"`
+	Restore      RestoreCmd      `cmd:"" help:"Replace all tracked environments with a backup"`
+	Migrate      MigrateCmd      `cmd:"" help:"Upgrade stored environments to the current schema version"`
+	MigrateStore MigrateStoreCmd `cmd:"" help:"Copy environments between storage backends"`
+	Database     StoreCmd        `cmd:"" name:"store" help:"Maintain and encrypt the database of the store"`
+	Config       ConfigCmd       `cmd:"" help:"Check the configuration file"`
 }
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+)
+
+// Config is the user configuration read from config.json in the config directory
+type Config struct {
+	Store StoreBackend `json:"store,omitempty"`
+	Sync  SyncConfig   `json:"sync,omitzero"`
+	Disk  DiskConfig   `json:"disk,omitzero"`
+	// Roots are evaluated in order to choose where new environments are created
+	Roots []RootRule `json:"roots,omitempty"`
+	// Templates maps each type to a directory of files added to new environments
+	Templates map[SpecType]string `json:"templates,omitempty"`
+	// Data customizes the layout of data environments
+	Data   DataConfig   `json:"data,omitzero"`
+	Backup BackupConfig `json:"backup,omitzero"`
+	Trash  TrashConfig  `json:"trash,omitzero"`
+	// Workspace is used when --workspace is not passed, the default workspace when empty
+	Workspace string `json:"workspace,omitempty"`
+	// Workspaces are the workspaces created besides the default one
+	Workspaces []string `json:"workspaces,omitempty"`
+	// OpenExisting makes new open an environment that already exists instead of failing
+	OpenExisting bool `json:"open_existing,omitempty"`
+	// EditorConfig makes new write editor settings for the type of environment
+	EditorConfig bool `json:"editor_config,omitempty"`
+	// Notify runs commands or posts to webhooks when environments are created, deleted or expire
+	Notify []NotifyRule `json:"notify,omitempty"`
+}
+
+// RootRule places environments matching its types and name pattern under a root directory
+type RootRule struct {
+	Path  string     `json:"path"`
+	Types []SpecType `json:"types,omitempty"`
+	// Match is a glob pattern for environment names
+	Match string `json:"match,omitempty"`
+}
+
+// Matches checks if an environment with name and type falls under the rule
+func (r RootRule) Matches(name string, specType SpecType) bool {
+	if len(r.Types) > 0 && !slices.Contains(r.Types, specType) {
+		return false
+	}
+	if r.Match != "" {
+		if ok, _ := path.Match(r.Match, name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchRoot returns the first root rule matching an environment
+func (c Config) MatchRoot(name string, specType SpecType) (RootRule, bool) {
+	for _, rule := range c.Roots {
+		if rule.Matches(name, specType) {
+			return rule, true
+		}
+	}
+	return RootRule{}, false
+}
+
+// DiskConfig configures disk space checks
+type DiskConfig struct {
+	// RequiredSpace overrides the estimated space needed to provision each type
+	RequiredSpace map[SpecType]ByteSize `json:"required_space,omitempty"`
+	// Quota is the disk space environments of a workspace should use at most
+	Quota ByteSize `json:"quota,omitempty"`
+}
+
+// SyncConfig configures syncing the environment registry between machines
+type SyncConfig struct {
+	Remote string `json:"remote,omitempty"`
+	// Paths maps path prefixes in the registry to path prefixes on this machine
+	Paths map[string]string `json:"paths,omitempty"`
+}
+
+// DefaultConfigPath returns the path of the configuration file
+func DefaultConfigPath() (string, error) {
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// LoadConfig loads the configuration file, returning an empty Config if it does not exist
+func LoadConfig() (Config, error) {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return Config{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+
+	c, problems := ParseConfig(data)
+	problems = slices.DeleteFunc(problems, func(p ConfigProblem) bool { return p.Warning })
+	if len(problems) > 0 {
+		return Config{}, WithHint(ConfigError{Path: path, Problems: problems}, "run 'scratch config doctor' to list every problem")
+	}
+	return c, nil
+}
+
+// Save writes the configuration file
+func (c Config) Save() error {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := EnsureDirectory(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", " ")
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_MatchRoot(t *testing.T) {
+	config := main.Config{
+		Roots: []main.RootRule{
+			{Path: "/big", Types: []main.SpecType{"node"}},
+			{Path: "/synced", Match: "notes-*"},
+		},
+	}
+
+	rule, ok := config.MatchRoot("app", "node")
+	assert.True(t, ok)
+	assert.Equal(t, "/big", rule.Path)
+
+	rule, ok = config.MatchRoot("notes-today", main.PythonSpec)
+	assert.True(t, ok)
+	assert.Equal(t, "/synced", rule.Path)
+
+	_, ok = config.MatchRoot("app", main.PythonSpec)
+	assert.False(t, ok)
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// deprecatedConfigKeys maps fields of config.json that are still read but
+// replaced, by dotted path with * for any index or key, to what replaces them
+var deprecatedConfigKeys = map[string]string{}
+
+// ConfigProblem is a problem with a field of config.json
+type ConfigProblem struct {
+	// Field is the dotted path of the field like roots.0.path, empty for the whole file
+	Field string
+	// Line and Column locate the problem in the file, 0 when unknown
+	Line, Column int
+	Message      string
+	// Warning marks problems that do not stop the config from loading
+	Warning bool
+}
+
+// In formats the problem in file like "config.json:3:14: roots.0.path: must be set"
+func (p ConfigProblem) In(file string) string {
+	var b strings.Builder
+	b.WriteString(file)
+	if p.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", p.Line, p.Column)
+	}
+	b.WriteString(": ")
+	if p.Field != "" {
+		b.WriteString(p.Field + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// ConfigError is returned when config.json has problems that stop it from loading
+type ConfigError struct {
+	Path     string
+	Problems []ConfigProblem
+}
+
+func (e ConfigError) Error() string {
+	msg := "invalid config " + e.Problems[0].In(e.Path)
+	if len(e.Problems) > 1 {
+		msg += fmt.Sprintf(", and %d more problems, see scratch config doctor", len(e.Problems)-1)
+	}
+	return msg
+}
+
+// lineColumn converts a byte offset in data to a line and column, both starting at 1
+func lineColumn(data []byte, offset int64) (int, int) {
+	offset = min(max(offset, 0), int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// skipSeparators advances offset past whitespace and commas to the start of the next token
+func skipSeparators(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+var (
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+	jsonUnmarshaler = reflect.TypeFor[json.Unmarshaler]()
+)
+
+// configField returns the type of the field key of a value of type t in config.json,
+// and false if t has no such field. A nil type accepts anything, like the value
+// of an unknown key.
+func configField(t reflect.Type, key string) (reflect.Type, bool) {
+	if t == nil {
+		return nil, true
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem(), true
+	case reflect.Slice, reflect.Array:
+		return t.Elem(), true
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if strings.EqualFold(cmp.Or(name, field.Name), key) {
+				return field.Type, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// configScan is where each field of config.json starts, which keys are not known
+// and which values cannot be decoded from text
+type configScan struct {
+	data    []byte
+	offsets map[string]int64
+	unknown []string
+	invalid []ConfigProblem
+}
+
+// walk reads the value at path of type t from dec, recording the fields in it
+func (s *configScan) walk(dec *json.Decoder, field string, t reflect.Type) error {
+	// Values decoding themselves, like durations and sizes, have no fields, and
+	// json does not say which one failed to decode
+	if t != nil && (reflect.PointerTo(t).Implements(textUnmarshaler) || reflect.PointerTo(t).Implements(jsonUnmarshaler)) {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, reflect.New(t).Interface()); err != nil {
+			s.invalid = append(s.invalid, ConfigProblem{Field: field, Message: err.Error()})
+		}
+		return nil
+	}
+
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok || delim == '}' || delim == ']' {
+		return nil
+	}
+
+	for i := 0; dec.More(); i++ {
+		start := skipSeparators(s.data, dec.InputOffset())
+		key := strconv.Itoa(i)
+		if delim == '{' {
+			token, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key = token.(string)
+		}
+		child := strings.TrimPrefix(field+"."+key, ".")
+		s.offsets[child] = start
+		childType, known := configField(t, key)
+		if !known {
+			s.unknown = append(s.unknown, child)
+		}
+		if err := s.walk(dec, child, childType); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// locate sets the line and column of problem from where its field starts
+func (s *configScan) locate(problem ConfigProblem) ConfigProblem {
+	if offset, ok := s.offsets[problem.Field]; ok && problem.Line == 0 {
+		problem.Line, problem.Column = lineColumn(s.data, offset)
+	}
+	return problem
+}
+
+// matchesConfigKey checks if the dotted field matches pattern, where * matches any segment
+func matchesConfigKey(pattern string, field string) bool {
+	patterns, fields := strings.Split(pattern, "."), strings.Split(field, ".")
+	if len(patterns) != len(fields) {
+		return false
+	}
+	for i := range patterns {
+		if patterns[i] != "*" && patterns[i] != fields[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// knownType checks if specType is built in or provided by a plugin
+func knownType(specType SpecType) bool {
+	_, err := NewProvisioner(specType)
+	return err == nil
+}
+
+// Problems returns the problems of the values of c that stop it from working as
+// intended, and warnings about paths that do not exist
+func (c Config) Problems() []ConfigProblem {
+	problems := []ConfigProblem{}
+	add := func(field string, warning bool, format string, args ...any) {
+		problems = append(problems, ConfigProblem{Field: field, Message: fmt.Sprintf(format, args...), Warning: warning})
+	}
+	checkTypes := func(field string, types []SpecType) {
+		for i, t := range types {
+			if !knownType(t) {
+				add(fmt.Sprintf("%s.%d", field, i), false, "unknown type %q", t)
+			}
+		}
+	}
+
+	if c.Store != "" && c.Store != PebbleBackend && c.Store != JSONBackend {
+		add("store", false, "unknown store backend %q, expected pebble or json", c.Store)
+	}
+	for i, rule := range c.Roots {
+		field := fmt.Sprintf("roots.%d", i)
+		if rule.Path == "" {
+			add(field+".path", false, "must be set")
+		} else if !filepath.IsAbs(rule.Path) {
+			add(field+".path", true, "%s is relative to the directory scratch runs in", rule.Path)
+		} else if _, err := os.Stat(rule.Path); err != nil {
+			add(field+".path", true, "%s does not exist yet", rule.Path)
+		}
+		checkTypes(field+".types", rule.Types)
+		if _, err := path.Match(rule.Match, ""); err != nil {
+			add(field+".match", false, "invalid pattern %q", rule.Match)
+		}
+	}
+	for _, t := range slices.Sorted(maps.Keys(c.Templates)) {
+		field := "templates." + string(t)
+		if !knownType(t) {
+			add(field, false, "unknown type %q", t)
+		} else if _, err := os.Stat(c.Templates[t]); err != nil {
+			add(field, true, "template directory %s does not exist", c.Templates[t])
+		}
+	}
+	for _, t := range slices.Sorted(maps.Keys(c.Disk.RequiredSpace)) {
+		if !knownType(t) {
+			add("disk.required_space."+string(t), false, "unknown type %q", t)
+		}
+	}
+	for i, dir := range c.Data.Dirs {
+		if filepath.IsAbs(dir) || !filepath.IsLocal(dir) {
+			add(fmt.Sprintf("data.dirs.%d", i), false, "%s must be relative to the environment", dir)
+		}
+	}
+	if c.Backup.Keep < 0 {
+		add("backup.keep", false, "must not be negative")
+	}
+	if c.Trash.Days < 0 {
+		add("trash.days", false, "must not be negative")
+	}
+	if c.Workspace != "" && !slices.Contains(c.AllWorkspaces(), c.Workspace) {
+		add("workspace", false, "unknown workspace %q, expected one of workspaces", c.Workspace)
+	}
+	for i, name := range c.Workspaces {
+		if err := ValidateWorkspace(name); err != nil {
+			add(fmt.Sprintf("workspaces.%d", i), false, "%s", err)
+		}
+	}
+	for i, rule := range c.Notify {
+		field := fmt.Sprintf("notify.%d", i)
+		if (len(rule.Command) == 0) == (rule.Webhook == "") {
+			add(field, false, "must have either a command or a webhook")
+		}
+		for j, action := range rule.Events {
+			if !slices.Contains(HistoryActions, action) {
+				add(fmt.Sprintf("%s.events.%d", field, j), false, "unknown event %q", action)
+			}
+		}
+		checkTypes(field+".types", rule.Types)
+	}
+	return problems
+}
+
+// ParseConfig decodes config.json and returns every problem found in it: syntax
+// and type errors, unknown and deprecated keys, and invalid values, located by
+// line and column and ordered as in the file
+func ParseConfig(data []byte) (Config, []ConfigProblem) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		problem := ConfigProblem{Message: err.Error()}
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			problem.Line, problem.Column = lineColumn(data, syntaxErr.Offset)
+			problem.Message = syntaxErr.Error()
+		case errors.As(err, &typeErr):
+			problem.Field = typeErr.Field
+			problem.Message = fmt.Sprintf("expected %s, not %s", typeErr.Type, typeErr.Value)
+		}
+		// The scan stops at the same error, after finding where the fields before it start
+		scan := &configScan{data: data, offsets: map[string]int64{}}
+		scan.walk(json.NewDecoder(bytes.NewReader(data)), "", reflect.TypeFor[Config]())
+		problems := []ConfigProblem{problem}
+		if len(scan.invalid) > 0 {
+			problems = scan.invalid
+		}
+		for i := range problems {
+			problems[i] = scan.locate(problems[i])
+		}
+		return Config{}, problems
+	}
+
+	scan := &configScan{data: data, offsets: map[string]int64{}}
+	if err := scan.walk(json.NewDecoder(bytes.NewReader(data)), "", reflect.TypeFor[Config]()); err != nil {
+		return Config{}, []ConfigProblem{{Message: err.Error()}}
+	}
+	problems := []ConfigProblem{}
+	for _, field := range scan.unknown {
+		problems = append(problems, ConfigProblem{Field: field, Message: "unknown key, it is ignored", Warning: true})
+	}
+	for field := range scan.offsets {
+		for pattern, replacement := range deprecatedConfigKeys {
+			if matchesConfigKey(pattern, field) {
+				problems = append(problems, ConfigProblem{Field: field, Message: "deprecated, " + replacement, Warning: true})
+			}
+		}
+	}
+	problems = append(problems, c.Problems()...)
+	for i := range problems {
+		problems[i] = scan.locate(problems[i])
+	}
+	slices.SortStableFunc(problems, func(a, b ConfigProblem) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return c, problems
+}
+
+// ConfigCmd represents the commands to inspect the configuration
+type ConfigCmd struct {
+	Doctor ConfigDoctorCmd `cmd:"" help:"List problems of config.json, like unknown keys and invalid values"`
+}
+
+// ConfigDoctorCmd represents the command to check the configuration
+type ConfigDoctorCmd struct{}
+
+// Run prints every problem of the configuration file, failing if any stops it from loading
+func (c ConfigDoctorCmd) Run() error {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		output.Info("No config at %s, defaults are used", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	_, problems := ParseConfig(data)
+	failed := 0
+	for _, problem := range problems {
+		level := "WARN "
+		if !problem.Warning {
+			level = "ERROR"
+			failed++
+		}
+		fmt.Printf("%s %s\n", level, problem.In(path))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d problems stop the config from loading", failed)
+	}
+	if len(problems) == 0 {
+		output.Success("No problems in %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		config, problems := main.ParseConfig([]byte(`{"roots": [{"path": "/", "types": ["python"]}]}`))
+		assert.Empty(t, problems)
+		require.Len(t, config.Roots, 1)
+		assert.Equal(t, "/", config.Roots[0].Path)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, problems := main.ParseConfig([]byte("{\n  \"trash\": {\"dayz\": 3}\n}"))
+		require.Len(t, problems, 1)
+		assert.Equal(t, "trash.dayz", problems[0].Field)
+		assert.True(t, problems[0].Warning)
+		assert.Equal(t, 2, problems[0].Line)
+		assert.Equal(t, 13, problems[0].Column)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, problems := main.ParseConfig([]byte("{\n  \"roots\": [\n    {\"path\": \"/\", \"types\": [\"python\", \"cobol\"]}\n  ]\n}"))
+		require.Len(t, problems, 1)
+		assert.Equal(t, "roots.0.types.1", problems[0].Field)
+		assert.False(t, problems[0].Warning)
+		assert.Equal(t, 3, problems[0].Line)
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		_, problems := main.ParseConfig([]byte("{\n  \"disk\": {\"quota\": \"lots\"}\n}"))
+		require.Len(t, problems, 1)
+		assert.Equal(t, "disk.quota", problems[0].Field)
+		assert.False(t, problems[0].Warning)
+		assert.Equal(t, 2, problems[0].Line)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		_, problems := main.ParseConfig([]byte("{\n  \"store\": \"json\",\n}"))
+		require.Len(t, problems, 1)
+		assert.Equal(t, 3, problems[0].Line)
+	})
+}
+
+func TestConfigError(t *testing.T) {
+	err := main.ConfigError{Path: "config.json", Problems: []main.ConfigProblem{
+		{Field: "roots.0.path", Line: 3, Column: 14, Message: "must be set"},
+		{Field: "trash.days", Message: "must not be negative"},
+	}}
+	assert.Equal(t, "invalid config config.json:3:14: roots.0.path: must be set, and 1 more problems, see scratch config doctor", err.Error())
+}
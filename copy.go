@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// ReflinkMode controls whether files are copied with copy-on-write clones
+type ReflinkMode string
+
+var (
+	ReflinkAuto   ReflinkMode = "auto"
+	ReflinkAlways ReflinkMode = "always"
+	ReflinkNever  ReflinkMode = "never"
+)
+
+// errReflinkUnsupported is returned when the platform or filesystem cannot clone files
+var errReflinkUnsupported = errors.New("reflink not supported")
+
+// CopyStats counts how files were copied
+type CopyStats struct {
+	Reflinked int
+	Copied    int
+	// Skipped counts files and directories matching an ignore pattern
+	Skipped int
+}
+
+// CopyTree copies the directory src to dst, which must not exist. Files are
+// cloned with reflinks where the filesystem supports it depending on mode.
+// Entries whose base name matches an ignore pattern are not copied.
+func CopyTree(src string, dst string, mode ReflinkMode, ignore ...string) (CopyStats, error) {
+	var stats CopyStats
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if path != src && matchesAny(d.Name(), ignore) {
+			stats.Skipped++
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			reflinked, err := copyFile(path, target, info.Mode().Perm(), mode)
+			if err != nil {
+				return fmt.Errorf("copy %s: %w", rel, err)
+			}
+			if reflinked {
+				stats.Reflinked++
+			} else {
+				stats.Copied++
+			}
+			return nil
+		default:
+			slog.Debug("Skipping special file", slog.String("path", path))
+			return nil
+		}
+	})
+	return stats, err
+}
+
+// matchesAny checks if name matches any of the glob patterns
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// copyFile copies a regular file, returning whether it was reflinked
+func copyFile(src string, dst string, perm fs.FileMode, mode ReflinkMode) (bool, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return false, err
+	}
+	defer out.Close()
+
+	if mode != ReflinkNever {
+		err := reflink(in, out)
+		if err == nil {
+			return true, nil
+		}
+		if mode == ReflinkAlways {
+			return false, err
+		}
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		return false, err
+	}
+	return false, out.Close()
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl request shared by btrfs, XFS and other CoW filesystems
+const ficlone = 0x40049409
+
+// reflink clones the contents of src into dst without copying data
+func reflink(src *os.File, dst *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd())
+	if errno != 0 {
+		return fmt.Errorf("%w: %v", errReflinkUnsupported, errno)
+	}
+	return nil
+}
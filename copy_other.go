@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// reflink is only implemented for Linux, other platforms fall back to regular copies
+func reflink(src *os.File, dst *os.File) error {
+	return errReflinkUnsupported
+}
@@ -0,0 +1,63 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyTree(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "main.py"), []byte("print(1)"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "pkg", "run.sh"), []byte("#!/bin/sh"), 0755))
+	require.NoError(t, os.Symlink("main.py", filepath.Join(src, "link.py")))
+
+	for _, mode := range []main.ReflinkMode{main.ReflinkNever, main.ReflinkAuto} {
+		t.Run(string(mode), func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "dst")
+			stats, err := main.CopyTree(src, dst, mode)
+			require.NoError(t, err)
+			require.Equal(t, 2, stats.Copied+stats.Reflinked)
+			if mode == main.ReflinkNever {
+				require.Zero(t, stats.Reflinked)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dst, "main.py"))
+			require.NoError(t, err)
+			require.Equal(t, "print(1)", string(data))
+
+			info, err := os.Stat(filepath.Join(dst, "pkg", "run.sh"))
+			require.NoError(t, err)
+			require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+			link, err := os.Readlink(filepath.Join(dst, "link.py"))
+			require.NoError(t, err)
+			require.Equal(t, "main.py", link)
+		})
+	}
+
+	_, err := main.CopyTree(src, src, main.ReflinkNever)
+	require.Error(t, err)
+}
+
+func TestCopyTree_Ignore(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, ".venv", "bin"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "pkg", "__pycache__"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, ".venv", "bin", "python"), []byte("python"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "pkg", "__pycache__", "mod.pyc"), []byte("pyc"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "pkg", "mod.py"), []byte("x = 1"), 0644))
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	stats, err := main.CopyTree(src, dst, main.ReflinkNever, main.PythonEnvironment{}.Ignore()...)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Copied)
+	require.Equal(t, 2, stats.Skipped)
+	require.FileExists(t, filepath.Join(dst, "pkg", "mod.py"))
+	require.NoDirExists(t, filepath.Join(dst, ".venv"))
+	require.NoDirExists(t, filepath.Join(dst, "pkg", "__pycache__"))
+}
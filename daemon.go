@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// The daemon holds the pebble store open and serves its key-value pairs over a
+// unix socket, so commands skip opening the database and never wait on its lock.
+// Commands use it through RemoteStore whenever it is running.
+
+// DefaultSocketPath returns the unix socket the daemon listens on. It lives in a
+// directory of its own so that directory can be private to the user.
+func DefaultSocketPath() (string, error) {
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon", "daemon.sock"), nil
+}
+
+// storeEntry is a key-value pair listed by the daemon
+type storeEntry struct {
+	Key   string
+	Value []byte
+}
+
+// storeOp is one write of a batch sent to the daemon
+type storeOp struct {
+	Key    string
+	Value  []byte `json:",omitempty"`
+	Delete bool   `json:",omitempty"`
+}
+
+// writeStoreError responds with err, with 404 for missing keys
+func writeStoreError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// NewStoreHandler serves the key-value pairs of store for RemoteStore
+func NewStoreHandler(store Storer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /value", func(w http.ResponseWriter, r *http.Request) {
+		data, err := store.Get(r.URL.Query().Get("key"))
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.Write(data)
+	})
+	mux.HandleFunc("PUT /value", func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = store.Put(r.URL.Query().Get("key"), data)
+		}
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /value", func(w http.ResponseWriter, r *http.Request) {
+		if err := store.Delete(r.URL.Query().Get("key")); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /list", func(w http.ResponseWriter, r *http.Request) {
+		entries := []storeEntry{}
+		err := store.ListPrefix(r.URL.Query().Get("prefix"), func(key string, data []byte) error {
+			entries = append(entries, storeEntry{Key: key, Value: data})
+			return nil
+		})
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, entries)
+	})
+	mux.HandleFunc("POST /batch", func(w http.ResponseWriter, r *http.Request) {
+		var ops []storeOp
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err := store.Batch(func(w Writer) error {
+			for _, op := range ops {
+				var err error
+				if op.Delete {
+					err = w.Delete(op.Key)
+				} else {
+					err = w.Put(op.Key, op.Value)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+// RemoteStore is a Storer for the store held by the daemon
+type RemoteStore struct {
+	client *http.Client
+}
+
+// DialDaemon connects to the daemon listening on socket, returning false if it is not running
+func DialDaemon(socket string) (*RemoteStore, bool) {
+	if _, err := os.Stat(socket); err != nil {
+		return nil, false
+	}
+	s := &RemoteStore{client: &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+		Timeout: 30 * time.Second,
+	}}
+	if _, err := s.do(http.MethodGet, "/ping", nil, nil); err != nil {
+		slog.Debug("Daemon is not running", slog.String("socket", socket), slog.Any("error", err))
+		return nil, false
+	}
+	return s, true
+}
+
+// do sends a request to the daemon and returns the body of its response
+func (s *RemoteStore) do(method string, path string, query url.Values, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, "http://daemon"+path+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("daemon: %w: %w", ErrStore, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("daemon: %w: %w", ErrStore, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("daemon: %s: %w", strings.TrimSpace(string(data)), ErrStore)
+	}
+	return data, nil
+}
+
+// Get fetches data by key
+func (s *RemoteStore) Get(key string) ([]byte, error) {
+	data, err := s.do(http.MethodGet, "/value", url.Values{"key": {key}}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get key %q: %w", key, err)
+	}
+	return data, nil
+}
+
+// Exists checks if a key exists
+func (s *RemoteStore) Exists(key string) (bool, error) {
+	if _, err := s.Get(key); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("check key exists: %w", err)
+	}
+	return true, nil
+}
+
+// Put adds or replaces a key with its data
+func (s *RemoteStore) Put(key string, data []byte) error {
+	if _, err := s.do(http.MethodPut, "/value", url.Values{"key": {key}}, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("put key %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes key with its data
+func (s *RemoteStore) Delete(key string) error {
+	if _, err := s.do(http.MethodDelete, "/value", url.Values{"key": {key}}, nil); err != nil {
+		return fmt.Errorf("delete key %q: %w", key, err)
+	}
+	return nil
+}
+
+// List lists all keys in store
+func (s *RemoteStore) List() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		keys := []string{}
+		err := s.ListFunc(func(key string, data []byte) error {
+			keys = append(keys, key)
+			return nil
+		})
+		if err != nil {
+			yield("", err)
+			return
+		}
+		for _, key := range keys {
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ListFunc processes each key-value pair with provided function
+func (s *RemoteStore) ListFunc(handle func(key string, data []byte) error) error {
+	return s.ListPrefix("", handle)
+}
+
+// ListPrefix processes each key-value pair whose key starts with prefix, which
+// the daemon sends at once
+func (s *RemoteStore) ListPrefix(prefix string, handle func(key string, data []byte) error) error {
+	data, err := s.do(http.MethodGet, "/list", url.Values{"prefix": {prefix}}, nil)
+	if err != nil {
+		return fmt.Errorf("list keys: %w", err)
+	}
+	var entries []storeEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("list keys: %w", err)
+	}
+	for _, entry := range entries {
+		if err := handle(entry.Key, entry.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remoteBatch collects the writes of a batch to send them at once
+type remoteBatch struct {
+	ops *[]storeOp
+}
+
+// Put stages adding or replacing a key with its data
+func (b remoteBatch) Put(key string, data []byte) error {
+	*b.ops = append(*b.ops, storeOp{Key: key, Value: data})
+	return nil
+}
+
+// Delete stages removing key with its data
+func (b remoteBatch) Delete(key string) error {
+	*b.ops = append(*b.ops, storeOp{Key: key, Delete: true})
+	return nil
+}
+
+// Batch sends the writes made by apply to the daemon, which commits them in one batch
+func (s *RemoteStore) Batch(apply func(w Writer) error) error {
+	ops := []storeOp{}
+	if err := apply(remoteBatch{&ops}); err != nil {
+		return err
+	}
+	body, err := json.Marshal(ops)
+	if err != nil {
+		return fmt.Errorf("marshal batch: %w", err)
+	}
+	if _, err := s.do(http.MethodPost, "/batch", nil, bytes.NewReader(body)); err != nil {
+		return fmt.Errorf("commit batch: %w", err)
+	}
+	return nil
+}
+
+// Close releases the connections to the daemon, which keeps the store open
+func (s *RemoteStore) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// Watcher checks the directories of environments in every workspace, reporting
+// deleted ones and keeping disk usage fresh
+type Watcher struct {
+	store      Storer
+	workspaces []string
+	// modified holds when each directory last changed, by workspace and ULID
+	modified map[string]time.Time
+}
+
+// NewWatcher returns a watcher of the environments of workspaces in store
+func NewWatcher(store Storer, workspaces []string) *Watcher {
+	return &Watcher{store: store, workspaces: workspaces, modified: map[string]time.Time{}}
+}
+
+// Scan checks every directory once. Environments whose directory changed since the
+// last scan are measured again, and others when their cached size is an hour old.
+func (w *Watcher) Scan() error {
+	// Commands may have encrypted or decrypted the store through the daemon since
+	// the last scan, so specs are read and cached with its current key
+	if err := UnlockStore(w.store); err != nil {
+		return fmt.Errorf("unlock store: %w", err)
+	}
+	for _, name := range w.workspaces {
+		store := NewWorkspaceStore(w.store, name)
+		specs, err := LoadSpecs(store)
+		if err != nil {
+			return fmt.Errorf("load environments of workspace %s: %w", name, err)
+		}
+		for _, spec := range specs {
+			if spec.IsArchived() {
+				continue
+			}
+			key := name + "/" + spec.Key()
+			previous, seen := w.modified[key]
+			info, err := os.Stat(spec.Path)
+			if err != nil {
+				if seen {
+					output.Warn("Directory of %s at %s was deleted", spec.ID(), spec.Path)
+					delete(w.modified, key)
+				}
+				continue
+			}
+			w.modified[key] = info.ModTime()
+			changed := seen && !previous.Equal(info.ModTime())
+			if changed {
+				slog.Debug("Directory changed", slog.String("id", spec.ID()))
+			}
+			measureSpecs(store, []Spec{spec}, changed)
+		}
+	}
+	return nil
+}
+
+// DaemonCmd represents the command to run the daemon
+type DaemonCmd struct {
+	Interval Duration `help:"How often directories of environments are checked" default:"5m"`
+}
+
+// Validate checks the interval is positive
+func (d DaemonCmd) Validate() error {
+	if d.Interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	return nil
+}
+
+// Run holds the store open and serves it until interrupted
+func (d DaemonCmd) Run(ctx *CLIContext) error {
+	backend, err := ctx.Backend()
+	if err != nil {
+		return err
+	}
+	if backend != PebbleBackend && backend != "" {
+		return fmt.Errorf("the daemon holds the pebble store, not the %s store", backend)
+	}
+	config, err := ctx.Config()
+	if err != nil {
+		return err
+	}
+	socket, err := DefaultSocketPath()
+	if err != nil {
+		return err
+	}
+	if remote, ok := DialDaemon(socket); ok {
+		remote.Close()
+		return fmt.Errorf("daemon listening on %s %w", socket, ErrExists)
+	}
+	// A socket left behind by a daemon that was killed refuses connections
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove stale socket: %w", err)
+	}
+
+	// The mode of a socket can only be changed after it is listening, so it is
+	// created where no other user can reach it in the meantime
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return fmt.Errorf("ensure socket directory exists: %w", err)
+	}
+	if err := os.Chmod(filepath.Dir(socket), 0700); err != nil {
+		return fmt.Errorf("restrict socket directory: %w", err)
+	}
+	store, err := NewPebbleStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	// An encrypted store asks for its key once, before prompts are turned off
+	if err := UnlockStore(store); err != nil {
+		return err
+	}
+	prompting = false
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server := &http.Server{Handler: NewStoreHandler(store)}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			output.Warn("%s", err)
+			stop()
+		}
+	}()
+	output.Info("Holding the store on %s", socket)
+
+	watcher := NewWatcher(store, config.AllWorkspaces())
+	ticker := time.NewTicker(time.Duration(d.Interval))
+	defer ticker.Stop()
+	for {
+		if err := watcher.Scan(); err != nil {
+			output.Warn("%s", err)
+		}
+		select {
+		case <-sigCtx.Done():
+			output.Info("Stopping daemon")
+			return server.Shutdown(context.Background())
+		case <-ticker.C:
+		}
+		// Workspaces may have been created or removed since the last scan
+		if config, err := LoadConfig(); err != nil {
+			output.Warn("%s", err)
+		} else {
+			watcher.workspaces = config.AllWorkspaces()
+		}
+	}
+}
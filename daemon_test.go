@@ -0,0 +1,155 @@
+package main_test
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteStore(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "d.sock")
+	_, ok := main.DialDaemon(socket)
+	require.False(t, ok)
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := &http.Server{Handler: main.NewStoreHandler(NewMemoryStore())}
+	go server.Serve(listener)
+	defer server.Close()
+
+	store, ok := main.DialDaemon(socket)
+	require.True(t, ok)
+	defer store.Close()
+
+	require.NoError(t, store.Put("a/1", []byte("one")))
+	data, err := store.Get("a/1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("one"), data)
+	_, err = store.Get("a/2")
+	require.ErrorIs(t, err, main.ErrNotFound)
+	exists, err := store.Exists("a/2")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, store.Batch(func(w main.Writer) error {
+		require.NoError(t, w.Put("a/2", []byte("two")))
+		require.NoError(t, w.Put("b/1", []byte("three")))
+		return w.Delete("a/1")
+	}))
+	keys := []string{}
+	require.NoError(t, store.ListPrefix("a/", func(key string, data []byte) error {
+		keys = append(keys, key)
+		return nil
+	}))
+	require.Equal(t, []string{"a/2"}, keys)
+
+	for _, key := range []string{"a/2", "b/1"} {
+		require.NoError(t, store.Delete(key))
+	}
+	spec := main.NewSpec("remote", main.PythonSpec, t.TempDir())
+	require.NoError(t, spec.Save(store))
+	specs, err := main.LoadSpecs(store)
+	require.NoError(t, err)
+	require.Equal(t, []main.Spec{spec}, specs)
+	require.NoError(t, store.Delete(spec.Key()))
+	require.ErrorIs(t, store.Delete(spec.Key()), main.ErrNotFound)
+}
+
+func TestWatcher_Scan(t *testing.T) {
+	store := NewMemoryStore()
+	ws := main.NewWorkspaceStore(store, main.DefaultWorkspace)
+	spec := main.NewSpec("watched", main.PythonSpec, t.TempDir())
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(spec.Path, "data"), make([]byte, 100), 0644))
+	require.NoError(t, spec.Save(ws))
+
+	watcher := main.NewWatcher(store, []string{main.DefaultWorkspace})
+	require.NoError(t, watcher.Scan())
+	measured, err := main.LookupID(ws, spec.UID)
+	require.NoError(t, err)
+	require.NotNil(t, measured.Usage)
+	require.EqualValues(t, 100, measured.Usage.Size)
+
+	// A new file changes the directory, so the cached size is replaced
+	require.NoError(t, os.WriteFile(filepath.Join(spec.Path, "more"), make([]byte, 50), 0644))
+	require.NoError(t, os.Chtimes(spec.Path, time.Now(), time.Now().Add(time.Minute)))
+	require.NoError(t, watcher.Scan())
+	measured, err = main.LookupID(ws, spec.UID)
+	require.NoError(t, err)
+	require.EqualValues(t, 150, measured.Usage.Size)
+
+	require.NoError(t, os.RemoveAll(spec.Path))
+	require.NoError(t, watcher.Scan())
+}
+
+// editedStore runs edit once after the specs are listed, like a command changing
+// an environment while the watcher measures it
+type editedStore struct {
+	MemoryStore
+	edit func()
+}
+
+func (s *editedStore) ListPrefix(prefix string, handle func(key string, data []byte) error) error {
+	if err := s.MemoryStore.ListPrefix(prefix, handle); err != nil {
+		return err
+	}
+	if s.edit != nil {
+		s.edit()
+		s.edit = nil
+	}
+	return nil
+}
+
+func TestWatcher_ScanKeepsEdits(t *testing.T) {
+	store := &editedStore{MemoryStore: NewMemoryStore()}
+	ws := main.NewWorkspaceStore(store, main.DefaultWorkspace)
+	spec := main.NewSpec("watched", main.PythonSpec, t.TempDir())
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+	require.NoError(t, spec.Save(ws))
+
+	edited := spec
+	edited.Description = "edited while measuring"
+	store.edit = func() { require.NoError(t, edited.Save(ws)) }
+	require.NoError(t, main.NewWatcher(store, []string{main.DefaultWorkspace}).Scan())
+
+	measured, err := main.LookupID(ws, spec.UID)
+	require.NoError(t, err)
+	require.NotNil(t, measured.Usage)
+	require.Equal(t, "edited while measuring", measured.Description)
+}
+
+func TestWatcher_ScanEncrypted(t *testing.T) {
+	t.Setenv("SCRATCH_PASSPHRASE", "hunter2")
+	t.Cleanup(func() { main.UnlockStore(NewMemoryStore()) })
+	store := NewMemoryStore()
+	ws := main.NewWorkspaceStore(store, main.DefaultWorkspace)
+	spec := main.NewSpec("watched", main.PythonSpec, t.TempDir())
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+	require.NoError(t, spec.Save(ws))
+	watcher := main.NewWatcher(store, []string{main.DefaultWorkspace})
+	require.NoError(t, watcher.Scan())
+
+	// The store is encrypted by a command while the daemon holds no key
+	enc, key, err := main.NewEncryption(main.PassphraseSource, "hunter2")
+	require.NoError(t, err)
+	_, err = main.EncryptStore(store, enc, key)
+	require.NoError(t, err)
+	require.NoError(t, main.UnlockStore(NewMemoryStore()))
+	require.NoError(t, watcher.Scan())
+
+	// and decrypted again, after which new measurements are cached unencrypted
+	_, err = main.DecryptStore(store)
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(spec.Path, time.Now(), time.Now().Add(time.Minute)))
+	require.NoError(t, watcher.Scan())
+	require.NotContains(t, string(store.Data[spec.Key()]), `"Encrypted"`)
+	measured, err := main.LookupID(ws, spec.UID)
+	require.NoError(t, err)
+	require.NotNil(t, measured.Usage)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DataConfig customizes the layout of data environments
+type DataConfig struct {
+	// Dirs are created in every data environment
+	Dirs []string `json:"dirs,omitempty"`
+	// Packages are installed in the virtual environment
+	Packages []string `json:"packages,omitempty"`
+}
+
+var (
+	defaultDataDirs     = []string{"data", "notebooks", "scripts"}
+	defaultDataPackages = []string{"pandas", "jupyter"}
+)
+
+// DataEnvironment represents a Python workspace for exploring datasets
+type DataEnvironment struct {
+	PythonEnvironment
+	Dirs     []string
+	Packages []string
+}
+
+// NewDataEnvironment creates a DataEnvironment with the layout of config, falling back to
+// data, notebooks and scripts directories with pandas and jupyter installed
+func NewDataEnvironment(config DataConfig) DataEnvironment {
+	p := DataEnvironment{Dirs: config.Dirs, Packages: config.Packages}
+	if len(p.Dirs) == 0 {
+		p.Dirs = defaultDataDirs
+	}
+	if len(p.Packages) == 0 {
+		p.Packages = defaultDataPackages
+	}
+	return p
+}
+
+// Provision creates the environment at provided directory
+func (p DataEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	if err := p.initProject(dir); err != nil {
+		return err
+	}
+	if err := p.writeLayout(dir); err != nil {
+		return err
+	}
+
+	slog.Debug("Provisioned data environment", slog.String("dir", dir))
+	return nil
+}
+
+// Describe summarizes data environments, whose layout config.json can change
+func (p DataEnvironment) Describe() Description {
+	return Description{
+		Summary:  fmt.Sprintf("A uv project for exploring datasets, with packages %s and directories %s", strings.Join(p.Packages, ", "), strings.Join(p.Dirs, ", ")),
+		Examples: []string{"scratch new survey -t data"},
+	}
+}
+
+// Detect recognizes Python projects with the layout of data environments, which
+// are more likely data than python environments
+func (p DataEnvironment) Detect(dir string) float64 {
+	confidence := p.PythonEnvironment.Detect(dir)
+	if confidence == NoMatch {
+		return NoMatch
+	}
+	if FilesExist(dir, p.Dirs...) != nil {
+		return confidence / 2
+	}
+	return min(confidence+0.05, 1)
+}
+
+// initProject creates a bare project and installs the packages
+func (p DataEnvironment) initProject(dir string) error {
+	if err := RunCommand(dir, "uv", "init", "--bare"); err != nil {
+		return fmt.Errorf("init uv: %w", err)
+	}
+	if err := RunCommand(dir, "uv", append([]string{"add"}, p.Packages...)...); err != nil {
+		return fmt.Errorf("uv add: %w", err)
+	}
+	return nil
+}
+
+// writeLayout creates the directories and README stub that are missing
+func (p DataEnvironment) writeLayout(dir string) error {
+	for _, d := range p.Dirs {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			return fmt.Errorf("create %s: %w", d, err)
+		}
+	}
+	return writeMissingFiles(dir, map[string]string{"README.md": p.readme(filepath.Base(dir))})
+}
+
+// readme returns the README stub describing the layout
+func (p DataEnvironment) readme(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", name)
+	b.WriteString("## Layout\n\n")
+	for _, d := range p.Dirs {
+		fmt.Fprintf(&b, "- `%s/`\n", d)
+	}
+	b.WriteString("\nStart Jupyter with `uv run jupyter lab`\n")
+	return b.String()
+}
+
+// Check verifies the project file, virtual environment and directories exist
+func (p DataEnvironment) Check(dir string) error {
+	return FilesExist(dir, append([]string{"pyproject.toml", ".venv"}, p.Dirs...)...)
+}
+
+// Ignore returns the virtual environment and notebook checkpoints
+func (p DataEnvironment) Ignore() []string {
+	return append(p.PythonEnvironment.Ignore(), ".ipynb_checkpoints")
+}
+
+// Reprovision initializes the project if pyproject.toml is missing, recreates the
+// virtual environment if .venv is missing and restores missing directories
+func (p DataEnvironment) Reprovision(dir string) error {
+	if FilesExist(dir, "pyproject.toml") != nil {
+		slog.Debug("Initializing missing project", slog.String("dir", dir))
+		if err := p.initProject(dir); err != nil {
+			return err
+		}
+	} else if FilesExist(dir, ".venv") != nil {
+		slog.Debug("Recreating missing virtual environment", slog.String("dir", dir))
+		if err := p.Rebuild(dir); err != nil {
+			return err
+		}
+	}
+	return p.writeLayout(dir)
+}
@@ -0,0 +1,36 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDataEnvironment(t *testing.T) {
+	p := main.NewDataEnvironment(main.DataConfig{})
+	require.Equal(t, []string{"data", "notebooks", "scripts"}, p.Dirs)
+	require.Equal(t, []string{"pandas", "jupyter"}, p.Packages)
+
+	p = main.NewDataEnvironment(main.DataConfig{Dirs: []string{"raw"}, Packages: []string{"polars"}})
+	require.Equal(t, []string{"raw"}, p.Dirs)
+	require.Equal(t, []string{"polars"}, p.Packages)
+
+	provisioner, err := main.Config{Data: main.DataConfig{Dirs: []string{"raw"}}}.Provisioner(main.DataSpec)
+	require.NoError(t, err)
+	require.Equal(t, []string{"raw"}, provisioner.(main.DataEnvironment).Dirs)
+}
+
+func TestDataEnvironment_Reprovision(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pyproject.toml"), nil, 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".venv"), 0755))
+
+	p := main.NewDataEnvironment(main.DataConfig{})
+	require.Error(t, p.Check(dir))
+	require.NoError(t, p.Reprovision(dir))
+	require.NoError(t, p.Check(dir))
+	require.FileExists(t, filepath.Join(dir, "README.md"))
+}
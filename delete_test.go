@@ -0,0 +1,108 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteCmd_FilesOrRecord(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	ctx := &main.CLIContext{}
+	store, err := ctx.Store()
+	require.NoError(t, err)
+	dataDir, err := main.DefaultDataDir()
+	require.NoError(t, err)
+	spec := main.NewSpec("kept", main.NotesSpec, dataDir)
+	require.NoError(t, main.NotesEnvironment{}.Provision(spec.Path))
+	require.NoError(t, main.WriteMarker(spec))
+	require.NoError(t, spec.Save(store))
+	id := main.IdentifyFlags{ID: spec.UID}
+
+	require.NoError(t, main.DeleteCmd{IdentifyFlags: id, Force: true, FilesOnly: true}.Run(ctx))
+	require.NoDirExists(t, spec.Path)
+	deprovisioned, err := main.LookupID(store, spec.UID)
+	require.NoError(t, err)
+	require.True(t, deprovisioned.IsDeprovisioned())
+	require.Error(t, main.DeleteCmd{IdentifyFlags: id, Force: true, FilesOnly: true}.Run(ctx))
+
+	require.NoError(t, main.ReprovisionCmd{BatchFlags: main.BatchFlags{IdentifyFlags: id, Jobs: 1}}.Run(ctx))
+	require.DirExists(t, spec.Path)
+	reprovisioned, err := main.LookupID(store, spec.UID)
+	require.NoError(t, err)
+	require.False(t, reprovisioned.IsDeprovisioned())
+	require.FileExists(t, filepath.Join(spec.Path, ".scratch.json"))
+
+	require.NoError(t, main.DeleteCmd{IdentifyFlags: id, Force: true, KeepFiles: true}.Run(ctx))
+	_, err = main.LookupID(store, spec.UID)
+	require.ErrorIs(t, err, main.ErrNotFound)
+	require.DirExists(t, spec.Path)
+	// Without its marker the directory is not taken for a lost environment
+	_, err = os.Stat(filepath.Join(spec.Path, ".scratch.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDeleteCmd_Workspace(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	config := main.Config{Workspaces: []string{"work"}}
+	require.NoError(t, config.Save())
+	require.NoError(t, main.UseWorkspace(config, "work"))
+	t.Cleanup(func() { main.UseWorkspace(config, main.DefaultWorkspace) })
+
+	ctx := &main.CLIContext{}
+	store, err := ctx.Store()
+	require.NoError(t, err)
+	// The data directory of the workspace sits next to the default one, not inside it
+	dataDir, err := main.DefaultDataDir()
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(dataDir, "scratch-work"), dataDir)
+	spec := main.NewSpec("job", main.NotesSpec, dataDir)
+	require.NoError(t, main.NotesEnvironment{}.Provision(spec.Path))
+	require.NoError(t, main.WriteMarker(spec))
+	require.NoError(t, spec.Save(store))
+
+	require.NoError(t, main.DeleteCmd{IdentifyFlags: main.IdentifyFlags{ID: spec.UID}, Force: true, Permanent: true}.Run(ctx))
+	require.NoDirExists(t, spec.Path)
+}
+
+func TestDeleteCmd_OSTrash(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("only the freedesktop.org trash can be redirected to a temporary directory")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	ctx := &main.CLIContext{}
+	t.Cleanup(func() { ctx.Close() })
+	store, err := ctx.Store()
+	require.NoError(t, err)
+	dataDir, err := main.DefaultDataDir()
+	require.NoError(t, err)
+	trashed := main.NewSpec("trashed", main.NotesSpec, dataDir)
+	removed := main.NewSpec("removed", main.NotesSpec, dataDir)
+	for _, spec := range []main.Spec{trashed, removed} {
+		require.NoError(t, main.NotesEnvironment{}.Provision(spec.Path))
+		require.NoError(t, main.WriteMarker(spec))
+		require.NoError(t, spec.Save(store))
+	}
+
+	require.NoError(t, main.DeleteCmd{IdentifyFlags: main.IdentifyFlags{ID: trashed.UID}, Force: true, Trash: true}.Run(ctx))
+	require.NoError(t, main.DeleteCmd{IdentifyFlags: main.IdentifyFlags{ID: removed.UID}, Force: true, Permanent: true}.Run(ctx))
+
+	path, err := main.DefaultHistoryPath()
+	require.NoError(t, err)
+	events, err := main.LoadEvents(path)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	// Directories in the trash of the system can be restored, so are not recorded as removed permanently
+	require.Equal(t, "system", events[0].Params["trash"])
+	require.NotContains(t, events[0].Params, "permanent")
+	require.Equal(t, "true", events[1].Params["permanent"])
+	require.NotContains(t, events[1].Params, "trash")
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// Description describes what a type creates, what it needs and how to use it
+type Description struct {
+	Type SpecType
+	// Summary says in one line what environments of the type are
+	Summary string
+	// Tools are the programs the type needs
+	Tools   []Tool
+	Options []Option
+	// Examples are command lines creating environments of the type
+	Examples []string
+}
+
+// Describer is implemented by provisioners that describe the environments they create
+type Describer interface {
+	// Describe returns the summary and examples of the type, the rest is filled in by DescribeType
+	Describe() Description
+}
+
+// DescribeType describes specType from its provisioner, with its tools and options
+func DescribeType(specType SpecType) (Description, error) {
+	p, err := NewProvisioner(specType)
+	if err != nil {
+		return Description{}, err
+	}
+	d := Description{Examples: []string{fmt.Sprintf("scratch new demo -t %s", specType)}}
+	if describer, ok := p.(Describer); ok {
+		d = describer.Describe()
+	}
+	d.Type = specType
+	if user, ok := p.(ToolUser); ok {
+		d.Tools = user.Tools()
+	}
+	if configurable, ok := p.(Configurable); ok {
+		d.Options = configurable.Options()
+	}
+	return d, nil
+}
+
+// Write prints the description with the tools that are missing marked
+func (d Description) Write(w io.Writer) {
+	fmt.Fprintf(w, "%s: %s\n", d.Type, d.Summary)
+	if len(d.Tools) > 0 {
+		tools := make([]string, len(d.Tools))
+		for i, tool := range d.Tools {
+			tools[i] = tool.Name
+			if CommandsExist(tool.Name) != nil {
+				tools[i] += " (missing, " + tool.Hint() + ")"
+			}
+		}
+		fmt.Fprintf(w, "  Tools:    %s\n", strings.Join(tools, ", "))
+	}
+	for i, option := range d.Options {
+		label := ""
+		if i == 0 {
+			label = "Options:"
+		}
+		fmt.Fprintf(w, "  %-9s --opt %s\n", label, option)
+	}
+	for i, example := range d.Examples {
+		label := ""
+		if i == 0 {
+			label = "Examples:"
+		}
+		fmt.Fprintf(w, "  %-9s %s\n", label, example)
+	}
+}
+
+// TypesCmd represents the command to list the types of environments
+type TypesCmd struct {
+	Types    []SpecType `arg:"" optional:"" help:"The types to list, all by default"`
+	Describe bool       `help:"Describe the tools, options and examples of each type and check it is ready"`
+}
+
+// Run lists the built-in types and the types of plugins with their summaries
+func (t TypesCmd) Run() error {
+	types := t.Types
+	if len(types) == 0 {
+		types = AllSpecTypes()
+	}
+	var ready []error
+	if t.Describe {
+		ready = CheckReadiness(types, DefaultReadyTimeout)
+	}
+	for i, specType := range types {
+		d, err := DescribeType(specType)
+		if err != nil {
+			return err
+		}
+		if !t.Describe {
+			fmt.Printf("%-13s %s\n", specType, d.Summary)
+			continue
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		d.Write(os.Stdout)
+		if ready[i] != nil {
+			fmt.Printf("  %-9s no, %s\n", "Ready:", ready[i])
+		} else {
+			fmt.Printf("  %-9s yes\n", "Ready:")
+		}
+	}
+	return nil
+}
+
+// HelpTypeFlag describes a type and exits, before the name of the environment is
+// required like the help flag
+type HelpTypeFlag string
+
+// BeforeReset describes the type passed to the flag
+func (h HelpTypeFlag) BeforeReset(ctx *kong.Context, trace *kong.Path) error {
+	d, err := DescribeType(SpecType(fmt.Sprint(ctx.FlagValue(trace.Flag))))
+	if err != nil {
+		return err
+	}
+	d.Write(ctx.Stdout)
+	ctx.Kong.Exit(0)
+	return nil
+}
@@ -0,0 +1,37 @@
+package main_test
+
+import (
+	"bytes"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeType(t *testing.T) {
+	// Every type describes itself instead of one it embeds
+	summaries := map[string]main.SpecType{}
+	for _, specType := range main.SpecTypes {
+		d, err := main.DescribeType(specType)
+		require.NoError(t, err)
+		require.Equal(t, specType, d.Type)
+		require.NotEmpty(t, d.Summary, specType)
+		require.NotEmpty(t, d.Examples, specType)
+		other, ok := summaries[d.Summary]
+		require.False(t, ok, "%s has the summary of %s", specType, other)
+		summaries[d.Summary] = specType
+	}
+
+	d, err := main.DescribeType(main.WebSpec)
+	require.NoError(t, err)
+	require.Equal(t, "npm", d.Tools[0].Name)
+	require.Equal(t, "framework", d.Options[0].Name)
+
+	var out bytes.Buffer
+	d.Write(&out)
+	require.Contains(t, out.String(), "web: A frontend project created with Vite\n")
+	require.Contains(t, out.String(), "  Options:  --opt framework=vanilla|react|vue|svelte: ")
+
+	_, err = main.DescribeType("nope")
+	require.ErrorIs(t, err, main.ErrUnknownType)
+}
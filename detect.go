@@ -0,0 +1,123 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Confidence levels reported by detectors
+const (
+	// NoMatch means the directory has nothing of the type
+	NoMatch = 0.0
+	// WeakMatch is reported for files other types use as well, like package.json
+	WeakMatch = 0.3
+	// LikelyMatch is reported for files mostly used by the type, like requirements.txt
+	LikelyMatch = 0.6
+	// StrongMatch is reported for the config file the type's tool writes
+	StrongMatch = 0.9
+)
+
+// Detector is implemented by provisioners that recognize their projects in existing directories
+type Detector interface {
+	// Detect returns the confidence from 0 to 1 that dir is a project of the type
+	Detect(dir string) float64
+}
+
+// Detection is how confident the detector of a type is about a directory
+type Detection struct {
+	Type       SpecType
+	Confidence float64
+}
+
+// String describes the detection like "python (90%)"
+func (d Detection) String() string {
+	return fmt.Sprintf("%s (%.0f%%)", d.Type, d.Confidence*100)
+}
+
+// detectFiles returns the highest confidence of the files in scores that exist in dir
+func detectFiles(dir string, scores map[string]float64) float64 {
+	confidence := NoMatch
+	for name, score := range scores {
+		if score > confidence && FilesExist(dir, name) == nil {
+			confidence = score
+		}
+	}
+	return confidence
+}
+
+// detectGlob returns score if a file in dir matches pattern
+func detectGlob(dir string, pattern string, score float64) float64 {
+	if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) > 0 {
+		return score
+	}
+	return NoMatch
+}
+
+// DetectTypes asks the detector of every type about dir and returns the types
+// that match, most confident first, in the order of types on a tie
+func DetectTypes(dir string) ([]Detection, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	detections := []Detection{}
+	for _, t := range AllSpecTypes() {
+		p, err := NewProvisioner(t)
+		if err != nil {
+			continue
+		}
+		detector, ok := p.(Detector)
+		if !ok {
+			continue
+		}
+		if confidence := detector.Detect(dir); confidence > NoMatch {
+			detections = append(detections, Detection{Type: t, Confidence: confidence})
+		}
+	}
+	slices.SortStableFunc(detections, func(a, b Detection) int {
+		return cmp.Compare(b.Confidence, a.Confidence)
+	})
+	return detections, nil
+}
+
+// DetectType detects the environment type of an existing directory from its files,
+// failing unless a type is more than a weak match
+func DetectType(dir string) (SpecType, error) {
+	detections, err := DetectTypes(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(detections) == 0 || detections[0].Confidence <= WeakMatch {
+		return "", fmt.Errorf("could not detect environment type of %s", dir)
+	}
+	return detections[0].Type, nil
+}
+
+// describeDetection names the type dir most likely is, or unknown when no type matches
+func describeDetection(dir string) string {
+	detections, err := DetectTypes(dir)
+	if err != nil || len(detections) == 0 {
+		return "unknown"
+	}
+	return detections[0].String()
+}
+
+// detectMismatch describes a directory of spec that no longer looks like its type
+// but confidently like another, or returns "" when it matches or cannot tell
+func detectMismatch(spec Spec) string {
+	p, err := NewProvisioner(spec.Type)
+	if err != nil {
+		return ""
+	}
+	detector, ok := p.(Detector)
+	if !ok || detector.Detect(spec.Path) > NoMatch {
+		return ""
+	}
+	detections, err := DetectTypes(spec.Path)
+	if err != nil || len(detections) == 0 || detections[0].Confidence < StrongMatch {
+		return ""
+	}
+	return fmt.Sprintf("looks like a %s project, not %s", detections[0].Type, spec.Type)
+}
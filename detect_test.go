@@ -0,0 +1,75 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+// project creates a directory with the named files and directories, which end in a slash
+func project(t *testing.T, names ...string) string {
+	dir := t.TempDir()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if filepath.Base(name)+"/" == name {
+			require.NoError(t, os.MkdirAll(path, 0755))
+			continue
+		}
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+	return dir
+}
+
+func TestDetectTypes(t *testing.T) {
+	detections, err := main.DetectTypes(project(t, "pyproject.toml", "main.py"))
+	require.NoError(t, err)
+	require.Equal(t, main.Detection{Type: main.PythonSpec, Confidence: main.StrongMatch}, detections[0])
+	require.Equal(t, "python (90%)", detections[0].String())
+
+	// The layout of data environments outranks plain python
+	detections, err = main.DetectTypes(project(t, "pyproject.toml", "data/", "notebooks/", "scripts/"))
+	require.NoError(t, err)
+	require.Equal(t, main.DataSpec, detections[0].Type)
+	require.Equal(t, main.PythonSpec, detections[1].Type)
+
+	detections, err = main.DetectTypes(project(t, "README.md"))
+	require.NoError(t, err)
+	require.Empty(t, detections)
+
+	cases := []struct {
+		files []string
+		want  main.SpecType
+	}{
+		{[]string{"requirements.txt"}, main.PythonSpec},
+		{[]string{"deno.lock"}, main.DenoSpec},
+		{[]string{"package.json", "bun.lockb"}, main.BunSpec},
+		{[]string{"paper.tex"}, main.LatexSpec},
+		{[]string{".copier-answers.yml", "package.json"}, main.CopierSpec},
+	}
+	for _, c := range cases {
+		specType, err := main.DetectType(project(t, c.files...))
+		require.NoError(t, err)
+		require.Equal(t, c.want, specType, c.files)
+	}
+
+	// Files many tools use are too weak to go by
+	_, err = main.DetectType(project(t, "package.json"))
+	require.Error(t, err)
+}
+
+func TestVerifySpec_Mismatch(t *testing.T) {
+	spec := main.Spec{Name: "switched", Type: main.DenoSpec, Path: project(t, "deno.json")}
+	require.Nil(t, main.VerifySpec(spec))
+
+	spec.Path = project(t, "pyproject.toml")
+	drift := main.VerifySpec(spec)
+	require.NotNil(t, drift)
+	require.Equal(t, "looks like a python project, not deno", drift.Problem)
+
+	// Directories that look like nothing in particular are left to the checks of the type
+	spec.Path = project(t, "notes.txt")
+	require.Equal(t, "missing deno.json", main.VerifySpec(spec).Problem)
+}
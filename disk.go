@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultRequiredSpace estimates the disk space needed to provision each type
+var DefaultRequiredSpace = map[SpecType]ByteSize{
+	PythonSpec:       200 * MB,
+	DenoSpec:         50 * MB,
+	BunSpec:          100 * MB,
+	LatexSpec:        1 * MB,
+	RSpec:            20 * MB,
+	JuliaSpec:        50 * MB,
+	JavaSpec:         100 * MB,
+	KotlinSpec:       100 * MB,
+	TerraformSpec:    100 * MB,
+	ShellSpec:        1 * MB,
+	NotesSpec:        1 * MB,
+	WebSpec:          200 * MB,
+	MLSpec:           5 * GB,
+	DataSpec:         500 * MB,
+	TemplateSpec:     50 * MB,
+	CookiecutterSpec: 50 * MB,
+	CopierSpec:       50 * MB,
+}
+
+// RequiredSpace returns the configured or default space needed to provision specType
+func (c Config) RequiredSpace(specType SpecType) ByteSize {
+	if size, ok := c.Disk.RequiredSpace[specType]; ok {
+		return size
+	}
+	return DefaultRequiredSpace[specType]
+}
+
+// nearestExistingDir walks up from path to the first directory that exists
+func nearestExistingDir(path string) (string, error) {
+	dir := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no existing parent directory for %s", path)
+		}
+		dir = parent
+	}
+}
+
+// CheckDiskSpace checks the filesystem that will hold path has at least required bytes free
+func CheckDiskSpace(path string, required ByteSize) error {
+	if required == 0 {
+		return nil
+	}
+
+	dir, err := nearestExistingDir(path)
+	if err != nil {
+		return err
+	}
+
+	free, err := FreeSpace(dir)
+	if err != nil {
+		return fmt.Errorf("check free space: %w", err)
+	}
+
+	if free < required {
+		return fmt.Errorf("not enough disk space at %s: %s free, %s required", dir, free, required)
+	}
+	return nil
+}
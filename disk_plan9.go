@@ -0,0 +1,6 @@
+package main
+
+// FreeSpace is not supported on plan9 and reports unlimited space
+func FreeSpace(dir string) (ByteSize, error) {
+	return ^ByteSize(0), nil
+}
@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+
+package main
+
+import "syscall"
+
+// FreeSpace returns the bytes available to unprivileged users on the filesystem holding dir
+func FreeSpace(dir string) (ByteSize, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return ByteSize(uint64(st.Bavail) * uint64(st.Bsize)), nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FindOrphanDirs returns directories directly under dataDir that are not
+// used by any of the specs
+func FindOrphanDirs(dataDir string, specs []Spec) ([]string, error) {
+	entries, err := os.ReadDir(dataDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read data dir: %w", err)
+	}
+
+	orphans := []string{}
+	for _, entry := range entries {
+		// Hidden directories like the archive are managed by scratch
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(dataDir, entry.Name())
+		used := false
+		for _, spec := range specs {
+			if (Spec{Path: dir}).Contains(spec.Path) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			orphans = append(orphans, dir)
+		}
+	}
+	return orphans, nil
+}
+
+// findOrphans finds untracked directories under the data directory and configured roots
+func findOrphans(ctx *CLIContext, specs []Spec) ([]string, error) {
+	config, err := ctx.Config()
+	if err != nil {
+		return nil, err
+	}
+	dataDir, err := DefaultDataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []string{dataDir}
+	for _, rule := range config.Roots {
+		if !slices.Contains(roots, rule.Path) {
+			roots = append(roots, rule.Path)
+		}
+	}
+
+	orphans := []string{}
+	for _, root := range roots {
+		dirs, err := FindOrphanDirs(root, specs)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, dirs...)
+	}
+	return orphans, nil
+}
+
+// checkWritable checks dir exists and files can be created in it
+func checkWritable(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("directory %s does not exist", dir)
+	}
+	f, err := os.CreateTemp(dir, ".doctor-")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// diagnosis is the outcome of a doctor check with an optional repair
+type diagnosis struct {
+	name string
+	err  error
+	fix  func() error
+}
+
+// DoctorCmd represents the command to diagnose the installation and environments
+type DoctorCmd struct {
+	Open string `short:"o" help:"The program environments are opened in" default:"code"`
+	Fix  bool   `help:"Repair problems where possible"`
+	// ReadyTimeout bounds each provisioner, which are checked concurrently
+	ReadyTimeout Duration `help:"How long each provisioner may take to check it is ready" default:"5s"`
+}
+
+// diagnose runs every check and returns the results
+func (d DoctorCmd) diagnose(ctx *CLIContext) []diagnosis {
+	results := []diagnosis{}
+
+	dirs := []struct {
+		name string
+		get  func() (string, error)
+	}{
+		{"config directory", DefaultConfigDir},
+		{"data directory", DefaultDataDir},
+	}
+	for _, d := range dirs {
+		dir, err := d.get()
+		if err != nil {
+			results = append(results, diagnosis{name: d.name, err: err})
+			continue
+		}
+		results = append(results, diagnosis{
+			name: fmt.Sprintf("%s %s", d.name, dir),
+			err:  checkWritable(dir),
+			fix:  func() error { return EnsureDirectory(dir) },
+		})
+	}
+
+	types := AllSpecTypes()
+	for i, err := range CheckReadiness(types, time.Duration(d.ReadyTimeout)) {
+		results = append(results, diagnosis{name: fmt.Sprintf("provisioner %s", types[i]), err: err})
+	}
+
+	results = append(results, diagnosis{name: fmt.Sprintf("opener %s", d.Open), err: OpenerExists(d.Open)})
+
+	store, err := ctx.Store()
+	results = append(results, diagnosis{name: "store", err: err})
+	if err != nil {
+		return results
+	}
+
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return append(results, diagnosis{name: "load environments", err: err})
+	}
+	for _, spec := range specs {
+		if spec.Exists() || spec.IsArchived() || spec.IsDeprovisioned() {
+			continue
+		}
+		results = append(results, diagnosis{
+			name: fmt.Sprintf("environment %s", spec.ID()),
+			err:  fmt.Errorf("directory %s does not exist", spec.Path),
+			fix:  func() error { return spec.Delete(store) },
+		})
+	}
+
+	orphans, err := findOrphans(ctx, specs)
+	if err != nil {
+		return append(results, diagnosis{name: "orphaned directories", err: err})
+	}
+	for _, dir := range orphans {
+		name := fmt.Sprintf("directory %s", dir)
+		spec, ok, err := ReadMarker(dir)
+		switch {
+		case err != nil:
+			results = append(results, diagnosis{name: name, err: err})
+		case ok:
+			// The marker makes the directory the record of an environment lost from the store
+			results = append(results, diagnosis{
+				name: name,
+				err:  fmt.Errorf("not tracked, but its %s records %s", markerFile, spec.ID()),
+				fix: func() error {
+					_, err := saveAdopted(store, spec)
+					return err
+				},
+			})
+		default:
+			results = append(results, diagnosis{
+				name: name,
+				err:  fmt.Errorf("not tracked by any environment, detected type %s, track it with 'scratch adopt %s'", describeDetection(dir), dir),
+			})
+		}
+	}
+
+	return results
+}
+
+// Run prints the result of each check and repairs problems if requested
+func (d DoctorCmd) Run(ctx *CLIContext) error {
+	failed := 0
+	for _, r := range d.diagnose(ctx) {
+		if r.err == nil {
+			fmt.Printf("OK    %s\n", r.name)
+			continue
+		}
+
+		if d.Fix && r.fix != nil {
+			if err := r.fix(); err != nil {
+				failed++
+				fmt.Printf("FAIL  %s: %v (fix failed: %v)\n", r.name, r.err, err)
+				continue
+			}
+			slog.Debug("Fixed", slog.String("check", r.name))
+			fmt.Printf("FIXED %s: %v\n", r.name, r.err)
+			continue
+		}
+
+		failed++
+		if r.fix != nil {
+			fmt.Printf("FAIL  %s: %v (fixable with --fix)\n", r.name, r.err)
+		} else {
+			fmt.Printf("FAIL  %s: %v\n", r.name, r.err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d problems found", failed)
+	}
+	return nil
+}
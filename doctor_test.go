@@ -0,0 +1,30 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindOrphanDirs(t *testing.T) {
+	tdir := t.TempDir()
+	for _, dir := range []string{"tracked", "orphan", filepath.Join("group", "nested")} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tdir, dir), 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(tdir, "file"), nil, 0644))
+
+	specs := []main.Spec{
+		main.NewSpec("tracked", main.PythonSpec, tdir),
+		main.NewSpec("nested", main.PythonSpec, filepath.Join(tdir, "group")),
+	}
+	orphans, err := main.FindOrphanDirs(tdir, specs)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(tdir, "orphan")}, orphans)
+
+	orphans, err = main.FindOrphanDirs(filepath.Join(tdir, "missing"), specs)
+	require.NoError(t, err)
+	require.Empty(t, orphans)
+}
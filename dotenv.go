@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// dotEnvFile holds the environment variables of an environment
+const dotEnvFile = ".env"
+
+// validEnvKey matches names of environment variables
+var validEnvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateEnvKeys checks every key of vars is a valid environment variable name
+func ValidateEnvKeys(vars map[string]string) error {
+	for _, key := range slices.Sorted(maps.Keys(vars)) {
+		if !validEnvKey.MatchString(key) {
+			return fmt.Errorf("invalid environment variable name %q", key)
+		}
+	}
+	return nil
+}
+
+// quoteEnvValue quotes value if it would not be read back as is
+func quoteEnvValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\r\n\"'#$\\`") {
+		return value
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(value) + `"`
+}
+
+// WriteDotEnv adds vars to the .env file in dir, creating it readable only by the user.
+// Variables appended to an existing file take precedence over earlier lines.
+func WriteDotEnv(dir string, vars map[string]string) error {
+	f, err := os.OpenFile(filepath.Join(dir, dotEnvFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", dotEnvFile, err)
+	}
+	defer f.Close()
+
+	for _, key := range slices.Sorted(maps.Keys(vars)) {
+		if _, err := fmt.Fprintf(f, "%s=%s\n", key, quoteEnvValue(vars[key])); err != nil {
+			return fmt.Errorf("write %s: %w", dotEnvFile, err)
+		}
+	}
+	return f.Close()
+}
+
+// ParseDotEnv reads variables in the common .env format: KEY=value lines with an
+// optional export prefix, # comments, and single or double quoted values
+func ParseDotEnv(r io.Reader) (map[string]string, error) {
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !validEnvKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=value", n)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// parseEnvValue unquotes a value, dropping comments after unquoted values
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `'`):
+		end := strings.Index(value[1:], `'`)
+		if end < 0 {
+			return "", errors.New("unterminated single quote")
+		}
+		return value[1 : end+1], nil
+	case strings.HasPrefix(value, `"`):
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; c {
+			case '"':
+				return b.String(), nil
+			case '\\':
+				i++
+				if i == len(value) {
+					return "", errors.New("unterminated double quote")
+				}
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double quote")
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// LoadDotEnv reads the .env file in dir as KEY=value entries for exec.Cmd.Env,
+// returning nothing if there is none
+func LoadDotEnv(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, dotEnvFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", dotEnvFile, err)
+	}
+	defer f.Close()
+
+	vars, err := ParseDotEnv(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Join(dir, dotEnvFile), err)
+	}
+	env := []string{}
+	for _, key := range slices.Sorted(maps.Keys(vars)) {
+		env = append(env, key+"="+vars[key])
+	}
+	return env, nil
+}
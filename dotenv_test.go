@@ -0,0 +1,60 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	input := `# API settings
+API_KEY=abc123
+export REGION = eu-west-1  # inline comment
+EMPTY=
+SINGLE='literal $HOME # kept'
+DOUBLE="line\nbreak \"quoted\""
+`
+	vars, err := main.ParseDotEnv(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"API_KEY": "abc123",
+		"REGION":  "eu-west-1",
+		"EMPTY":   "",
+		"SINGLE":  "literal $HOME # kept",
+		"DOUBLE":  "line\nbreak \"quoted\"",
+	}, vars)
+
+	_, err = main.ParseDotEnv(strings.NewReader("not a variable\n"))
+	require.ErrorContains(t, err, "line 1")
+	_, err = main.ParseDotEnv(strings.NewReader(`KEY="open`))
+	require.Error(t, err)
+}
+
+func TestWriteDotEnv(t *testing.T) {
+	dir := t.TempDir()
+	vars := map[string]string{"TOKEN": `a b"c$d\e`, "EMPTY": "", "PLAIN": "value"}
+	require.NoError(t, main.WriteDotEnv(dir, vars))
+
+	info, err := os.Stat(filepath.Join(dir, ".env"))
+	require.NoError(t, err)
+	if filepath.Separator == '/' {
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// Later writes override earlier values
+	require.NoError(t, main.WriteDotEnv(dir, map[string]string{"PLAIN": "other"}))
+	env, err := main.LoadDotEnv(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"EMPTY=", "PLAIN=other", `TOKEN=a b"c$d\e`}, env)
+
+	env, err = main.LoadDotEnv(t.TempDir())
+	require.NoError(t, err)
+	require.Empty(t, env)
+
+	require.Error(t, main.ValidateEnvKeys(map[string]string{"1X": ""}))
+	require.NoError(t, main.ValidateEnvKeys(vars))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+// ParseDuration extends time.ParseDuration with days and weeks, e.g. "14d" or "2w"
+func ParseDuration(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": Day, "w": Week} {
+		if n, ok := strings.CutSuffix(str, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// FormatDuration formats d in the largest whole unit of days, hours or minutes
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d >= Day:
+		return fmt.Sprintf("%dd", d/Day)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+// Duration is a time.Duration flag accepting days and weeks
+type Duration time.Duration
+
+// UnmarshalText parses the duration for flags and config
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats the duration for config
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(FormatDuration(time.Duration(d))), nil
+}
+
+func (d Duration) String() string {
+	return FormatDuration(time.Duration(d))
+}
@@ -0,0 +1,35 @@
+package main_test
+
+import (
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"14d":  14 * main.Day,
+		"2w":   2 * main.Week,
+		"1.5d": 36 * time.Hour,
+		"90m":  90 * time.Minute,
+	}
+	for input, expected := range cases {
+		got, err := main.ParseDuration(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, got, input)
+	}
+
+	for _, input := range []string{"", "soon", "-1d", "d"} {
+		_, err := main.ParseDuration(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "3d", main.FormatDuration(3*main.Day+time.Hour))
+	assert.Equal(t, "5h", main.FormatDuration(5*time.Hour))
+	assert.Equal(t, "0m", main.FormatDuration(time.Second))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// editorSettingsFile is where VS Code reads settings of a folder
+var editorSettingsFile = filepath.Join(".vscode", "settings.json")
+
+// venvPython returns the interpreter of the virtual environment relative to the folder
+func venvPython(goos string) string {
+	if goos == "windows" {
+		return "${workspaceFolder}\\.venv\\Scripts\\python.exe"
+	}
+	return "${workspaceFolder}/.venv/bin/python"
+}
+
+// EditorSettings returns the VS Code settings for environments of specType on goos,
+// or nil for types without any
+func EditorSettings(specType SpecType, goos string) map[string]any {
+	switch specType {
+	case PythonSpec, DataSpec:
+		return map[string]any{
+			"python.defaultInterpreterPath":       venvPython(goos),
+			"python.terminal.activateEnvironment": true,
+		}
+	case DenoSpec:
+		return map[string]any{
+			"deno.enable": true,
+			"deno.lint":   true,
+		}
+	case BunSpec:
+		return map[string]any{
+			"typescript.tsdk": "node_modules/typescript/lib",
+		}
+	case LatexSpec:
+		return map[string]any{
+			"latex-workshop.latex.outDir": "build",
+		}
+	}
+	return nil
+}
+
+// WriteEditorConfig writes VS Code settings for specType into dir unless the type
+// has none or dir already has settings, like ones from a template, and reports if it wrote them
+func WriteEditorConfig(dir string, specType SpecType) (bool, error) {
+	settings := EditorSettings(specType, runtime.GOOS)
+	if settings == nil {
+		return false, nil
+	}
+	path := filepath.Join(dir, editorSettingsFile)
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return false, fmt.Errorf("marshal editor settings: %w", err)
+	}
+	if err := EnsureDirectory(filepath.Dir(path)); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return false, fmt.Errorf("write editor settings: %w", err)
+	}
+	return true, nil
+}
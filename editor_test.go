@@ -0,0 +1,54 @@
+package main_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditorSettings(t *testing.T) {
+	settings := main.EditorSettings(main.PythonSpec, "linux")
+	require.Equal(t, "${workspaceFolder}/.venv/bin/python", settings["python.defaultInterpreterPath"])
+
+	settings = main.EditorSettings(main.DataSpec, "windows")
+	require.Equal(t, `${workspaceFolder}\.venv\Scripts\python.exe`, settings["python.defaultInterpreterPath"])
+
+	require.Equal(t, true, main.EditorSettings(main.DenoSpec, "linux")["deno.enable"])
+	require.Nil(t, main.EditorSettings(main.CopierSpec, "linux"))
+}
+
+func TestWriteEditorConfig(t *testing.T) {
+	dir := t.TempDir()
+	wrote, err := main.WriteEditorConfig(dir, main.DenoSpec)
+	require.NoError(t, err)
+	require.True(t, wrote)
+
+	data, err := os.ReadFile(filepath.Join(dir, ".vscode", "settings.json"))
+	require.NoError(t, err)
+	var settings map[string]any
+	require.NoError(t, json.Unmarshal(data, &settings))
+	require.Equal(t, true, settings["deno.lint"])
+
+	t.Run("keeps existing settings", func(t *testing.T) {
+		path := filepath.Join(dir, ".vscode", "settings.json")
+		require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0644))
+		wrote, err := main.WriteEditorConfig(dir, main.DenoSpec)
+		require.NoError(t, err)
+		require.False(t, wrote)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "{}\n", string(data))
+	})
+
+	t.Run("skips types without settings", func(t *testing.T) {
+		dir := t.TempDir()
+		wrote, err := main.WriteEditorConfig(dir, main.TemplateSpec)
+		require.NoError(t, err)
+		require.False(t, wrote)
+		require.NoDirExists(t, filepath.Join(dir, ".vscode"))
+	})
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Encrypted stores seal spec values with AES-256-GCM. Keys, including the name
+// index, stay readable so environments can be looked up before decrypting.
+const (
+	metaKeyPrefix = "meta/"
+	// encryptionKey holds the encryption settings, outside of every workspace
+	encryptionKey = metaKeyPrefix + "encryption"
+	// passphraseEnv holds the passphrase so scripts are not prompted for it
+	passphraseEnv = "SCRATCH_PASSPHRASE"
+	// passphraseIterations is the PBKDF2-SHA256 work factor recommended by OWASP
+	passphraseIterations = 600_000
+	keySize              = 32
+)
+
+// encryptionCheck is sealed with the key to tell a wrong key from corrupt data
+var encryptionCheck = []byte(AppName)
+
+// specCipher seals spec values when the store is encrypted, set once the store is opened
+var specCipher cipher.AEAD
+
+// KeySource is where the key of an encrypted store comes from
+type KeySource string
+
+var (
+	KeyringSource    KeySource = "keyring"
+	PassphraseSource KeySource = "passphrase"
+)
+
+// Encryption records how the store is encrypted, without the key
+type Encryption struct {
+	Source KeySource
+	// Salt derives the key from the passphrase
+	Salt  []byte `json:",omitempty"`
+	Check []byte
+}
+
+// sealedSpec is the record of an encrypted spec, which is still valid JSON for the JSON store
+type sealedSpec struct {
+	Encrypted []byte
+}
+
+// newCipher returns the AES-GCM cipher of key
+func newCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data with a random nonce prepended
+func seal(aead cipher.AEAD, data []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	return aead.Seal(nonce, nonce, data, nil)
+}
+
+// unseal decrypts data sealed by seal
+func unseal(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
+
+// sealSpec encrypts an encoded spec if the store is encrypted
+func sealSpec(data []byte) ([]byte, error) {
+	if specCipher == nil {
+		return data, nil
+	}
+	return json.Marshal(sealedSpec{Encrypted: seal(specCipher, data)})
+}
+
+// unsealSpec decrypts the encoded spec sealed in raw
+func unsealSpec(raw json.RawMessage) ([]byte, error) {
+	if specCipher == nil {
+		return nil, fmt.Errorf("environment is encrypted but the store has no key: %w", ErrStore)
+	}
+	var sealed []byte
+	if err := json.Unmarshal(raw, &sealed); err != nil {
+		return nil, fmt.Errorf("decode encrypted spec: %w", err)
+	}
+	data, err := unseal(specCipher, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt spec: %w", err)
+	}
+	return data, nil
+}
+
+// redactSealed clears the fields of spec that an encrypted store keeps sealed, for
+// copies written outside of it like the marker, the README and the history log
+func redactSealed(spec Spec) Spec {
+	if specCipher == nil {
+		return spec
+	}
+	spec.Description = ""
+	spec.Meta = nil
+	spec.Env = nil
+	return spec
+}
+
+// passphraseKey derives the key from passphrase and salt
+func passphraseKey(passphrase string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, salt, passphraseIterations, keySize)
+}
+
+// readPassphrase reads the passphrase from SCRATCH_PASSPHRASE or asks for it
+func readPassphrase(prompt string) (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if !canPrompt() {
+		return "", ErrNoPassphrase
+	}
+	return askForSecret(prompt)
+}
+
+// NewEncryption creates a random key saved in the OS keyring, or derives one from
+// passphrase, and returns the settings to store with it
+func NewEncryption(source KeySource, passphrase string) (Encryption, []byte, error) {
+	enc := Encryption{Source: source}
+	var key []byte
+	switch source {
+	case KeyringSource:
+		key = make([]byte, keySize)
+		rand.Read(key)
+		if err := keyringSet(hex.EncodeToString(key)); err != nil {
+			return Encryption{}, nil, err
+		}
+	case PassphraseSource:
+		if passphrase == "" {
+			return Encryption{}, nil, errors.New("passphrase must not be empty")
+		}
+		enc.Salt = make([]byte, 16)
+		rand.Read(enc.Salt)
+		var err error
+		if key, err = passphraseKey(passphrase, enc.Salt); err != nil {
+			return Encryption{}, nil, err
+		}
+	default:
+		return Encryption{}, nil, fmt.Errorf("unknown key source %q", source)
+	}
+
+	aead, err := newCipher(key)
+	if err != nil {
+		return Encryption{}, nil, err
+	}
+	enc.Check = seal(aead, encryptionCheck)
+	return enc, key, nil
+}
+
+// Cipher gets the key from its source and checks it is the one the store was encrypted with
+func (e Encryption) Cipher() (cipher.AEAD, error) {
+	var key []byte
+	switch e.Source {
+	case KeyringSource:
+		secret, err := keyringGet()
+		if err != nil {
+			return nil, fmt.Errorf("get key of encrypted store: %w", err)
+		}
+		if key, err = hex.DecodeString(secret); err != nil {
+			return nil, fmt.Errorf("decode key from keyring: %w", err)
+		}
+	case PassphraseSource:
+		passphrase, err := readPassphrase("Passphrase")
+		if err != nil {
+			return nil, err
+		}
+		if key, err = passphraseKey(passphrase, e.Salt); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown key source %q", e.Source)
+	}
+
+	aead, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := unseal(aead, e.Check); err != nil {
+		return nil, fmt.Errorf("wrong %s for encrypted store: %w", e.Source, ErrStore)
+	}
+	return aead, nil
+}
+
+// LoadEncryption reads the encryption settings of store, returning nil if it is not encrypted
+func LoadEncryption(store Reader) (*Encryption, error) {
+	data, err := store.Get(encryptionKey)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var enc Encryption
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, fmt.Errorf("unmarshal encryption settings: %w", err)
+	}
+	return &enc, nil
+}
+
+// UnlockStore gets the key of store so specs are sealed and unsealed with it,
+// doing nothing if the store is not encrypted
+func UnlockStore(store Reader) error {
+	enc, err := LoadEncryption(store)
+	if err != nil || enc == nil {
+		specCipher = nil
+		return err
+	}
+	// The key is asked for once even when the store is opened again, like by serve
+	if specCipher != nil {
+		if _, err := unseal(specCipher, enc.Check); err == nil {
+			return nil
+		}
+	}
+	specCipher = nil
+	aead, err := enc.Cipher()
+	if err != nil {
+		return err
+	}
+	specCipher = aead
+	return nil
+}
+
+// unscopedKey returns key without the prefix of its workspace
+func unscopedKey(key string) string {
+	if rest, ok := strings.CutPrefix(key, workspaceKeyPrefix); ok {
+		_, key, _ = strings.Cut(rest, "/")
+	}
+	return key
+}
+
+// isStoredSpec checks if key holds a tracked or deleted spec in any workspace
+func isStoredSpec(key string) bool {
+	key = unscopedKey(key)
+	return isSpecKey(key) || strings.HasPrefix(key, trashKeyPrefix)
+}
+
+// resealSpecs saves every spec in every workspace of store with aead, or
+// unencrypted when nil, together with the encryption settings
+func resealSpecs(store Storer, aead cipher.AEAD, enc *Encryption) (int, error) {
+	specs := map[string]Spec{}
+	err := store.ListFunc(func(key string, data []byte) error {
+		if !isStoredSpec(key) {
+			return nil
+		}
+		spec, _, err := decodeSpec(data)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", key, err)
+		}
+		specs[key] = spec
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	previous := specCipher
+	specCipher = aead
+	err = store.Batch(func(w Writer) error {
+		for key, spec := range specs {
+			data, err := marshalSpec(spec)
+			if err != nil {
+				return fmt.Errorf("marshal spec to json: %w", err)
+			}
+			if err := w.Put(key, data); err != nil {
+				return err
+			}
+		}
+		if enc == nil {
+			return w.Delete(encryptionKey)
+		}
+		data, err := json.Marshal(enc)
+		if err != nil {
+			return fmt.Errorf("marshal encryption settings: %w", err)
+		}
+		return w.Put(encryptionKey, data)
+	})
+	if err != nil {
+		specCipher = previous
+		return 0, err
+	}
+	// Markers now leave out what the store seals, or no longer does
+	for key, spec := range specs {
+		if isSpecKey(unscopedKey(key)) {
+			refreshMarker(spec)
+		}
+	}
+	return len(specs), nil
+}
+
+// EncryptStore encrypts every spec in store with key
+func EncryptStore(store Storer, enc Encryption, key []byte) (int, error) {
+	aead, err := newCipher(key)
+	if err != nil {
+		return 0, err
+	}
+	return resealSpecs(store, aead, &enc)
+}
+
+// DecryptStore saves every spec in store unencrypted, which must be unlocked
+func DecryptStore(store Storer) (int, error) {
+	return resealSpecs(store, nil, nil)
+}
+
+// rootStore returns the store of ctx with the keys of every workspace
+func rootStore(ctx *CLIContext) (Storer, error) {
+	store, err := ctx.Store()
+	if err != nil {
+		return nil, err
+	}
+	if ws, ok := store.(*WorkspaceStore); ok {
+		return ws.Storer, nil
+	}
+	return store, nil
+}
+
+// StoreEncryptCmd represents the command to encrypt the environments in the store
+type StoreEncryptCmd struct {
+	Passphrase bool `help:"Derive the key from a passphrase, read from SCRATCH_PASSPHRASE or asked for, instead of saving a random key in the OS keyring"`
+}
+
+// Run sets up the key and encrypts every environment with it
+func (s StoreEncryptCmd) Run(ctx *CLIContext) error {
+	store, err := rootStore(ctx)
+	if err != nil {
+		return err
+	}
+	if enc, err := LoadEncryption(store); err != nil {
+		return err
+	} else if enc != nil {
+		return fmt.Errorf("store is already encrypted with a %s: %w", enc.Source, ErrExists)
+	}
+
+	source, passphrase := KeyringSource, ""
+	if s.Passphrase {
+		source = PassphraseSource
+		if passphrase, err = readPassphrase("New passphrase"); err != nil {
+			return err
+		}
+		if os.Getenv(passphraseEnv) == "" {
+			again, err := askForSecret("Repeat passphrase")
+			if err != nil {
+				return err
+			}
+			if again != passphrase {
+				return errors.New("passphrases do not match")
+			}
+		}
+	}
+
+	enc, key, err := NewEncryption(source, passphrase)
+	if err != nil {
+		return err
+	}
+	count, err := EncryptStore(store, enc, key)
+	if err != nil {
+		return err
+	}
+	output.Success("Encrypted %d environments with a key from the %s", count, source)
+	return nil
+}
+
+// StoreDecryptCmd represents the command to remove the encryption of the store
+type StoreDecryptCmd struct{}
+
+// Run saves every environment unencrypted
+func (s StoreDecryptCmd) Run(ctx *CLIContext) error {
+	store, err := rootStore(ctx)
+	if err != nil {
+		return err
+	}
+	if specCipher == nil {
+		return fmt.Errorf("store is not encrypted")
+	}
+	count, err := DecryptStore(store)
+	if err != nil {
+		return err
+	}
+	output.Success("Decrypted %d environments", count)
+	return nil
+}
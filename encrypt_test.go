@@ -0,0 +1,94 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptStore(t *testing.T) {
+	// Later tests must not inherit the key
+	t.Cleanup(func() { main.UnlockStore(NewMemoryStore()) })
+
+	store := NewMemoryStore()
+	spec := main.NewSpec("secret-notes", main.PythonSpec, t.TempDir())
+	spec.Description = "api key in .env"
+	require.NoError(t, spec.Save(store))
+	work := main.NewWorkspaceStore(store, "work")
+	require.NoError(t, spec.Save(work))
+
+	enc, key, err := main.NewEncryption(main.PassphraseSource, "hunter2")
+	require.NoError(t, err)
+	count, err := main.EncryptStore(store, enc, key)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	for key, value := range store.Data {
+		if strings.Contains(key, "spec/") {
+			require.NotContains(t, string(value), "api key", key)
+		}
+	}
+
+	// Forget the key EncryptStore unlocked the store with
+	require.NoError(t, main.UnlockStore(NewMemoryStore()))
+	t.Setenv("SCRATCH_PASSPHRASE", "wrong")
+	require.ErrorIs(t, main.UnlockStore(store), main.ErrStore)
+	_, err = main.LoadSpecs(store)
+	require.Error(t, err)
+
+	t.Setenv("SCRATCH_PASSPHRASE", "hunter2")
+	require.NoError(t, main.UnlockStore(store))
+	for _, s := range []main.Storer{main.NewWorkspaceStore(store, main.DefaultWorkspace), work} {
+		specs, err := main.LoadSpecs(s)
+		require.NoError(t, err)
+		require.Equal(t, []main.Spec{spec}, specs)
+	}
+
+	count, err = main.DecryptStore(store)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Contains(t, string(store.Data[spec.Key()]), "api key")
+	enc2, err := main.LoadEncryption(store)
+	require.NoError(t, err)
+	require.Nil(t, enc2)
+}
+
+func TestEncryptStore_Marker(t *testing.T) {
+	t.Cleanup(func() { main.UnlockStore(NewMemoryStore()) })
+
+	store := NewMemoryStore()
+	spec := main.NewSpec("secret-notes", main.NotesSpec, t.TempDir())
+	spec.Description = "api key in .env"
+	spec.Meta = map[string]string{"ticket": "SEC-1"}
+	spec.Env = []string{"API_KEY"}
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+	require.NoError(t, spec.Save(store))
+	require.NoError(t, main.WriteMarker(spec))
+
+	marker := func() string {
+		data, err := os.ReadFile(filepath.Join(spec.Path, ".scratch.json"))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	enc, key, err := main.NewEncryption(main.PassphraseSource, "hunter2")
+	require.NoError(t, err)
+	_, err = main.EncryptStore(store, enc, key)
+	require.NoError(t, err)
+	for _, secret := range []string{"api key", "SEC-1", "API_KEY"} {
+		require.NotContains(t, marker(), secret)
+	}
+	_, err = main.WriteReadme(spec)
+	require.NoError(t, err)
+	readme, err := os.ReadFile(filepath.Join(spec.Path, "README.md"))
+	require.NoError(t, err)
+	require.NotContains(t, string(readme), "api key")
+
+	_, err = main.DecryptStore(store)
+	require.NoError(t, err)
+	require.Contains(t, marker(), "api key")
+}
@@ -1,51 +1,291 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // SpecType describes supported environment types
 type SpecType string
 
 var (
-	PythonSpec SpecType = "python"
+	PythonSpec       SpecType = "python"
+	DenoSpec         SpecType = "deno"
+	BunSpec          SpecType = "bun"
+	LatexSpec        SpecType = "latex"
+	RSpec            SpecType = "r"
+	JuliaSpec        SpecType = "julia"
+	JavaSpec         SpecType = Java
+	KotlinSpec       SpecType = Kotlin
+	TerraformSpec    SpecType = "terraform"
+	ShellSpec        SpecType = "sh"
+	NotesSpec        SpecType = "notes"
+	WebSpec          SpecType = "web"
+	MLSpec           SpecType = "ml"
+	DataSpec         SpecType = "data"
+	TemplateSpec     SpecType = "template"
+	CookiecutterSpec SpecType = Cookiecutter
+	CopierSpec       SpecType = Copier
 )
 
+// SpecTypes lists every supported environment type
+var SpecTypes = []SpecType{PythonSpec, DenoSpec, BunSpec, LatexSpec, RSpec, JuliaSpec, JavaSpec, KotlinSpec, TerraformSpec, ShellSpec, NotesSpec, WebSpec, DataSpec, MLSpec, TemplateSpec, CookiecutterSpec, CopierSpec}
+
 func SpecID(t SpecType, name string) string {
-	return fmt.Sprintf("%s:%s", t, name)
+	return fmt.Sprintf("%s:%s", t, idEscaper.Replace(name))
+}
+
+// idEscaper escapes the separator in names so IDs split unambiguously
+var (
+	idEscaper   = strings.NewReplacer("%", "%25", ":", "%3A")
+	idUnescaper = strings.NewReplacer("%25", "%", "%3A", ":")
+)
+
+// ParseSpecID splits an ID into the type and name of the environment
+func ParseSpecID(id string) (SpecType, string, error) {
+	t, name, ok := strings.Cut(id, ":")
+	if !ok || t == "" || name == "" {
+		return "", "", fmt.Errorf("invalid environment ID %q, expected type:name", id)
+	}
+	return SpecType(t), idUnescaper.Replace(name), nil
 }
 
 // Spec defines the environment
 type Spec struct {
+	// UID is the ULID identifying the environment in the store
+	UID  string `json:",omitempty"`
 	Name string
 	Type SpecType
 	Path string
+	Tags []string          `json:",omitempty"`
+	Meta map[string]string `json:",omitempty"`
+	// Root is the configured root directory chosen for the environment
+	Root string `json:",omitempty"`
+	// Archive is where the environment was moved to when archived
+	Archive string `json:",omitempty"`
+	// Template is the source the environment was created from with --from
+	Template    TemplateSource `json:",omitempty"`
+	Description string         `json:",omitempty"`
+	// Usage caches the last measured disk usage
+	Usage *DiskUsage `json:",omitempty"`
+	// Created is when the environment was created, zero if it was created before it was recorded
+	Created time.Time `json:",omitzero"`
+	// LastUsed is when the environment was last opened
+	LastUsed time.Time `json:",omitzero"`
+	// Opens counts how often the environment was opened, or entered with run and shell
+	Opens int `json:",omitempty"`
+	// Expires is when the environment should be pruned, zero if it never expires
+	Expires time.Time `json:",omitzero"`
+	// Env lists the variables written to the .env file of the environment, without their values
+	Env []string `json:",omitempty"`
+	// Deleted is when the environment was moved to the trash
+	Deleted time.Time `json:",omitzero"`
+	// Trash is where the directory was moved to when deleted
+	Trash string `json:",omitempty"`
+	// Locked protects the environment from delete and prune
+	Locked bool `json:",omitempty"`
+	// Hidden leaves the environment out of list unless --all is passed
+	Hidden bool `json:",omitempty"`
+	// Parent is the project the environment was created for, like an experiment on a repository
+	Parent string `json:",omitempty"`
+	// InPlace marks environments provisioned in a directory that already existed,
+	// like a subfolder of a repository, which is never moved or removed
+	InPlace bool `json:",omitempty"`
+	// Framework is the framework web environments were created with, like react
+	Framework string `json:",omitempty"`
+	// Deprovisioned is when delete --files-only removed the directory, zero while it exists
+	Deprovisioned time.Time `json:",omitzero"`
 }
 
 // NewSpec creates a new Spec
 func NewSpec(name string, t SpecType, wd string) Spec {
-	return Spec{name, t, filepath.Join(wd, name)}
+	return Spec{UID: NewULID(time.Now()), Name: name, Type: t, Path: filepath.Join(wd, name)}
 }
 
-// LoadSpec loads spec for environment
+// LoadSpec loads spec for environment, migrating it from older schema versions
 func LoadSpec(data []byte) (Spec, error) {
-	var s Spec
-	if err := json.Unmarshal(data, &s); err != nil {
-		return Spec{}, fmt.Errorf("unmarshal spec: %w", err)
-	}
-
-	return s, nil
+	s, _, err := decodeSpec(data)
+	return s, err
 }
 
 // String returns a string represntation of Spec
 func (s Spec) String() string {
-	return fmt.Sprintf("%s (%s) - %s", s.Name, s.Type, s.Path)
+	str := fmt.Sprintf("%s (%s) - %s", s.Name, s.Type, s.Path)
+	if len(s.Tags) > 0 {
+		str += fmt.Sprintf(" [%s]", strings.Join(s.Tags, ", "))
+	}
+	if s.IsArchived() {
+		str += " (archived)"
+	}
+	if s.IsExpired(time.Now()) {
+		str += " (expired)"
+	}
+	if s.Description != "" {
+		str += fmt.Sprintf(" %q", s.Description)
+	}
+	return str
+}
+
+// Details returns a multi-line description of every set field
+func (s Spec) Details() string {
+	var b strings.Builder
+	field := func(name string, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-12s %s\n", name+":", value)
+		}
+	}
+	field("ID", s.ID())
+	field("UID", s.UID)
+	field("Name", s.Name)
+	field("Type", string(s.Type))
+	field("Path", s.Path)
+	field("Description", s.Description)
+	field("Tags", strings.Join(s.Tags, ", "))
+	field("Env", strings.Join(s.Env, ", "))
+	for _, key := range slices.Sorted(maps.Keys(s.Meta)) {
+		field("Meta", fmt.Sprintf("%s=%s", key, s.Meta[key]))
+	}
+	field("Root", s.Root)
+	field("Archive", s.Archive)
+	field("Template", string(s.Template))
+	field("Framework", s.Framework)
+	if !s.Created.IsZero() {
+		field("Created", s.Created.Local().Format(time.DateTime))
+	}
+	if !s.LastUsed.IsZero() {
+		field("Last used", fmt.Sprintf("%s, opened %d times", s.LastUsed.Local().Format(time.DateTime), s.Opens))
+	}
+	if !s.Expires.IsZero() {
+		field("Expires", s.Expires.Local().Format(time.DateTime))
+	}
+	if s.IsDeleted() {
+		field("Deleted", s.Deleted.Local().Format(time.DateTime))
+	}
+	field("Trash", s.Trash)
+	if s.Locked {
+		field("Locked", "yes, delete and prune skip it")
+	}
+	if s.Hidden {
+		field("Hidden", "yes, list shows it with --all")
+	}
+	field("For", s.Parent)
+	if s.InPlace {
+		field("In place", "yes, delete keeps the directory")
+	}
+	if s.IsDeprovisioned() {
+		field("Files", fmt.Sprintf("removed %s, recreate them with scratch reprovision", s.Deprovisioned.Local().Format(time.DateTime)))
+	}
+	return b.String()
+}
+
+// MarkUsed returns the spec recording it was used at now
+func (s Spec) MarkUsed(now time.Time) Spec {
+	s.LastUsed = now
+	s.Opens++
+	return s
+}
+
+// IsExpired checks if the environment is past its TTL at now
+func (s Spec) IsExpired(now time.Time) bool {
+	return !s.Expires.IsZero() && now.After(s.Expires)
+}
+
+// IsArchived checks if the environment was moved to the archive
+func (s Spec) IsArchived() bool {
+	return s.Archive != ""
+}
+
+// IsDeprovisioned checks if the directory of the environment was removed while its record was kept
+func (s Spec) IsDeprovisioned() bool {
+	return !s.Deprovisioned.IsZero()
+}
+
+// HasTag checks if the spec is tagged with tag
+func (s Spec) HasTag(tag string) bool {
+	return slices.Contains(s.Tags, tag)
+}
+
+// SpecEdit describes changes to the tags and metadata of a spec
+type SpecEdit struct {
+	AddTags    []string
+	RemoveTags []string
+	SetMeta    map[string]string
+	UnsetMeta  []string
+}
+
+// Apply returns a copy of the spec with the edit applied and whether anything changed
+func (e SpecEdit) Apply(s Spec) (Spec, bool) {
+	changed := false
+
+	tags := slices.Clone(s.Tags)
+	for _, tag := range e.AddTags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+			changed = true
+		}
+	}
+	for _, tag := range e.RemoveTags {
+		if i := slices.Index(tags, tag); i >= 0 {
+			tags = slices.Delete(tags, i, i+1)
+			changed = true
+		}
+	}
+	slices.Sort(tags)
+	if len(tags) == 0 {
+		tags = nil
+	}
+
+	meta := maps.Clone(s.Meta)
+	for key, value := range e.SetMeta {
+		if current, ok := meta[key]; !ok || current != value {
+			if meta == nil {
+				meta = map[string]string{}
+			}
+			meta[key] = value
+			changed = true
+		}
+	}
+	for _, key := range e.UnsetMeta {
+		if _, ok := meta[key]; ok {
+			delete(meta, key)
+			changed = true
+		}
+	}
+	if len(meta) == 0 {
+		meta = nil
+	}
+
+	s.Tags = tags
+	s.Meta = meta
+	return s, changed
+}
+
+// String returns a summary of the edit
+func (e SpecEdit) String() string {
+	changes := []string{}
+	for _, tag := range e.AddTags {
+		changes = append(changes, "+tag "+tag)
+	}
+	for _, tag := range e.RemoveTags {
+		changes = append(changes, "-tag "+tag)
+	}
+	for _, key := range slices.Sorted(maps.Keys(e.SetMeta)) {
+		changes = append(changes, fmt.Sprintf("+meta %s=%s", key, e.SetMeta[key]))
+	}
+	for _, key := range e.UnsetMeta {
+		changes = append(changes, "-meta "+key)
+	}
+	return strings.Join(changes, ", ")
 }
 
 // ID returns a unique identifier for the spec
@@ -53,63 +293,358 @@ func (s Spec) ID() string {
 	return SpecID(s.Type, s.Name)
 }
 
-// Exists checks if the environment created by the spec exists
+// Contains checks if path is the environment directory or inside it
+func (s Spec) Contains(path string) bool {
+	return pathWithin(s.Path, path)
+}
+
+// pathWithin checks if path is dir or inside it
+func pathWithin(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// FindSpecByPath finds the innermost environment containing path
+func FindSpecByPath(lister Lister, path string) (Spec, bool, error) {
+	var found Spec
+	var ok bool
+	err := ForEachSpec(lister, func(spec Spec) error {
+		if spec.Contains(path) && len(spec.Path) > len(found.Path) {
+			found = spec
+			ok = true
+		}
+		return nil
+	})
+	if err != nil {
+		return Spec{}, false, err
+	}
+	return found, ok, nil
+}
+
+// LookupSpec fetches and loads the spec stored at key
+func LookupSpec(store Reader, key string) (Spec, error) {
+	data, err := store.Get(key)
+	if err != nil {
+		return Spec{}, fmt.Errorf("get environment %q: %w", key, err)
+	}
+	return LoadSpec(data)
+}
+
+// LoadSpecs loads every spec in the store
+func LoadSpecs(lister Lister) ([]Spec, error) {
+	specs := []Spec{}
+	err := ForEachSpec(lister, func(spec Spec) error {
+		specs = append(specs, spec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// Exists checks if the environment created by the spec exists. A symlink exists even when
+// its target is gone, so deleting the environment removes it.
 func (s Spec) Exists() bool {
-	_, err := os.Stat(s.Path)
+	_, err := os.Lstat(longPath(s.Path))
 	return err == nil
 }
 
-// Save saves the spec to storage
+// Save saves the spec and its name index entry to storage, with its paths cleaned
 func (s Spec) Save(storer Writer) error {
-	data, err := json.MarshalIndent(&s, "", " ")
+	s.Path = CleanPath(s.Path)
+	s.Parent = CleanPath(s.Parent)
+	data, err := marshalSpec(s)
 	if err != nil {
 		return fmt.Errorf("marshal spec to json: %w", err)
 	}
-	return storer.Put(s.ID(), data)
+	if err := storer.Put(s.Key(), data); err != nil {
+		return err
+	}
+	if s.UID != "" {
+		return storer.Put(s.nameKey(), marshalIndexValue(s.UID))
+	}
+	return nil
 }
 
 // Scaffolder applies the spec and builds out the environment
 type Scaffolder struct {
-	spec Spec
+	spec        Spec
+	config      Config
+	provisioner Provisioner
+	template    string
+	data        TemplateData
+}
+
+// invalidNameChars cannot be used in directory names on every platform or in IDs
+const invalidNameChars = `/\:<>"|?*`
+
+// ValidateName checks the name can be used as a directory name and in IDs
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	if name == "." || name == ".." {
+		return fmt.Errorf("name %q is reserved", name)
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("name %q must not contain path separators", name)
+	}
+	if i := strings.IndexFunc(name, invalidNameRune); i >= 0 {
+		r, _ := utf8.DecodeRuneInString(name[i:])
+		return fmt.Errorf("name %q must not contain %q", name, r)
+	}
+	if strings.TrimSpace(name) != name {
+		return fmt.Errorf("name %q must not start or end with spaces", name)
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("name %q must not start with -", name)
+	}
+	return nil
+}
+
+// invalidNameRune checks if r cannot be used in names
+func invalidNameRune(r rune) bool {
+	return unicode.IsControl(r) || strings.ContainsRune(invalidNameChars, r)
+}
+
+// Slugify replaces runs of characters not allowed in names with - so the result passes
+// ValidateName, or returns an empty string if nothing usable is left
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		if invalidNameRune(r) || unicode.IsSpace(r) {
+			dash = true
+			continue
+		}
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+		b.WriteRune(r)
+	}
+	return strings.TrimLeft(b.String(), "-.")
+}
+
+// PreflightError aggregates every failed pre-flight check
+type PreflightError struct {
+	Errs []error
+}
+
+func (e PreflightError) Error() string {
+	var b strings.Builder
+	b.WriteString("pre-flight checks failed:")
+	for _, err := range e.Errs {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
 }
 
-// Build creates the environment based on the spec
-func (s Scaffolder) Build() error {
+func (e PreflightError) Unwrap() []error {
+	return e.Errs
+}
+
+// Preflight runs every check required before the environment is built
+// and returns all failures rather than stopping at the first
+func (s Scaffolder) Preflight(store ReadLister) []error {
+	defer timePhase("preflight checks")()
+	errs := []error{}
+
+	if err := ValidateName(s.spec.Name); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := checkDuplicate(store, s.spec); err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.spec.InPlace {
+		if err := checkNested(store, s.spec.Path); err != nil {
+			errs = append(errs, err)
+		}
+	} else if _, err := os.Stat(s.spec.Path); err == nil {
+		errs = append(errs, fmt.Errorf("directory %s %w", s.spec.Path, ErrExists))
+	}
+
+	p, err := s.Provisioner(s.spec.Type)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("unknown environment type: %w", err))
+	} else if err := checkReady(p); err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.template != "" {
+		if info, err := os.Stat(s.template); err != nil || !info.IsDir() {
+			errs = append(errs, fmt.Errorf("template directory %s not found", s.template))
+		} else if err := CheckTemplate(s.template, s.data); err != nil {
+			errs = append(errs, fmt.Errorf("invalid template: %w", err))
+		}
+	}
+
+	return errs
+}
+
+// checkNested checks dir is neither inside a tracked environment nor contains one,
+// so environments provisioned in place never overlap
+func checkNested(store Lister, dir string) error {
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		if spec.Contains(dir) || pathWithin(dir, spec.Path) {
+			return fmt.Errorf("directory %s overlaps %s at %s: %w", dir, spec.ID(), spec.Path, ErrExists)
+		}
+	}
+	return nil
+}
+
+// NewScaffolder creates a Scaffolder for spec
+func NewScaffolder(spec Spec) Scaffolder {
+	return Scaffolder{spec: spec}
+}
+
+// WithConfig returns a Scaffolder that provisions types customized by config
+func (s Scaffolder) WithConfig(config Config) Scaffolder {
+	s.config = config
+	return s
+}
+
+// WithProvisioner returns a Scaffolder that provisions the environment with p
+func (s Scaffolder) WithProvisioner(p Provisioner) Scaffolder {
+	s.provisioner = p
+	return s
+}
+
+// WithTemplate returns a Scaffolder that renders the template directory into the environment
+// after it is provisioned
+func (s Scaffolder) WithTemplate(dir string, data TemplateData) Scaffolder {
+	s.template = dir
+	s.data = data
+	return s
+}
+
+// Build creates the environment based on the spec, returning the log of how it was provisioned
+func (s Scaffolder) Build() (ProvisionLog, error) {
+	log := ProvisionLog{}
+	stop := RecordCommands(&log)
+	defer stop()
+
 	p, err := s.Provisioner(s.spec.Type)
 	if err != nil {
-		return fmt.Errorf("unknown environment type: %w", err)
+		return log, fmt.Errorf("unknown environment type: %w", err)
 	}
 
 	slog.Debug("Checking if provisioner is ready")
-	if err := p.Ready(); err != nil {
-		return fmt.Errorf("provisioner not ready: %w", err)
+	done := timePhase("check readiness")
+	err = p.Ready()
+	done()
+	if err != nil {
+		return log, fmt.Errorf("provisioner not ready: %w", err)
 	}
 
 	slog.Debug("Checking if output directory already exists")
-	if _, err := os.Stat(s.spec.Path); err == nil {
-		return fmt.Errorf("environment already exists at location")
+	if _, err := os.Stat(s.spec.Path); err == nil && !s.spec.InPlace {
+		return log, fmt.Errorf("environment already exists at location")
 	}
 
 	slog.Debug("Ensuring all folders in output path are created")
 	if err := os.MkdirAll(s.spec.Path, 0755); err != nil {
-		return fmt.Errorf("ensure output directory: %w", err)
+		return log, fmt.Errorf("ensure output directory: %w", err)
 	}
 
 	slog.Debug("Provisioning environment")
-	if err := p.Provision(s.spec.Path); err != nil {
-		return err
+	log.Tools = ToolVersions(p)
+	done = timePhase("provision " + string(s.spec.Type))
+	err = p.Provision(s.spec.Path)
+	done()
+	if err != nil {
+		return log, err
 	}
 
-	return nil
+	if s.template != "" {
+		slog.Debug("Rendering template", slog.String("template", s.template))
+		done := timePhase("render template")
+		err := RenderTemplate(s.template, s.spec.Path, s.data)
+		done()
+		if err != nil {
+			return log, fmt.Errorf("render template: %w", err)
+		}
+	}
+
+	return log, nil
+}
+
+// Manifest hashes the files scaffolded into the environment, except the ones the
+// provisioner recreates like dependencies
+func (s Scaffolder) Manifest() (Manifest, error) {
+	defer timePhase("hash files")()
+	p, err := s.Provisioner(s.spec.Type)
+	if err != nil {
+		return nil, err
+	}
+	return HashFiles(s.spec.Path, ignoredFiles(p)...)
 }
 
 // Provisioner returns the Provisioner associated with the SpecType
 func (s Scaffolder) Provisioner(specType SpecType) (Provisioner, error) {
+	if s.provisioner != nil {
+		return s.provisioner, nil
+	}
+	return s.config.Provisioner(specType)
+}
+
+// Provisioner returns the Provisioner associated with the SpecType, customized by config
+func (c Config) Provisioner(specType SpecType) (Provisioner, error) {
+	if specType == DataSpec {
+		return NewDataEnvironment(c.Data), nil
+	}
+	return NewProvisioner(specType)
+}
+
+// NewProvisioner returns the Provisioner associated with the SpecType
+func NewProvisioner(specType SpecType) (Provisioner, error) {
 	switch specType {
 	case PythonSpec:
 		return PythonEnvironment{}, nil
+	case DenoSpec:
+		return DenoEnvironment{}, nil
+	case BunSpec:
+		return BunEnvironment{}, nil
+	case LatexSpec:
+		return LatexEnvironment{}, nil
+	case RSpec:
+		return REnvironment{}, nil
+	case JuliaSpec:
+		return JuliaEnvironment{}, nil
+	case JavaSpec, KotlinSpec:
+		return JVMEnvironment{Language: string(specType)}, nil
+	case TerraformSpec:
+		return TerraformEnvironment{}, nil
+	case ShellSpec:
+		return ShellEnvironment{}, nil
+	case NotesSpec:
+		return NotesEnvironment{}, nil
+	case WebSpec:
+		return WebEnvironment{}, nil
+	case MLSpec:
+		return NewMLEnvironment(""), nil
+	case DataSpec:
+		return NewDataEnvironment(DataConfig{}), nil
+	case TemplateSpec:
+		return TemplateEnvironment{}, nil
+	case CookiecutterSpec, CopierSpec:
+		return GeneratorEnvironment{Generator: string(specType)}, nil
 	default:
-		return nil, fmt.Errorf("unknown spec type %q", specType)
+		if plugin, ok := FindPlugin(specType); ok {
+			return plugin, nil
+		}
+		return nil, fmt.Errorf("%w %q", ErrUnknownType, specType)
 	}
 }
 
@@ -130,7 +665,17 @@ func RunCommand(wd string, name string, args ...string) error {
 	initCmd := exec.Command(name, args...)
 	initCmd.Dir = wd
 
-	if out, err := initCmd.CombinedOutput(); err != nil {
+	stop := timePhase(strings.Join(append([]string{name}, args...), " "))
+	start := time.Now()
+	out, err := initCmd.CombinedOutput()
+	stop()
+	record := CommandRecord{Time: start, Dir: wd, Command: append([]string{name}, args...), Output: string(out), Duration: time.Since(start)}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	recordCommand(record)
+
+	if err != nil {
 		return fmt.Errorf("%s: %w", strings.TrimSpace(string(out)), err)
 	}
 	return nil
@@ -142,6 +687,59 @@ type Provisioner interface {
 	Provision(dir string) error
 }
 
+// Checker is implemented by provisioners that can verify an environment is intact
+type Checker interface {
+	Check(dir string) error
+}
+
+// Rebuilder is implemented by provisioners that can restore an environment in place
+type Rebuilder interface {
+	Rebuild(dir string) error
+}
+
+// Reprovisioner is implemented by provisioners that can repair an existing environment by
+// re-running only the steps whose output is missing, leaving files that exist untouched
+type Reprovisioner interface {
+	Reprovision(dir string) error
+}
+
+// Upgrader is implemented by provisioners that can upgrade dependencies of an environment
+type Upgrader interface {
+	Upgrade(dir string) error
+}
+
+// Ignorer is implemented by provisioners with files that can be recreated and are
+// not copied when cloning an environment
+type Ignorer interface {
+	Ignore() []string
+}
+
+// FilesExist checks if all named files exist in dir
+func FilesExist(dir string, names ...string) error {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("missing %s", name)
+		}
+	}
+	return nil
+}
+
+// writeMissingFiles writes files, which map names to contents, to dir unless they already exist
+func writeMissingFiles(dir string, files map[string]string) error {
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // PythonEnvironment represents a Python environment to be created
 type PythonEnvironment struct{}
 
@@ -167,14 +765,74 @@ func (p PythonEnvironment) Provision(dir string) error {
 		return fmt.Errorf("uv venv: %w", err)
 	}
 
-	slog.Info("Created environment at " + dir)
+	slog.Debug("Provisioned python environment", slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes projects of uv and other Python tools
+func (p PythonEnvironment) Detect(dir string) float64 {
+	confidence := detectFiles(dir, map[string]float64{
+		"uv.lock":          StrongMatch,
+		"pyproject.toml":   StrongMatch,
+		"requirements.txt": LikelyMatch,
+		"setup.py":         LikelyMatch,
+	})
+	return max(confidence, detectGlob(dir, "*.py", WeakMatch))
+}
+
+// Describe summarizes python environments
+func (p PythonEnvironment) Describe() Description {
+	return Description{Summary: "A uv project with a virtual environment", Examples: []string{"scratch new demo"}}
+}
+
+// Tools returns uv
+func (p PythonEnvironment) Tools() []Tool {
+	return []Tool{uvTool}
+}
+
+// Check verifies the project file and virtual environment exist
+func (p PythonEnvironment) Check(dir string) error {
+	return FilesExist(dir, "pyproject.toml", ".venv")
+}
+
+// Ignore returns the virtual environment and tool caches
+func (p PythonEnvironment) Ignore() []string {
+	return []string{".venv", "__pycache__", ".pytest_cache", ".mypy_cache", ".ruff_cache"}
+}
+
+// Rebuild recreates the virtual environment and reinstalls dependencies
+func (p PythonEnvironment) Rebuild(dir string) error {
+	if err := RunCommand(dir, "uv", "sync"); err != nil {
+		return fmt.Errorf("uv sync: %w", err)
+	}
+	return nil
+}
+
+// Reprovision initializes the project if pyproject.toml is missing and recreates the
+// virtual environment with the locked dependencies if .venv is missing
+func (p PythonEnvironment) Reprovision(dir string) error {
+	if FilesExist(dir, "pyproject.toml") != nil {
+		slog.Debug("Initializing missing project", slog.String("dir", dir))
+		if err := RunCommand(dir, "uv", "init"); err != nil {
+			return fmt.Errorf("init uv: %w", err)
+		}
+	}
+	if FilesExist(dir, ".venv") != nil {
+		slog.Debug("Recreating missing virtual environment", slog.String("dir", dir))
+		if err := RunCommand(dir, "uv", "sync"); err != nil {
+			return fmt.Errorf("uv sync: %w", err)
+		}
+	}
 	return nil
 }
 
-// OpenFolder opens folder with specified program
-func OpenFolder(program string, dir string) error {
-	if err := RunCommand("", program, dir); err != nil {
-		return fmt.Errorf("open folder: %w", err)
+// Upgrade upgrades locked dependencies and syncs the virtual environment
+func (p PythonEnvironment) Upgrade(dir string) error {
+	if err := RunCommand(dir, "uv", "lock", "--upgrade"); err != nil {
+		return fmt.Errorf("uv lock: %w", err)
+	}
+	if err := RunCommand(dir, "uv", "sync"); err != nil {
+		return fmt.Errorf("uv sync: %w", err)
 	}
 	return nil
 }
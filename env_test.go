@@ -1,11 +1,16 @@
 package main_test
 
 import (
-	"errors"
+	"fmt"
+	"iter"
 	"log/slog"
+	"maps"
 	"os"
 	"path"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	main "github.com/chargeflux/scratch"
 	"github.com/stretchr/testify/assert"
@@ -22,7 +27,7 @@ func NewMemoryStore() MemoryStore {
 
 func (m MemoryStore) Get(key string) ([]byte, error) {
 	if v, ok := m.Data[key]; !ok {
-		return nil, errors.New("key does not exist")
+		return nil, fmt.Errorf("get key %q: %w", key, main.ErrNotFound)
 	} else {
 		return v, nil
 	}
@@ -40,17 +45,59 @@ func (m MemoryStore) Put(key string, data []byte) error {
 
 func (m MemoryStore) Delete(key string) error {
 	if _, ok := m.Data[key]; !ok {
-		return errors.New("key does not exist")
+		return fmt.Errorf("delete key %q: %w", key, main.ErrNotFound)
 	}
 	delete(m.Data, key)
 	return nil
 }
 
+func (m MemoryStore) List() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for _, key := range slices.Sorted(maps.Keys(m.Data)) {
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (m MemoryStore) ListFunc(handle func(key string, data []byte) error) error {
+	return m.ListPrefix("", handle)
+}
+
+func (m MemoryStore) ListPrefix(prefix string, handle func(key string, data []byte) error) error {
+	for _, key := range slices.Sorted(maps.Keys(m.Data)) {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := handle(key, m.Data[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m MemoryStore) Batch(apply func(w main.Writer) error) error {
+	staged := MemoryStore{maps.Clone(m.Data)}
+	if err := apply(staged); err != nil {
+		return err
+	}
+	clear(m.Data)
+	maps.Copy(m.Data, staged.Data)
+	return nil
+}
+
+func (m MemoryStore) Close() error {
+	return nil
+}
+
 func TestNewSpec(t *testing.T) {
 	tdir := t.TempDir()
 	name := "test"
 	got := main.NewSpec(name, main.PythonSpec, tdir)
+	assert.True(t, main.IsULID(got.UID))
 	expected := main.Spec{
+		UID:  got.UID,
 		Name: name,
 		Type: main.PythonSpec,
 		Path: path.Join(tdir, name),
@@ -69,7 +116,7 @@ func TestSpec_ID(t *testing.T) {
 	t.Run("colon", func(t *testing.T) {
 		spec := main.NewSpec(":test-bar", main.PythonSpec, tdir)
 
-		assert.Equal(t, "python::test-bar", spec.ID())
+		assert.Equal(t, "python:%3Atest-bar", spec.ID())
 	})
 
 	t.Run("space", func(t *testing.T) {
@@ -100,14 +147,177 @@ func TestSpec_SaveLoad(t *testing.T) {
 	err := spec.Save(mw)
 	require.NoError(t, err)
 
-	require.Contains(t, mw.Data, spec.ID())
+	require.Contains(t, mw.Data, spec.Key())
 
-	lspec, err := main.LoadSpec(mw.Data[spec.ID()])
+	lspec, err := main.LoadSpec(mw.Data[spec.Key()])
 
 	require.NoError(t, err)
 	require.Equal(t, spec, lspec)
 }
 
+func TestSpec_Contains(t *testing.T) {
+	tdir := t.TempDir()
+	spec := main.NewSpec("test", main.PythonSpec, tdir)
+
+	assert.True(t, spec.Contains(spec.Path))
+	assert.True(t, spec.Contains(path.Join(spec.Path, "src", "pkg")))
+	assert.False(t, spec.Contains(tdir))
+	assert.False(t, spec.Contains(path.Join(tdir, "test-other")))
+}
+
+func TestFindSpecByPath(t *testing.T) {
+	tdir := t.TempDir()
+	mw := NewMemoryStore()
+	outer := main.NewSpec("outer", main.PythonSpec, tdir)
+	inner := main.NewSpec("inner", main.PythonSpec, outer.Path)
+	require.NoError(t, outer.Save(mw))
+	require.NoError(t, inner.Save(mw))
+
+	spec, ok, err := main.FindSpecByPath(mw, path.Join(inner.Path, "src"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, inner, spec)
+
+	spec, ok, err = main.FindSpecByPath(mw, outer.Path)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, outer, spec)
+
+	_, ok, err = main.FindSpecByPath(mw, tdir)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestSpec_Details(t *testing.T) {
+	spec := main.Spec{
+		Name:        "test",
+		Type:        main.PythonSpec,
+		Path:        "/tmp/test",
+		Description: "trying out polars",
+		Tags:        []string{"a", "b"},
+	}
+	details := spec.Details()
+	assert.Contains(t, details, "ID:          python:test\n")
+	assert.Contains(t, details, "Description: trying out polars\n")
+	assert.Contains(t, details, "Tags:        a, b\n")
+	assert.NotContains(t, details, "Archive:")
+}
+
+func TestSpecEdit_Apply(t *testing.T) {
+	spec := main.Spec{Name: "test", Type: main.PythonSpec, Tags: []string{"b"}}
+
+	t.Run("changes", func(t *testing.T) {
+		edit := main.SpecEdit{
+			AddTags:    []string{"c", "a"},
+			RemoveTags: []string{"b"},
+			SetMeta:    map[string]string{"owner": "me"},
+		}
+		got, changed := edit.Apply(spec)
+		require.True(t, changed)
+		assert.Equal(t, []string{"a", "c"}, got.Tags)
+		assert.Equal(t, map[string]string{"owner": "me"}, got.Meta)
+		assert.Equal(t, []string{"b"}, spec.Tags)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		edit := main.SpecEdit{
+			AddTags:   []string{"b"},
+			UnsetMeta: []string{"owner"},
+		}
+		got, changed := edit.Apply(spec)
+		require.False(t, changed)
+		assert.Equal(t, spec, got)
+	})
+
+	t.Run("remove all", func(t *testing.T) {
+		withMeta := spec
+		withMeta.Meta = map[string]string{"owner": "me"}
+		edit := main.SpecEdit{
+			RemoveTags: []string{"b"},
+			UnsetMeta:  []string{"owner"},
+		}
+		got, changed := edit.Apply(withMeta)
+		require.True(t, changed)
+		assert.Nil(t, got.Tags)
+		assert.Nil(t, got.Meta)
+	})
+}
+
+func TestValidateName(t *testing.T) {
+	require.NoError(t, main.ValidateName("test bar"))
+	require.Error(t, main.ValidateName(""))
+	require.Error(t, main.ValidateName(".."))
+	require.Error(t, main.ValidateName("foo/bar"))
+	require.Error(t, main.ValidateName("foo:bar"))
+	require.Error(t, main.ValidateName(" foo"))
+	require.Error(t, main.ValidateName("-foo"))
+	require.Error(t, main.ValidateName("foo\tbar"))
+}
+
+func TestSlugify(t *testing.T) {
+	for name, slug := range map[string]string{
+		"test bar":     "test-bar",
+		"a:b/c":        "a-b-c",
+		"  ../x  y?":   "x-y",
+		"ünïcode ok":   "ünïcode-ok",
+		"valid-name.1": "valid-name.1",
+		"::":           "",
+	} {
+		require.Equal(t, slug, main.Slugify(name), name)
+		if slug != "" {
+			require.NoError(t, main.ValidateName(slug))
+		}
+	}
+}
+
+func TestSpecID(t *testing.T) {
+	require.Equal(t, "python:test", main.SpecID(main.PythonSpec, "test"))
+
+	for _, name := range []string{"test", "a:b", "100%", "%3A"} {
+		id := main.SpecID(main.PythonSpec, name)
+		specType, parsed, err := main.ParseSpecID(id)
+		require.NoError(t, err)
+		require.Equal(t, main.PythonSpec, specType)
+		require.Equal(t, name, parsed)
+	}
+	require.NotEqual(t, main.SpecID(main.PythonSpec, "a:b"), main.SpecID("python:a", "b"))
+
+	_, _, err := main.ParseSpecID("python")
+	require.Error(t, err)
+}
+
+func TestScaffolder_Preflight(t *testing.T) {
+	tdir := t.TempDir()
+	mw := NewMemoryStore()
+	spec := main.Spec{Name: "test", Type: "unknown", Path: tdir}
+	require.NoError(t, spec.Save(mw))
+
+	errs := main.NewScaffolder(spec).Preflight(mw)
+	require.Len(t, errs, 3)
+
+	err := main.PreflightError{errs}
+	assert.Contains(t, err.Error(), `environment "unknown:test" already exists at`)
+	assert.Contains(t, err.Error(), "unknown environment type")
+}
+
+func TestScaffolder_PreflightInPlace(t *testing.T) {
+	tdir := t.TempDir()
+	mw := NewMemoryStore()
+	tracked := main.NewSpec("tracked", main.PythonSpec, tdir)
+	require.NoError(t, tracked.Save(mw))
+
+	spec := main.NewSpec("repo", main.PythonSpec, "")
+	spec.Path, spec.InPlace = tdir, true
+	err := main.PreflightError{main.NewScaffolder(spec).Preflight(mw)}
+	assert.Contains(t, err.Error(), "overlaps python:tracked")
+
+	// The directory may exist when the environment is provisioned in place
+	spec.Path = t.TempDir()
+	for _, err := range main.NewScaffolder(spec).Preflight(mw) {
+		assert.NotErrorIs(t, err, main.ErrExists)
+	}
+}
+
 func TestCommandsExist(t *testing.T) {
 	require.NoError(t, main.CommandsExist("go"))
 
@@ -119,6 +329,24 @@ func TestRunCommand(t *testing.T) {
 	require.Error(t, main.RunCommand("", "foo"))
 }
 
+func TestOpenCommand(t *testing.T) {
+	dir := t.TempDir()
+	cmd := main.OpenCommand(main.DefaultOpener, dir)
+	require.NotContains(t, cmd.Args, main.DefaultOpener)
+
+	cmd = main.OpenCommand("code", dir)
+	require.Equal(t, []string{"code", dir}, cmd.Args)
+}
+
+func TestNewProvisioner(t *testing.T) {
+	for _, specType := range main.SpecTypes {
+		p, err := main.NewProvisioner(specType)
+		require.NoError(t, err, specType)
+		_, ok := p.(main.ToolUser)
+		require.True(t, ok, specType)
+	}
+}
+
 func TestPythonEnvironment_Ready(t *testing.T) {
 	require.NoError(t, main.PythonEnvironment{}.Ready())
 }
@@ -138,3 +366,11 @@ func TestPythonEnvironment_Provision(t *testing.T) {
 	require.Contains(t, items, ".venv")
 	require.Contains(t, items, "pyproject.toml")
 }
+
+func TestSpec_IsExpired(t *testing.T) {
+	now := time.Now()
+	require.False(t, main.Spec{}.IsExpired(now))
+	require.False(t, main.Spec{Expires: now.Add(time.Hour)}.IsExpired(now))
+	require.True(t, main.Spec{Expires: now.Add(-time.Hour)}.IsExpired(now))
+	require.Contains(t, main.Spec{Name: "a", Expires: now.Add(-time.Hour)}.String(), "(expired)")
+}
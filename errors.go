@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Exit codes of failures that scripts wrapping scratch can branch on. Other
+// failures exit with 1 and invalid flags with 80.
+const (
+	ExitNotFound    = 2
+	ExitExists      = 3
+	ExitProvisioner = 4
+	ExitStore       = 5
+	ExitLocked      = 6
+	ExitPolicy      = 7
+	ExitAmbiguous   = 8
+	ExitInteractive = 9
+)
+
+// CodedError is an error value with the exit code of its category. kong exits
+// with the code of the first CodedError wrapped by a failed command.
+type CodedError struct {
+	msg  string
+	code int
+}
+
+func (e *CodedError) Error() string {
+	return e.msg
+}
+
+// ExitCode is the status scratch exits with when failing with the error
+func (e *CodedError) ExitCode() int {
+	return e.code
+}
+
+var (
+	// ErrNotFound is returned when a key or environment does not exist in the store
+	ErrNotFound = &CodedError{"key not found", ExitNotFound}
+	// ErrExists is returned when an environment or its directory already exists
+	ErrExists = &CodedError{"already exists", ExitExists}
+	// ErrUnknownType is returned when no provisioner handles the type of environment
+	ErrUnknownType = &CodedError{"unknown spec type", ExitProvisioner}
+	// ErrProvisioner is returned when the programs a provisioner needs are missing
+	ErrProvisioner = &CodedError{"provisioner not ready", ExitProvisioner}
+	// ErrStore is returned when the store cannot be opened or read
+	ErrStore = &CodedError{"store unavailable", ExitStore}
+	// ErrStoreLocked is returned when another process holds the store
+	ErrStoreLocked = &CodedError{"another scratch instance is running", ExitLocked}
+	// ErrPolicy is returned when the policy forbids an operation
+	ErrPolicy = &CodedError{"forbidden by policy", ExitPolicy}
+	// ErrProtected is returned when deleting a locked environment
+	ErrProtected = &CodedError{"environment is locked", ExitPolicy}
+	// ErrUnsafePath is returned when deleting a directory outside the data directory and roots
+	ErrUnsafePath = &CodedError{"not inside the data directory or a root", ExitPolicy}
+	// ErrUnexpectedContent is returned when deleting a directory that does not look like an environment
+	ErrUnexpectedContent = &CodedError{"does not look like an environment scratch created", ExitPolicy}
+	// ErrQuota is returned when environments would use more disk space than the quota
+	ErrQuota = &CodedError{"over disk quota", ExitPolicy}
+	// ErrAmbiguous is returned when several environments match and none can be chosen interactively
+	ErrAmbiguous = &CodedError{"ambiguous environment", ExitAmbiguous}
+	// ErrNotInteractive is returned when confirmation is needed but stdin is not a terminal
+	ErrNotInteractive = &CodedError{"confirmation needs a terminal, pass --force", ExitInteractive}
+	// ErrNoPassphrase is returned when the store is encrypted with a passphrase that cannot be asked for
+	ErrNoPassphrase = &CodedError{"passphrase needs a terminal, set SCRATCH_PASSPHRASE", ExitInteractive}
+)
+
+// HintError is an error with a hint at what the user can do about it, like the
+// command that installs a missing tool. The hint is written on its own line when
+// scratch fails with the error.
+type HintError struct {
+	// Msg describes what failed, and may be empty when Err says it already
+	Msg  string
+	Err  error
+	Hint string
+}
+
+func (e *HintError) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Msg
+	case e.Msg == "":
+		return e.Err.Error()
+	}
+	return e.Msg + ": " + e.Err.Error()
+}
+
+func (e *HintError) Unwrap() error {
+	return e.Err
+}
+
+// WithHint adds hint to err, returning err as is when it is nil or hint is empty
+func WithHint(err error, hint string) error {
+	if err == nil || hint == "" {
+		return err
+	}
+	return &HintError{Err: err, Hint: hint}
+}
+
+// ErrorHints returns the hints of err and the errors it wraps, outermost first
+func ErrorHints(err error) []string {
+	var hints []string
+	for err != nil {
+		if h, ok := err.(*HintError); ok && h.Hint != "" {
+			hints = append(hints, h.Hint)
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				hints = append(hints, ErrorHints(e)...)
+			}
+			return hints
+		default:
+			return hints
+		}
+	}
+	return hints
+}
+
+// WriteHints writes each hint of err on its own indented line, yellow when color is set
+func WriteHints(w io.Writer, err error, color bool) {
+	prefix := "hint: "
+	if color {
+		prefix = colorYellow + prefix + colorReset
+	}
+	for _, hint := range ErrorHints(err) {
+		fmt.Fprintf(w, "  %s%s\n", prefix, hint)
+	}
+}
+
+// ExitCode is the status scratch exits with when failing with err: the code of the
+// first CodedError it wraps, or 1
+func ExitCode(err error) int {
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	return 1
+}
@@ -0,0 +1,65 @@
+package main_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func exitCode(t *testing.T, err error) int {
+	t.Helper()
+	var coder kong.ExitCoder
+	require.True(t, errors.As(err, &coder), err)
+	return coder.ExitCode()
+}
+
+func TestExitCodes(t *testing.T) {
+	tdir := t.TempDir()
+	mw := NewMemoryStore()
+	spec := main.NewSpec("test", main.PythonSpec, tdir)
+	require.NoError(t, spec.Save(mw))
+
+	_, err := main.ResolveQuery(mw, "missing")
+	require.Equal(t, main.ExitNotFound, exitCode(t, err))
+
+	_, err = main.LookupID(mw, main.SpecID(main.PythonSpec, "missing"))
+	require.Equal(t, main.ExitNotFound, exitCode(t, err))
+
+	err = main.PreflightError{main.NewScaffolder(spec).Preflight(mw)}
+	require.Equal(t, main.ExitExists, exitCode(t, err))
+
+	_, err = main.NewProvisioner("unknown")
+	require.Equal(t, main.ExitProvisioner, exitCode(t, err))
+
+	err = main.Policy{AllowedTypes: []main.SpecType{"other"}}.CheckType(main.PythonSpec)
+	require.Equal(t, main.ExitPolicy, exitCode(t, err))
+
+	require.NoError(t, main.NewSpec("test", main.PythonSpec, t.TempDir()).Save(mw))
+	_, err = main.ResolveQuery(mw, "test")
+	require.Equal(t, main.ExitAmbiguous, exitCode(t, err))
+}
+
+func TestHintError(t *testing.T) {
+	require.NoError(t, main.WithHint(nil, "run scratch doctor"))
+	require.Equal(t, main.ErrStore, main.WithHint(main.ErrStore, ""))
+
+	inner := main.WithHint(fmt.Errorf("get db: %w", main.ErrStore), "run scratch doctor")
+	err := error(&main.HintError{Msg: "list", Err: inner, Hint: "check permissions"})
+	require.Equal(t, "list: get db: store unavailable", err.Error())
+	require.ErrorIs(t, err, main.ErrStore)
+	require.Equal(t, main.ExitStore, main.ExitCode(err))
+	require.Equal(t, []string{"check permissions", "run scratch doctor"}, main.ErrorHints(err))
+
+	joined := errors.Join(errors.New("plain"), main.WithHint(main.ErrNotFound, "run scratch list"))
+	require.Equal(t, []string{"run scratch list"}, main.ErrorHints(joined))
+	require.Equal(t, 1, main.ExitCode(errors.New("plain")))
+
+	var b bytes.Buffer
+	main.WriteHints(&b, err, false)
+	require.Equal(t, "  hint: check permissions\n  hint: run scratch doctor\n", b.String())
+}
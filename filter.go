@@ -0,0 +1,67 @@
+package main
+
+import (
+	"slices"
+	"time"
+)
+
+// Filter selects environments by type, tags and age
+type Filter struct {
+	// Type matches environments of one type, or any type when empty
+	Type SpecType
+	// Tags must all be on matching environments
+	Tags []string
+	// OlderThan matches environments created longer ago than this, 0 to disable
+	OlderThan time.Duration
+	// Parent matches environments created for the project at this path or under it
+	Parent string
+}
+
+// IsSet checks if the filter excludes anything
+func (f Filter) IsSet() bool {
+	return f.Type != "" || len(f.Tags) > 0 || f.OlderThan > 0 || f.Parent != ""
+}
+
+// Match checks if spec passes the filter at now. Environments created before
+// creation times were recorded are never older than anything.
+func (f Filter) Match(spec Spec, now time.Time) bool {
+	if f.Type != "" && spec.Type != f.Type {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !spec.HasTag(tag) {
+			return false
+		}
+	}
+	if f.OlderThan > 0 && (spec.Created.IsZero() || now.Sub(spec.Created) <= f.OlderThan) {
+		return false
+	}
+	if f.Parent != "" && (spec.Parent == "" || !pathWithin(f.Parent, spec.Parent)) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the specs passing the filter at now
+func (f Filter) Apply(specs []Spec, now time.Time) []Spec {
+	return slices.DeleteFunc(slices.Clone(specs), func(spec Spec) bool {
+		return !f.Match(spec, now)
+	})
+}
+
+// FilterFlags narrow down the environments a command lists or acts on
+type FilterFlags struct {
+	Tag       []string `help:"Only environments with all of these tags"`
+	OlderThan Duration `help:"Only environments created longer ago than this, like 30d"`
+	For       string   `help:"Only environments created for the project at this path or under it" type:"path"`
+}
+
+// Filter returns the filter of the flags for environments of specType, or of any type when empty
+func (f FilterFlags) Filter(specType SpecType) Filter {
+	return Filter{Type: specType, Tags: f.Tag, OlderThan: time.Duration(f.OlderThan), Parent: f.For}
+}
+
+// Narrows checks if any flag narrows down the environments, which makes commands act on every match
+func (f FilterFlags) Narrows() bool {
+	return len(f.Tag) > 0 || f.OlderThan > 0 || f.For != ""
+}
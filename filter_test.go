@@ -0,0 +1,40 @@
+package main_test
+
+import (
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	old := main.Spec{Name: "old", Type: main.DenoSpec, Tags: []string{"web", "demo"}, Created: now.Add(-60 * 24 * time.Hour)}
+	recent := main.Spec{Name: "recent", Type: main.DenoSpec, Tags: []string{"web"}, Created: now.Add(-time.Hour)}
+	python := main.Spec{Name: "python", Type: main.PythonSpec, Created: now.Add(-60 * 24 * time.Hour)}
+	unknown := main.Spec{Name: "unknown", Type: main.DenoSpec}
+	linked := main.Spec{Name: "linked", Type: main.DenoSpec, Parent: "/code/app/web"}
+	specs := []main.Spec{old, recent, python, unknown, linked}
+
+	cases := []struct {
+		name   string
+		filter main.Filter
+		want   []main.Spec
+	}{
+		{"none", main.Filter{}, specs},
+		{"type", main.Filter{Type: main.DenoSpec}, []main.Spec{old, recent, unknown, linked}},
+		{"tags", main.Filter{Tags: []string{"web", "demo"}}, []main.Spec{old}},
+		// Environments without a creation time are never old enough
+		{"older than", main.Filter{OlderThan: 30 * 24 * time.Hour}, []main.Spec{old, python}},
+		{"parent", main.Filter{Parent: "/code/app"}, []main.Spec{linked}},
+		{"other parent", main.Filter{Parent: "/code/application"}, []main.Spec{}},
+		{"combined", main.Filter{Type: main.DenoSpec, OlderThan: 30 * 24 * time.Hour}, []main.Spec{old}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.filter.IsSet(), c.name != "none")
+			require.Equal(t, c.want, c.filter.Apply(specs, now))
+		})
+	}
+}
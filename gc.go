@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// GCItem is something scratch manages that can be removed to reclaim space
+type GCItem struct {
+	Path   string
+	Size   ByteSize
+	Reason string
+}
+
+// PlanGC finds archives no environment refers to and archive objects no
+// archive links to
+func PlanGC(archiveDir string, specs []Spec) ([]GCItem, error) {
+	items := []GCItem{}
+
+	tracked := map[string]bool{}
+	for _, spec := range specs {
+		if spec.IsArchived() {
+			tracked[filepath.Clean(spec.Archive)] = true
+		}
+	}
+
+	typeDirs, err := os.ReadDir(archiveDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return items, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read archive dir: %w", err)
+	}
+	for _, typeDir := range typeDirs {
+		if !typeDir.IsDir() || typeDir.Name() == filepath.Base(objectsDir(archiveDir)) {
+			continue
+		}
+		archives, err := os.ReadDir(filepath.Join(archiveDir, typeDir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read archive dir: %w", err)
+		}
+		for _, archive := range archives {
+			path := filepath.Join(archiveDir, typeDir.Name(), archive.Name())
+			if tracked[path] {
+				continue
+			}
+			size, err := DirSize(path)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, GCItem{path, size, "untracked archive"})
+		}
+	}
+
+	err = filepath.WalkDir(objectsDir(archiveDir), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if links, ok := linkCount(info); ok && links <= 1 {
+			items = append(items, GCItem{path, ByteSize(info.Size()), "unused archive object"})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan archive objects: %w", err)
+	}
+
+	return items, nil
+}
+
+// GcCmd represents the command to reclaim space from data scratch no longer needs
+type GcCmd struct {
+	PlanFlags
+}
+
+// planGC loads the environments and plans what gc would remove
+func planGC(ctx *CLIContext) ([]GCItem, error) {
+	store, err := ctx.ReadOnlyStore()
+	if err != nil {
+		return nil, err
+	}
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return nil, err
+	}
+	archiveDir, err := DefaultArchiveDir()
+	if err != nil {
+		return nil, err
+	}
+	return PlanGC(archiveDir, specs)
+}
+
+// Run prints the reclaimable items and removes them with --apply
+func (g GcCmd) Run(ctx *CLIContext) error {
+	items, err := planGC(ctx)
+	if err != nil {
+		return err
+	}
+
+	actions := make([]PlannedAction, 0, len(items))
+	for _, item := range items {
+		actions = append(actions, PlannedAction{
+			Action: "remove",
+			Target: item.Path,
+			Path:   item.Path,
+			Size:   item.Size,
+			Reason: item.Reason,
+		})
+	}
+	if err := g.PrintPlan(actions); err != nil {
+		return err
+	}
+	if !g.Apply {
+		return nil
+	}
+
+	var total ByteSize
+	for _, item := range items {
+		if err := removeTree(item.Path); err != nil {
+			return fmt.Errorf("remove %s: %w", item.Path, err)
+		}
+		total += item.Size
+	}
+	output.Success("Reclaimed %s", total)
+	return nil
+}
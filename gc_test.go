@@ -0,0 +1,39 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanGC(t *testing.T) {
+	archiveDir := t.TempDir()
+	tracked := filepath.Join(archiveDir, "python", "tracked")
+	stray := filepath.Join(archiveDir, "python", "stray")
+	for _, dir := range []string{tracked, stray} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.py"), []byte(filepath.Base(dir)), 0644))
+	}
+
+	objects := filepath.Join(archiveDir, ".objects")
+	_, err := main.DedupTree(tracked, objects)
+	require.NoError(t, err)
+	unused := filepath.Join(objects, "ab", "abc-644")
+	require.NoError(t, os.MkdirAll(filepath.Dir(unused), 0755))
+	require.NoError(t, os.WriteFile(unused, []byte("unused"), 0644))
+
+	specs := []main.Spec{{Name: "tracked", Type: main.PythonSpec, Archive: tracked}}
+	items, err := main.PlanGC(archiveDir, specs)
+	require.NoError(t, err)
+	require.Equal(t, []main.GCItem{
+		{Path: stray, Size: 5, Reason: "untracked archive"},
+		{Path: unused, Size: 6, Reason: "unused archive object"},
+	}, items)
+
+	items, err = main.PlanGC(filepath.Join(archiveDir, "missing"), specs)
+	require.NoError(t, err)
+	require.Empty(t, items)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Project generators that environments can be created with
+const (
+	Cookiecutter = "cookiecutter"
+	Copier       = "copier"
+)
+
+var generatorTools = map[string]Tool{
+	Cookiecutter: {
+		Name: Cookiecutter,
+		Installers: map[string][]Installer{
+			"linux":   {{Requires: "uv", Command: "uv tool install cookiecutter"}, {Requires: "pipx", Command: "pipx install cookiecutter"}},
+			"darwin":  {{Requires: "brew", Command: "brew install cookiecutter"}, {Requires: "uv", Command: "uv tool install cookiecutter"}},
+			"windows": {{Requires: "uv", Command: "uv tool install cookiecutter"}, {Requires: "pipx", Command: "pipx install cookiecutter"}},
+		},
+	},
+	Copier: {
+		Name: Copier,
+		Installers: map[string][]Installer{
+			"linux":   {{Requires: "uv", Command: "uv tool install copier"}, {Requires: "pipx", Command: "pipx install copier"}},
+			"darwin":  {{Requires: "brew", Command: "brew install copier"}, {Requires: "uv", Command: "uv tool install copier"}},
+			"windows": {{Requires: "uv", Command: "uv tool install copier"}, {Requires: "pipx", Command: "pipx install copier"}},
+		},
+	},
+}
+
+// GeneratorEnvironment represents an environment generated from a cookiecutter or copier template
+type GeneratorEnvironment struct {
+	// Generator is Cookiecutter or Copier
+	Generator string
+	// Template is anything the generator accepts, like gh:user/repo or a local directory
+	Template string
+	// Answers are passed to the generator instead of prompting
+	Answers map[string]string
+	// AnswersFile is a cookiecutter config file or copier data file with more answers
+	AnswersFile string
+}
+
+// Ready checks the generator is installed and a template was given
+func (p GeneratorEnvironment) Ready() error {
+	if err := CommandsExist(p.Generator); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	if p.Template == "" {
+		return fmt.Errorf("no %s template, pass --template", p.Generator)
+	}
+	return nil
+}
+
+// Describe summarizes cookiecutter and copier environments
+func (p GeneratorEnvironment) Describe() Description {
+	return Description{
+		Summary:  fmt.Sprintf("A project generated from a template with %s", p.Generator),
+		Examples: []string{fmt.Sprintf("scratch new demo -t %s --template gh:user/template --var name=demo", p.Generator)},
+	}
+}
+
+// Tools returns the generator
+func (p GeneratorEnvironment) Tools() []Tool {
+	return []Tool{generatorTools[p.Generator]}
+}
+
+// Provision generates the project into the provided directory without prompting
+func (p GeneratorEnvironment) Provision(dir string) error {
+	if p.Template == "" {
+		return fmt.Errorf("no %s template, pass --template", p.Generator)
+	}
+
+	var err error
+	switch p.Generator {
+	case Cookiecutter:
+		err = p.cookiecutter(dir)
+	case Copier:
+		err = p.copier(dir)
+	default:
+		err = fmt.Errorf("unknown generator %q", p.Generator)
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("Generated environment", slog.String("generator", p.Generator), slog.String("template", p.Template), slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes projects generated by copier from the answers it records,
+// while cookiecutter leaves nothing behind to recognize
+func (p GeneratorEnvironment) Detect(dir string) float64 {
+	if p.Generator != string(CopierSpec) {
+		return NoMatch
+	}
+	return detectFiles(dir, map[string]float64{".copier-answers.yml": StrongMatch})
+}
+
+// answerArgs formats answers as sorted key=value arguments
+func (p GeneratorEnvironment) answerArgs(flag string) []string {
+	args := []string{}
+	for _, key := range slices.Sorted(maps.Keys(p.Answers)) {
+		if flag != "" {
+			args = append(args, flag)
+		}
+		args = append(args, fmt.Sprintf("%s=%s", key, p.Answers[key]))
+	}
+	return args
+}
+
+// cookiecutter generates the project next to dir, since cookiecutter names the directory
+// after the project, and moves it into place
+func (p GeneratorEnvironment) cookiecutter(dir string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".scratch-generate-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	args := []string{p.Template, "--no-input", "--output-dir", tmp}
+	if p.AnswersFile != "" {
+		args = append(args, "--config-file", p.AnswersFile)
+	}
+	if err := RunCommand("", Cookiecutter, append(args, p.answerArgs("")...)...); err != nil {
+		return fmt.Errorf("cookiecutter: %w", err)
+	}
+
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		return fmt.Errorf("cookiecutter generated %d entries instead of a project directory", len(entries))
+	}
+	// dir was created empty before provisioning
+	if err := os.Remove(dir); err != nil {
+		return fmt.Errorf("replace %s: %w", dir, err)
+	}
+	return os.Rename(filepath.Join(tmp, entries[0].Name()), dir)
+}
+
+// copier generates the project directly into dir
+func (p GeneratorEnvironment) copier(dir string) error {
+	args := []string{"copy", "--defaults"}
+	if p.AnswersFile != "" {
+		args = append(args, "--data-file", p.AnswersFile)
+	}
+	args = append(args, p.answerArgs("--data")...)
+	if err := RunCommand("", Copier, append(args, p.Template, dir)...); err != nil {
+		return fmt.Errorf("copier: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCookiecutter writes its arguments to a directory named like a generated project
+const fakeCookiecutter = `#!/bin/sh
+template=$1; shift
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --output-dir) out=$2; shift ;;
+    *) args="$args $1" ;;
+  esac
+  shift
+done
+mkdir -p "$out/my-project" && echo "$template$args" > "$out/my-project/args"
+`
+
+// fakeCopier writes its arguments to the destination directory, its last argument
+const fakeCopier = `#!/bin/sh
+for dst; do :; done
+echo "$@" > "$dst/args"
+`
+
+func TestGeneratorEnvironment_Provision(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("generators are shell scripts")
+	}
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "cookiecutter"), []byte(fakeCookiecutter), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "copier"), []byte(fakeCopier), 0755))
+	t.Setenv("PATH", bin+string(filepath.ListSeparator)+os.Getenv("PATH"))
+
+	answers := map[string]string{"project_name": "demo", "author": "me"}
+	for generator, expected := range map[string]string{
+		main.Cookiecutter: "gh:user/repo --no-input author=me project_name=demo\n",
+		main.Copier:       "copy --defaults --data author=me --data project_name=demo gh:user/repo ",
+	} {
+		p := main.GeneratorEnvironment{Generator: generator, Template: "gh:user/repo", Answers: answers}
+		require.NoError(t, p.Ready())
+
+		dir := filepath.Join(t.TempDir(), "demo")
+		require.NoError(t, os.Mkdir(dir, 0755))
+		require.NoError(t, p.Provision(dir))
+
+		data, err := os.ReadFile(filepath.Join(dir, "args"))
+		require.NoError(t, err)
+		if generator == main.Copier {
+			expected += dir + "\n"
+		}
+		require.Equal(t, expected, string(data))
+		entries, err := os.ReadDir(filepath.Dir(dir))
+		require.NoError(t, err)
+		require.Len(t, entries, 1, "temporary directories are removed")
+	}
+
+	require.Error(t, main.GeneratorEnvironment{Generator: main.Copier}.Ready())
+}
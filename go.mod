@@ -3,9 +3,12 @@ module github.com/chargeflux/scratch
 go 1.25
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/alecthomas/kong v1.13.0
 	github.com/cockroachdb/pebble v1.1.5
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/sys v0.18.0
 )
 
 require (
@@ -34,7 +37,6 @@ require (
 	github.com/prometheus/procfs v0.9.0 // indirect
 	github.com/rogpeppe/go-internal v1.9.0 // indirect
 	golang.org/x/exp v0.0.0-20230626212559-97b1e661b5df // indirect
-	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	google.golang.org/protobuf v1.33.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
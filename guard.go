@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DirSummary is what deleting a directory would remove, shown when asking for confirmation
+type DirSummary struct {
+	Files int
+	Size  ByteSize
+}
+
+func (s DirSummary) String() string {
+	return fmt.Sprintf("%d files, %s", s.Files, s.Size)
+}
+
+// SummarizeDir counts the regular files under dir and their size
+func SummarizeDir(dir string) (DirSummary, error) {
+	var summary DirSummary
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		summary.Files++
+		summary.Size += ByteSize(info.Size())
+		return nil
+	})
+	if err != nil {
+		return DirSummary{}, fmt.Errorf("summarize %s: %w", dir, err)
+	}
+	return summary, nil
+}
+
+// CheckGuard checks the directory of spec looks like something scratch created before it
+// is removed, in case its path was repointed at a real project. It passes when the marker
+// file is the one of spec, or without a marker when the files the provisioner scaffolds
+// exist or the directory is empty. Symlinks pass since only the link is removed.
+func CheckGuard(spec Spec, config Config) error {
+	info, err := os.Lstat(longPath(spec.Path))
+	if errors.Is(err, os.ErrNotExist) || (err == nil && info.Mode()&fs.ModeSymlink != 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	marker, ok, err := ReadMarker(spec.Path)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if marker.UID != spec.UID {
+			return fmt.Errorf("%s has the %s of %s: %w", spec.Path, markerFile, marker.ID(), ErrUnexpectedContent)
+		}
+		return nil
+	}
+
+	if p, err := config.Provisioner(spec.Type); err == nil {
+		if c, ok := p.(Checker); ok && c.Check(spec.Path) == nil {
+			return nil
+		}
+	}
+	entries, err := os.ReadDir(spec.Path)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s has no %s or files of a %s environment: %w", spec.Path, markerFile, spec.Type, ErrUnexpectedContent)
+}
+
+// withoutRefused drops the environments check refuses to delete, warning about each
+func withoutRefused(specs []Spec, check func(Spec) error) []Spec {
+	allowed := []Spec{}
+	for _, spec := range specs {
+		if err := check(spec); err != nil {
+			output.Warn("Skipping %s: %s", spec.ID(), err)
+			continue
+		}
+		allowed = append(allowed, spec)
+	}
+	return allowed
+}
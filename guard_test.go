@@ -0,0 +1,39 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckGuard(t *testing.T) {
+	dir := t.TempDir()
+	spec := main.NewSpec("guarded", main.NotesSpec, dir)
+	// Missing and empty directories have nothing to lose
+	require.NoError(t, main.CheckGuard(spec, main.Config{}))
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+	require.NoError(t, main.CheckGuard(spec, main.Config{}))
+
+	require.NoError(t, os.WriteFile(filepath.Join(spec.Path, "main.go"), []byte("package main\n"), 0644))
+	require.ErrorIs(t, main.CheckGuard(spec, main.Config{}), main.ErrUnexpectedContent)
+
+	// The files of a notes environment are enough without a marker
+	require.NoError(t, main.NotesEnvironment{}.Provision(spec.Path))
+	require.NoError(t, main.CheckGuard(spec, main.Config{}))
+
+	require.NoError(t, main.WriteMarker(spec))
+	require.NoError(t, main.CheckGuard(spec, main.Config{}))
+	other := main.NewSpec("other", main.NotesSpec, dir)
+	other.Path = spec.Path
+	err := main.CheckGuard(other, main.Config{})
+	require.ErrorIs(t, err, main.ErrUnexpectedContent)
+	require.ErrorContains(t, err, "notes:guarded")
+
+	summary, err := main.SummarizeDir(spec.Path)
+	require.NoError(t, err)
+	require.Equal(t, 4, summary.Files)
+	require.Contains(t, summary.String(), "4 files, ")
+}
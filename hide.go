@@ -0,0 +1,44 @@
+package main
+
+// HideCmd represents the command to hide an environment from list
+type HideCmd struct {
+	IdentifyFlags
+}
+
+// Run marks the environment hidden
+func (h HideCmd) Run(ctx *CLIContext) error {
+	return setHidden(ctx, h.IdentifyFlags, true)
+}
+
+// UnhideCmd represents the command to show a hidden environment in list again
+type UnhideCmd struct {
+	IdentifyFlags
+}
+
+// Run marks the environment visible
+func (u UnhideCmd) Run(ctx *CLIContext) error {
+	return setHidden(ctx, u.IdentifyFlags, false)
+}
+
+// setHidden hides or shows the environment identified by flags
+func setHidden(ctx *CLIContext, flags IdentifyFlags, hidden bool) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	spec, err := flags.Resolve(store)
+	if err != nil {
+		return err
+	}
+
+	spec.Hidden = hidden
+	if err := spec.Save(store); err != nil {
+		return err
+	}
+	if hidden {
+		output.Success("Hid %s, list it with scratch list --all", spec.ID())
+	} else {
+		output.Success("Unhid %s", spec.ID())
+	}
+	return nil
+}
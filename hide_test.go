@@ -0,0 +1,21 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpec_HiddenRoundTrip(t *testing.T) {
+	mw := NewMemoryStore()
+	spec := main.NewSpec("rarely", main.PythonSpec, t.TempDir())
+	spec.Hidden = true
+	require.NoError(t, spec.Save(mw))
+
+	specs, err := main.LoadSpecs(mw)
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+	require.True(t, specs[0].Hidden)
+	require.Contains(t, specs[0].Details(), "Hidden:")
+}
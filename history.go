@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HistoryAction is the kind of operation recorded in the history log
+type HistoryAction string
+
+var (
+	ActionCreate HistoryAction = "create"
+	ActionClone  HistoryAction = "clone"
+	ActionAdopt  HistoryAction = "adopt"
+	ActionDelete HistoryAction = "delete"
+	ActionRename HistoryAction = "rename"
+	ActionOpen   HistoryAction = "open"
+	// ActionTrash is recorded when an environment is deleted to the trash, and
+	// ActionDelete once it is purged from there
+	ActionTrash    HistoryAction = "trash"
+	ActionUndelete HistoryAction = "undelete"
+	// ActionExpire is recorded when an environment past its TTL is pruned
+	ActionExpire HistoryAction = "expire"
+)
+
+// HistoryActions lists every action recorded in the history log
+var HistoryActions = []HistoryAction{ActionCreate, ActionClone, ActionAdopt, ActionDelete, ActionRename, ActionOpen, ActionTrash, ActionUndelete, ActionExpire}
+
+// maxEventFiles caps the files recorded for a deleted environment
+const maxEventFiles = 50
+
+// Event is one operation recorded in the history log
+type Event struct {
+	Time   time.Time     `json:"time"`
+	Action HistoryAction `json:"action"`
+	UID    string        `json:"uid,omitempty"`
+	ID     string        `json:"id"`
+	Type   SpecType      `json:"type"`
+	Path   string        `json:"path"`
+	// Workspace is the workspace of the environment, empty for the default one
+	Workspace string `json:"workspace,omitempty"`
+	// Size is the disk usage of a deleted environment
+	Size ByteSize `json:"size,omitempty"`
+	// Seconds is how long the operation took, like provisioning a new environment
+	Seconds float64 `json:"seconds,omitempty"`
+	// Params are the options of the operation, like the program opening an environment
+	Params map[string]string `json:"params,omitempty"`
+	// Files are the top-level entries of a deleted environment, directories ending in /
+	Files []string `json:"files,omitempty"`
+}
+
+// NewEvent creates an event for an operation on spec happening now
+func NewEvent(action HistoryAction, spec Spec) Event {
+	event := Event{Time: time.Now(), Action: action, UID: spec.UID, ID: spec.ID(), Type: spec.Type, Path: spec.Path}
+	if workspace != DefaultWorkspace {
+		event.Workspace = workspace
+	}
+	return event
+}
+
+// With returns the event with the parameter key set to value, unless value is empty
+func (e Event) With(key string, value string) Event {
+	if value == "" {
+		return e
+	}
+	e.Params = maps.Clone(e.Params)
+	if e.Params == nil {
+		e.Params = map[string]string{}
+	}
+	e.Params[key] = value
+	return e
+}
+
+// WithContents returns the event with the top-level entries of dir and its size,
+// which are best effort since the directory may already be gone
+func (e Event) WithContents(dir string) Event {
+	if size, err := DirSize(dir); err == nil {
+		e.Size = size
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return e
+	}
+	e.Files = nil
+	for _, entry := range entries {
+		if len(e.Files) == maxEventFiles {
+			e.Files = append(e.Files, fmt.Sprintf("... %d more", len(entries)-maxEventFiles))
+			break
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		e.Files = append(e.Files, name)
+	}
+	return e
+}
+
+// Details describes the parameters, size and files of the event on one line
+func (e Event) Details() string {
+	details := []string{}
+	for _, key := range slices.Sorted(maps.Keys(e.Params)) {
+		details = append(details, fmt.Sprintf("%s=%s", key, e.Params[key]))
+	}
+	if e.Seconds >= 1 {
+		details = append(details, fmt.Sprintf("took %.1fs", e.Seconds))
+	}
+	if e.Size > 0 {
+		details = append(details, e.Size.String())
+	}
+	if len(e.Files) > 0 {
+		details = append(details, "files: "+strings.Join(e.Files, " "))
+	}
+	return strings.Join(details, ", ")
+}
+
+// DefaultHistoryPath returns the path of the history log
+func DefaultHistoryPath() (string, error) {
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// AppendEvent appends the event to the history log at path
+func AppendEvent(path string, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	return f.Close()
+}
+
+// LoadEvents reads every event from the history log at path, returning none if it does not exist
+func LoadEvents(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+
+	events := []Event{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("unmarshal history line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return events, nil
+}
+
+// recordEvent appends the event to the default history log and notifies it,
+// logging rather than failing the operation if it cannot be written
+func recordEvent(event Event) {
+	path, err := DefaultHistoryPath()
+	if err == nil {
+		err = AppendEvent(path, event)
+	}
+	if err != nil {
+		output.Warn("Could not record history: %s", err)
+	}
+	notify(event)
+}
+
+// EventFilter selects events from the history log
+type EventFilter struct {
+	// Query is all or part of the name of environments, or a ULID
+	Query   string
+	Actions []HistoryAction
+	Type    SpecType
+	// Since drops events before it when set
+	Since time.Time
+}
+
+// Match checks if event passes the filter
+func (f EventFilter) Match(event Event) bool {
+	if len(f.Actions) > 0 && !slices.Contains(f.Actions, event.Action) {
+		return false
+	}
+	if f.Type != "" && event.Type != f.Type {
+		return false
+	}
+	if !f.Since.IsZero() && event.Time.Before(f.Since) {
+		return false
+	}
+	if f.Query != "" && event.UID != f.Query {
+		_, name, err := ParseSpecID(event.ID)
+		if err != nil || matchName(f.Query, name) < matchSubstring {
+			return false
+		}
+	}
+	return true
+}
+
+// HistoryCmd represents the command to show the history log
+type HistoryCmd struct {
+	Query  string          `arg:"" optional:"" help:"All or part of the name of environments, or a ULID, to show events of"`
+	Action []HistoryAction `short:"a" help:"Only these actions, like delete or trash"`
+	Type   SpecType        `short:"t" help:"Only environments of this type"`
+	Since  Duration        `help:"Only events in the last duration, like 7d"`
+	Limit  int             `short:"n" help:"Show the last N events, 0 for all" default:"20"`
+	JSON   bool            `help:"Print events as JSON lines"`
+}
+
+// Run prints the matching events, oldest first
+func (h HistoryCmd) Run(ctx *CLIContext) error {
+	path, err := DefaultHistoryPath()
+	if err != nil {
+		return err
+	}
+	events, err := LoadEvents(path)
+	if err != nil {
+		return err
+	}
+
+	filter := EventFilter{Query: h.Query, Actions: h.Action, Type: h.Type}
+	if h.Since > 0 {
+		filter.Since = time.Now().Add(-time.Duration(h.Since))
+	}
+	events = slices.DeleteFunc(events, func(event Event) bool { return !filter.Match(event) })
+	if h.Limit > 0 && len(events) > h.Limit {
+		events = events[len(events)-h.Limit:]
+	}
+	if len(events) == 0 {
+		output.Info("No events recorded")
+		return nil
+	}
+
+	if h.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, event := range events {
+			if err := enc.Encode(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	table := Table{Header: []string{"time", "action", "id", "details"}}
+	for _, event := range events {
+		table.Append(event.Time.Local().Format(time.DateTime), string(event.Action), event.ID, event.Details())
+	}
+	return table.Write(os.Stdout, IsTerminal(os.Stdout))
+}
@@ -0,0 +1,79 @@
+package main_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	events, err := main.LoadEvents(path)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	spec := main.Spec{Name: "demo", Type: main.PythonSpec, Path: "/tmp/demo"}
+	created := main.NewEvent(main.ActionCreate, spec)
+	deleted := main.NewEvent(main.ActionDelete, spec)
+	deleted.Size = 1024
+	require.NoError(t, main.AppendEvent(path, created))
+	require.NoError(t, main.AppendEvent(path, deleted))
+
+	events, err = main.LoadEvents(path)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, main.ActionCreate, events[0].Action)
+	require.Equal(t, "python:demo", events[0].ID)
+	require.True(t, created.Time.Equal(events[0].Time))
+	require.Equal(t, main.ByteSize(1024), events[1].Size)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0644))
+	_, err = main.LoadEvents(path)
+	require.Error(t, err)
+}
+
+func TestEvent_WithContents(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("hello"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "data"), 0755))
+	spec := main.Spec{Name: "demo", Type: main.PythonSpec, Path: dir}
+
+	event := main.NewEvent(main.ActionTrash, spec).With("permanent", "true").With("description", "").WithContents(dir)
+	require.Equal(t, map[string]string{"permanent": "true"}, event.Params)
+	require.Equal(t, []string{"data/", "notes.md"}, event.Files)
+	require.EqualValues(t, 5, event.Size)
+	require.Equal(t, "permanent=true, 5B, files: data/ notes.md", event.Details())
+
+	for i := range 60 {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d", i)), nil, 0644))
+	}
+	event = event.WithContents(dir)
+	require.Len(t, event.Files, 51)
+	require.Equal(t, "... 12 more", event.Files[50])
+
+	gone := event.WithContents(filepath.Join(dir, "missing"))
+	require.Equal(t, event.Files, gone.Files)
+}
+
+func TestEventFilter_Match(t *testing.T) {
+	now := time.Now()
+	spec := main.Spec{UID: main.NewULID(now), Name: "pandas-test", Type: main.PythonSpec, Path: "/tmp/pandas-test"}
+	event := main.NewEvent(main.ActionDelete, spec)
+	event.Time = now.Add(-48 * time.Hour)
+
+	require.True(t, main.EventFilter{}.Match(event))
+	require.True(t, main.EventFilter{Query: "PANDAS"}.Match(event))
+	require.True(t, main.EventFilter{Query: spec.UID}.Match(event))
+	require.False(t, main.EventFilter{Query: "pdt"}.Match(event))
+	require.True(t, main.EventFilter{Actions: []main.HistoryAction{main.ActionTrash, main.ActionDelete}}.Match(event))
+	require.False(t, main.EventFilter{Actions: []main.HistoryAction{main.ActionOpen}}.Match(event))
+	require.False(t, main.EventFilter{Type: main.DenoSpec}.Match(event))
+	require.True(t, main.EventFilter{Since: now.Add(-72 * time.Hour)}.Match(event))
+	require.False(t, main.EventFilter{Since: now.Add(-24 * time.Hour)}.Match(event))
+}
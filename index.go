@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Specs are stored under spec/<ULID> and indexed by name under name/<type>:<name>/<ULID>,
+// so environments of the same type can share a name in different directories.
+// Specs saved before ULIDs are keyed by their ID until MigrateSpecKeys moves them.
+const (
+	specKeyPrefix = "spec/"
+	nameKeyPrefix = "name/"
+)
+
+// ReadLister can fetch and list key-value pairs
+type ReadLister interface {
+	Reader
+	Lister
+}
+
+// Key returns the store key of the spec
+func (s Spec) Key() string {
+	if s.UID == "" {
+		return s.ID()
+	}
+	return specKeyPrefix + s.UID
+}
+
+// nameKey returns the key of the spec in the name index
+func (s Spec) nameKey() string {
+	return nameKeyPrefix + s.ID() + "/" + s.UID
+}
+
+// isSpecKey checks if key holds a tracked spec rather than an index entry, log
+// or deleted spec
+func isSpecKey(key string) bool {
+	for _, prefix := range []string{nameKeyPrefix, logKeyPrefix, manifestKeyPrefix, trashKeyPrefix, metaKeyPrefix} {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// Delete removes the spec and its index entry from storage
+func (s Spec) Delete(storer Writer) error {
+	if err := storer.Delete(s.Key()); err != nil {
+		return err
+	}
+	if s.UID != "" {
+		if err := storer.Delete(s.nameKey()); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := storer.Delete(s.logKey()); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := storer.Delete(s.manifestKey()); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForEachSpec loads every spec in the store, skipping index entries
+func ForEachSpec(lister Lister, handle func(Spec) error) error {
+	return lister.ListFunc(func(key string, data []byte) error {
+		if !isSpecKey(key) {
+			return nil
+		}
+		spec, err := LoadSpec(data)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", key, err)
+		}
+		return handle(spec)
+	})
+}
+
+// FindSpecs returns every environment of specType called name using the name index
+func FindSpecs(store ReadLister, specType SpecType, name string) ([]Spec, error) {
+	id := SpecID(specType, name)
+	prefix := nameKeyPrefix + id + "/"
+	keys := []string{}
+	err := store.ListPrefix(prefix, func(key string, data []byte) error {
+		keys = append(keys, specKeyPrefix+strings.TrimPrefix(key, prefix))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list keys: %w", err)
+	}
+
+	exists, err := store.Exists(id)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		keys = append(keys, id)
+	}
+
+	specs := make([]Spec, 0, len(keys))
+	for _, key := range keys {
+		spec, err := LookupSpec(store, key)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// ForEachSpecOfType loads every spec of specType, or of any type when empty. Only
+// the name index entries of the type and specs saved before ULIDs are scanned.
+func ForEachSpecOfType(store ReadLister, specType SpecType, handle func(Spec) error) error {
+	if specType == "" {
+		return ForEachSpec(store, handle)
+	}
+
+	uids := []string{}
+	err := store.ListPrefix(nameKeyPrefix+SpecID(specType, ""), func(key string, data []byte) error {
+		uids = append(uids, path.Base(key))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list keys: %w", err)
+	}
+	for _, uid := range uids {
+		spec, err := LookupSpec(store, specKeyPrefix+uid)
+		if err != nil {
+			return err
+		}
+		if err := handle(spec); err != nil {
+			return err
+		}
+	}
+
+	return store.ListPrefix(SpecID(specType, ""), func(key string, data []byte) error {
+		spec, err := LoadSpec(data)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", key, err)
+		}
+		return handle(spec)
+	})
+}
+
+// LoadSpecsOfType loads every spec of specType, or of any type when empty
+func LoadSpecsOfType(store ReadLister, specType SpecType) ([]Spec, error) {
+	specs := []Spec{}
+	err := ForEachSpecOfType(store, specType, func(spec Spec) error {
+		specs = append(specs, spec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// IsNamePattern checks if name is a glob pattern rather than an exact name
+func IsNamePattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// MatchNamePattern returns every environment of specType, or of any type when empty,
+// whose name matches the glob pattern
+func MatchNamePattern(store ReadLister, specType SpecType, pattern string) ([]Spec, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	specs := []Spec{}
+	err := ForEachSpecOfType(store, specType, func(spec Spec) error {
+		if ok, _ := path.Match(pattern, spec.Name); ok || spec.Name == pattern {
+			specs = append(specs, spec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// FindSpecsAtPath returns every environment whose directory is dir
+func FindSpecsAtPath(lister Lister, dir string) ([]Spec, error) {
+	dir = filepath.Clean(dir)
+	specs := []Spec{}
+	err := ForEachSpec(lister, func(spec Spec) error {
+		if filepath.Clean(spec.Path) == dir {
+			specs = append(specs, spec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// LookupID finds the environment by ULID or by type:name
+func LookupID(store ReadLister, id string) (Spec, error) {
+	if IsULID(id) {
+		return LookupSpec(store, specKeyPrefix+id)
+	}
+	specType, name, err := ParseSpecID(id)
+	if err != nil {
+		return Spec{}, err
+	}
+	return ResolveName(store, specType, name)
+}
+
+// ResolveName finds the environment of specType called name, asking which one is
+// meant when several share the name
+func ResolveName(store ReadLister, specType SpecType, name string) (Spec, error) {
+	id := SpecID(specType, name)
+	specs, err := FindSpecs(store, specType, name)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	if len(specs) == 0 {
+		return Spec{}, fmt.Errorf("get environment %q: %w", id, ErrNotFound)
+	}
+	return chooseSpec(fmt.Sprintf("environments are called %q", id), specs)
+}
+
+// FindExisting finds the tracked environment with the same name, type and path as spec
+func FindExisting(store ReadLister, spec Spec) (Spec, bool, error) {
+	specs, err := FindSpecs(store, spec.Type, spec.Name)
+	if err != nil {
+		return Spec{}, false, err
+	}
+	for _, other := range specs {
+		if other.Path == spec.Path {
+			return other, true, nil
+		}
+	}
+	return Spec{}, false, nil
+}
+
+// checkDuplicate checks no environment with the same name and type is tracked at the path of spec
+func checkDuplicate(store ReadLister, spec Spec) error {
+	_, ok, err := FindExisting(store, spec)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return fmt.Errorf("environment %q %w at %s", spec.ID(), ErrExists, spec.Path)
+	}
+	return nil
+}
+
+// MigrateSpecKeys moves specs saved before ULIDs to ULID keys with a name index entry
+func MigrateSpecKeys(store Storer) (int, error) {
+	legacy := []string{}
+	for key, err := range store.List() {
+		if err != nil {
+			return 0, fmt.Errorf("list keys: %w", err)
+		}
+		if isSpecKey(key) && !strings.HasPrefix(key, specKeyPrefix) {
+			legacy = append(legacy, key)
+		}
+	}
+
+	for _, key := range legacy {
+		spec, err := LookupSpec(store, key)
+		if err != nil {
+			return 0, err
+		}
+		spec = spec.withUID()
+		if err := spec.Save(store); err != nil {
+			return 0, err
+		}
+		if err := store.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return len(legacy), nil
+}
+
+// withUID returns the spec with the ULID derived from its ID if it was saved before ULIDs
+func (s Spec) withUID() Spec {
+	if s.UID == "" {
+		s.UID = legacyULID(s.ID())
+	}
+	return s
+}
+
+// marshalIndexValue encodes the ULID an index entry points to
+func marshalIndexValue(uid string) []byte {
+	data, _ := json.Marshal(uid)
+	return data
+}
@@ -0,0 +1,153 @@
+package main_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindSpecs(t *testing.T) {
+	store := NewMemoryStore()
+	a := main.NewSpec("test", main.PythonSpec, "/a")
+	b := main.NewSpec("test", main.PythonSpec, "/b")
+	other := main.NewSpec("other", main.PythonSpec, "/a")
+	legacy := main.Spec{Name: "test", Type: main.PythonSpec, Path: "/c/test"}
+	for _, spec := range []main.Spec{a, b, other, legacy} {
+		require.NoError(t, spec.Save(store))
+	}
+	require.Contains(t, store.Data, "python:test")
+
+	specs, err := main.FindSpecs(store, main.PythonSpec, "test")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []main.Spec{a, b, legacy}, specs)
+
+	all, err := main.LoadSpecs(store)
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+
+	found, err := main.LookupID(store, b.UID)
+	require.NoError(t, err)
+	require.Equal(t, b, found)
+	found, err = main.LookupID(store, "python:other")
+	require.NoError(t, err)
+	require.Equal(t, other, found)
+
+	// Several matches are ambiguous without a terminal to ask on
+	_, err = main.ResolveName(store, main.PythonSpec, "test")
+	require.ErrorContains(t, err, b.UID)
+	_, err = main.ResolveName(store, main.PythonSpec, "missing")
+	require.ErrorIs(t, err, main.ErrNotFound)
+
+	require.NoError(t, a.Delete(store))
+	require.NoError(t, b.Delete(store))
+	require.NoError(t, legacy.Delete(store))
+	require.Len(t, store.Data, 2)
+}
+
+func TestMigrateSpecKeys(t *testing.T) {
+	store := NewMemoryStore()
+	legacy := main.Spec{Name: "test", Type: main.PythonSpec, Path: filepath.Join("/a", "test")}
+	current := main.NewSpec("new", main.PythonSpec, "/a")
+	require.NoError(t, legacy.Save(store))
+	require.NoError(t, current.Save(store))
+
+	n, err := main.MigrateSpecKeys(store)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.NotContains(t, store.Data, "python:test")
+
+	specs, err := main.FindSpecs(store, main.PythonSpec, "test")
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+	require.True(t, main.IsULID(specs[0].UID))
+
+	// Migrating the same environment elsewhere assigns the same ULID
+	other := NewMemoryStore()
+	require.NoError(t, legacy.Save(other))
+	_, err = main.MigrateSpecKeys(other)
+	require.NoError(t, err)
+	require.Contains(t, other.Data, specs[0].Key())
+
+	n, err = main.MigrateSpecKeys(store)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestNewULID(t *testing.T) {
+	now := time.Now()
+	first := main.NewULID(now)
+	second := main.NewULID(now.Add(time.Millisecond))
+	require.True(t, main.IsULID(first))
+	require.Less(t, first, second)
+	require.NotEqual(t, first, main.NewULID(now))
+
+	require.False(t, main.IsULID("python:test"))
+	require.False(t, main.IsULID("81M55J1DH00000000000000000"))
+}
+
+func TestFindExisting(t *testing.T) {
+	store := NewMemoryStore()
+	a := main.NewSpec("test", main.PythonSpec, "/a")
+	require.NoError(t, a.Save(store))
+
+	found, ok, err := main.FindExisting(store, main.NewSpec("test", main.PythonSpec, "/a"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, a, found)
+
+	_, ok, err = main.FindExisting(store, main.NewSpec("test", main.PythonSpec, "/b"))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestLoadSpecsOfType(t *testing.T) {
+	store := NewMemoryStore()
+	python := main.NewSpec("a", main.PythonSpec, "/a")
+	deno := main.NewSpec("a", main.DenoSpec, "/a")
+	legacy := main.Spec{Name: "old", Type: main.PythonSpec, Path: "/a/old"}
+	for _, spec := range []main.Spec{python, deno, legacy} {
+		require.NoError(t, spec.Save(store))
+	}
+
+	specs, err := main.LoadSpecsOfType(store, main.PythonSpec)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []main.Spec{python, legacy}, specs)
+
+	specs, err = main.LoadSpecsOfType(store, "")
+	require.NoError(t, err)
+	require.Len(t, specs, 3)
+
+	work := main.NewWorkspaceStore(store, "work")
+	require.NoError(t, python.Save(work))
+	specs, err = main.LoadSpecsOfType(work, main.PythonSpec)
+	require.NoError(t, err)
+	require.Equal(t, []main.Spec{python}, specs)
+}
+
+func TestMatchNamePattern(t *testing.T) {
+	store := NewMemoryStore()
+	a := main.NewSpec("proto-a", main.PythonSpec, "/a")
+	b := main.NewSpec("proto-b", main.PythonSpec, "/b")
+	deno := main.NewSpec("proto-c", main.DenoSpec, "/a")
+	other := main.NewSpec("other", main.PythonSpec, "/a")
+	for _, spec := range []main.Spec{a, b, deno, other} {
+		require.NoError(t, spec.Save(store))
+	}
+
+	require.True(t, main.IsNamePattern("proto-*"))
+	require.False(t, main.IsNamePattern("proto-a"))
+
+	specs, err := main.MatchNamePattern(store, main.PythonSpec, "proto-*")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []main.Spec{a, b}, specs)
+
+	_, err = main.MatchNamePattern(store, main.PythonSpec, "proto-[")
+	require.Error(t, err)
+
+	specs, err = main.FindSpecsAtPath(store, "/a/proto-a/")
+	require.NoError(t, err)
+	require.Equal(t, []main.Spec{a}, specs)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+var denoTool = Tool{
+	Name: "deno",
+	Installers: map[string][]Installer{
+		"linux":   {{Command: "curl -fsSL https://deno.land/install.sh | sh"}},
+		"darwin":  {{Requires: "brew", Command: "brew install deno"}, {Command: "curl -fsSL https://deno.land/install.sh | sh"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=DenoLand.Deno -e"}, {Command: "irm https://deno.land/install.ps1 | iex"}},
+	},
+}
+
+var bunTool = Tool{
+	Name: "bun",
+	Installers: map[string][]Installer{
+		"linux":   {{Command: "curl -fsSL https://bun.sh/install | bash"}},
+		"darwin":  {{Requires: "brew", Command: "brew install oven-sh/bun/bun"}, {Command: "curl -fsSL https://bun.sh/install | bash"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=Oven-sh.Bun -e"}, {Command: "irm https://bun.sh/install.ps1 | iex"}},
+	},
+}
+
+// DenoEnvironment represents a Deno project to be created
+type DenoEnvironment struct{}
+
+// Ready checks if the environment is ready to be created
+func (p DenoEnvironment) Ready() error {
+	if err := CommandsExist("deno"); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	return nil
+}
+
+// Provision creates the environment at provided directory
+func (p DenoEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	if err := RunCommand(dir, "deno", "init"); err != nil {
+		return fmt.Errorf("deno init: %w", err)
+	}
+
+	slog.Debug("Provisioned deno environment", slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes projects with a Deno config or lock file
+func (p DenoEnvironment) Detect(dir string) float64 {
+	return detectFiles(dir, map[string]float64{
+		"deno.json":  StrongMatch,
+		"deno.jsonc": StrongMatch,
+		"deno.lock":  LikelyMatch,
+	})
+}
+
+// Describe summarizes deno environments
+func (p DenoEnvironment) Describe() Description {
+	return Description{Summary: "A Deno project created with deno init", Examples: []string{"scratch new demo -t deno"}}
+}
+
+// Tools returns deno
+func (p DenoEnvironment) Tools() []Tool {
+	return []Tool{denoTool}
+}
+
+// Check verifies the project file exists
+func (p DenoEnvironment) Check(dir string) error {
+	return FilesExist(dir, "deno.json")
+}
+
+// Ignore returns npm packages installed by deno
+func (p DenoEnvironment) Ignore() []string {
+	return []string{"node_modules"}
+}
+
+// Rebuild reinstalls dependencies
+func (p DenoEnvironment) Rebuild(dir string) error {
+	if err := RunCommand(dir, "deno", "install"); err != nil {
+		return fmt.Errorf("deno install: %w", err)
+	}
+	return nil
+}
+
+// Reprovision initializes the project if deno.json is missing
+func (p DenoEnvironment) Reprovision(dir string) error {
+	if FilesExist(dir, "deno.json") != nil {
+		slog.Debug("Initializing missing project", slog.String("dir", dir))
+		if err := RunCommand(dir, "deno", "init"); err != nil {
+			return fmt.Errorf("deno init: %w", err)
+		}
+	}
+	return nil
+}
+
+// BunEnvironment represents a Bun project to be created
+type BunEnvironment struct{}
+
+// Ready checks if the environment is ready to be created
+func (p BunEnvironment) Ready() error {
+	if err := CommandsExist("bun"); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	return nil
+}
+
+// Provision creates the environment at provided directory
+func (p BunEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	if err := RunCommand(dir, "bun", "init", "-y"); err != nil {
+		return fmt.Errorf("bun init: %w", err)
+	}
+
+	slog.Debug("Provisioned bun environment", slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes projects with a Bun lock file, and weakly any JavaScript package
+func (p BunEnvironment) Detect(dir string) float64 {
+	return detectFiles(dir, map[string]float64{
+		"bun.lock":     StrongMatch,
+		"bun.lockb":    StrongMatch,
+		"bunfig.toml":  LikelyMatch,
+		"package.json": WeakMatch,
+	})
+}
+
+// Describe summarizes bun environments
+func (p BunEnvironment) Describe() Description {
+	return Description{Summary: "A Bun project created with bun init", Examples: []string{"scratch new demo -t bun"}}
+}
+
+// Tools returns bun
+func (p BunEnvironment) Tools() []Tool {
+	return []Tool{bunTool}
+}
+
+// Check verifies the package file and installed packages exist
+func (p BunEnvironment) Check(dir string) error {
+	return FilesExist(dir, "package.json", "node_modules")
+}
+
+// Ignore returns installed packages
+func (p BunEnvironment) Ignore() []string {
+	return []string{"node_modules"}
+}
+
+// Rebuild reinstalls dependencies
+func (p BunEnvironment) Rebuild(dir string) error {
+	if err := RunCommand(dir, "bun", "install"); err != nil {
+		return fmt.Errorf("bun install: %w", err)
+	}
+	return nil
+}
+
+// Upgrade updates dependencies to their latest compatible versions
+func (p BunEnvironment) Upgrade(dir string) error {
+	if err := RunCommand(dir, "bun", "update"); err != nil {
+		return fmt.Errorf("bun update: %w", err)
+	}
+	return nil
+}
+
+// Reprovision initializes the project if package.json is missing and reinstalls
+// dependencies if node_modules is missing
+func (p BunEnvironment) Reprovision(dir string) error {
+	if FilesExist(dir, "package.json") != nil {
+		slog.Debug("Initializing missing project", slog.String("dir", dir))
+		if err := RunCommand(dir, "bun", "init", "-y"); err != nil {
+			return fmt.Errorf("bun init: %w", err)
+		}
+	}
+	if FilesExist(dir, "node_modules") != nil {
+		slog.Debug("Reinstalling missing packages", slog.String("dir", dir))
+		return p.Rebuild(dir)
+	}
+	return nil
+}
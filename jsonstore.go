@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JSONStore is a Storer backed by a single human-readable JSON file
+type JSONStore struct {
+	path string
+	data map[string]json.RawMessage
+	// lock is held until the store is closed, as every write rewrites the whole file.
+	// Read-only stores have none and cannot be written.
+	lock io.Closer
+}
+
+// NewJSONStore loads the store from path, which is created on first write. Like Pebble,
+// only one process can open it at a time, retrying briefly before failing with ErrStoreLocked.
+func NewJSONStore(path string) (*JSONStore, error) {
+	if err := EnsureDirectory(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	var lock io.Closer
+	for attempt := 1; ; attempt++ {
+		var err error
+		lock, err = lockFile(path + ".lock")
+		if err == nil {
+			break
+		}
+		if !isLockError(err) {
+			return nil, fmt.Errorf("lock json store: %w", err)
+		}
+		if attempt == lockRetries {
+			return nil, ErrStoreLocked
+		}
+		slog.Debug("Store is locked, retrying", slog.Int("attempt", attempt))
+		time.Sleep(lockInterval)
+	}
+
+	s, err := ReadJSONStore(path)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	s.lock = lock
+	return s, nil
+}
+
+// ReadJSONStore loads the store from path without locking it, for reading backups
+// or a snapshot while another process holds the store. Writes to it fail.
+func ReadJSONStore(path string) (*JSONStore, error) {
+	s := &JSONStore{path: path, data: map[string]json.RawMessage{}}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read json store: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("unmarshal json store %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// Exists checks if a key exists
+func (s *JSONStore) Exists(key string) (bool, error) {
+	_, ok := s.data[key]
+	return ok, nil
+}
+
+// Get fetches data by key
+func (s *JSONStore) Get(key string) ([]byte, error) {
+	val, ok := s.data[key]
+	if !ok {
+		return nil, fmt.Errorf("get key %q: %w", key, ErrNotFound)
+	}
+	return slices.Clone([]byte(val)), nil
+}
+
+// List lists all keys in store
+func (s *JSONStore) List() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for _, key := range slices.Sorted(maps.Keys(s.data)) {
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ListFunc processes each key-value pair with provided function
+func (s *JSONStore) ListFunc(handle func(key string, data []byte) error) error {
+	return s.ListPrefix("", handle)
+}
+
+// ListPrefix processes each key-value pair whose key starts with prefix
+func (s *JSONStore) ListPrefix(prefix string, handle func(key string, data []byte) error) error {
+	for _, key := range slices.Sorted(maps.Keys(s.data)) {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := handle(key, slices.Clone([]byte(s.data[key]))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Put adds or replaces a key with its data, which must be valid JSON
+func (s *JSONStore) Put(key string, data []byte) error {
+	if err := jsonBatch(s.data).Put(key, data); err != nil {
+		return err
+	}
+	if err := s.flush(); err != nil {
+		return fmt.Errorf("put key %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes key with its data
+func (s *JSONStore) Delete(key string) error {
+	jsonBatch(s.data).Delete(key)
+	if err := s.flush(); err != nil {
+		return fmt.Errorf("delete key %q: %w", key, err)
+	}
+	return nil
+}
+
+// Batch applies the writes made by apply to a copy of the store and flushes it
+// once, so the file on disk has either all of them or none
+func (s *JSONStore) Batch(apply func(w Writer) error) error {
+	staged := jsonBatch(maps.Clone(s.data))
+	if err := apply(staged); err != nil {
+		return err
+	}
+
+	previous := s.data
+	s.data = staged
+	if err := s.flush(); err != nil {
+		s.data = previous
+		return fmt.Errorf("commit batch: %w", err)
+	}
+	return nil
+}
+
+// jsonBatch is a Writer changing the data of a JSONStore in memory without flushing it
+type jsonBatch map[string]json.RawMessage
+
+// Put adds or replaces a key with its data, which must be valid JSON
+func (b jsonBatch) Put(key string, data []byte) error {
+	if !json.Valid(data) {
+		return fmt.Errorf("put key %q: value is not valid JSON", key)
+	}
+	b[key] = slices.Clone(data)
+	return nil
+}
+
+// Delete removes key with its data
+func (b jsonBatch) Delete(key string) error {
+	delete(b, key)
+	return nil
+}
+
+// Close releases the lock, as every write is already flushed
+func (s *JSONStore) Close() error {
+	if s.lock == nil {
+		return nil
+	}
+	lock := s.lock
+	s.lock = nil
+	return lock.Close()
+}
+
+// flush atomically writes the store to disk
+func (s *JSONStore) flush() error {
+	if s.lock == nil {
+		return fmt.Errorf("json store %s is read-only", s.path)
+	}
+	raw, err := json.MarshalIndent(s.data, "", " ")
+	if err != nil {
+		return fmt.Errorf("marshal json store: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("write json store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("replace json store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+var juliaTool = Tool{
+	Name: "julia",
+	Installers: map[string][]Installer{
+		"linux":   {{Command: "curl -fsSL https://install.julialang.org | sh -s -- --yes"}},
+		"darwin":  {{Command: "curl -fsSL https://install.julialang.org | sh -s -- --yes"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=Julialang.Juliaup -e"}},
+	},
+}
+
+// juliaFiles are written to new Julia environments, with Project.toml listing no
+// packages until some are added with Pkg.add
+var juliaFiles = map[string]string{
+	"Project.toml": "[deps]\n",
+	"main.jl": `# Run with: julia --project=. main.jl
+# Add packages with: julia --project=. -e 'using Pkg; Pkg.add("DataFrames")'
+
+println("Hello from Julia $(VERSION)")
+`,
+}
+
+// instantiateArgs runs Pkg.instantiate in the project of the working directory,
+// which resolves Project.toml into Manifest.toml and installs the packages
+var instantiateArgs = []string{"--project=.", "-e", "using Pkg; Pkg.instantiate()"}
+
+// JuliaEnvironment represents a Julia project to be created
+type JuliaEnvironment struct{}
+
+// Ready checks if the environment is ready to be created
+func (p JuliaEnvironment) Ready() error {
+	if err := CommandsExist("julia"); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	return nil
+}
+
+// instantiate installs the packages of the project in dir
+func (p JuliaEnvironment) instantiate(dir string) error {
+	if err := RunCommand(dir, "julia", instantiateArgs...); err != nil {
+		return fmt.Errorf("Pkg.instantiate: %w", err)
+	}
+	return nil
+}
+
+// Provision creates the environment at provided directory
+func (p JuliaEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	if err := writeMissingFiles(dir, juliaFiles); err != nil {
+		return err
+	}
+	if err := p.instantiate(dir); err != nil {
+		return err
+	}
+
+	slog.Debug("Provisioned julia environment", slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes Julia projects, and likely any with Julia sources
+func (p JuliaEnvironment) Detect(dir string) float64 {
+	confidence := detectFiles(dir, map[string]float64{
+		"Project.toml":      StrongMatch,
+		"JuliaProject.toml": StrongMatch,
+		"Manifest.toml":     LikelyMatch,
+	})
+	return max(confidence, detectGlob(dir, "*.jl", LikelyMatch))
+}
+
+// Describe summarizes julia environments
+func (p JuliaEnvironment) Describe() Description {
+	return Description{Summary: "A Julia project instantiated with Pkg", Examples: []string{"scratch new sim -t julia"}}
+}
+
+// Tools returns julia, installed with juliaup
+func (p JuliaEnvironment) Tools() []Tool {
+	return []Tool{juliaTool}
+}
+
+// Check verifies the project and script exist
+func (p JuliaEnvironment) Check(dir string) error {
+	return FilesExist(dir, slices.Sorted(maps.Keys(juliaFiles))...)
+}
+
+// Rebuild installs the packages of the manifest again
+func (p JuliaEnvironment) Rebuild(dir string) error {
+	return p.instantiate(dir)
+}
+
+// Reprovision writes the scaffolded files that are missing and instantiates the
+// project if Manifest.toml is missing
+func (p JuliaEnvironment) Reprovision(dir string) error {
+	if err := writeMissingFiles(dir, juliaFiles); err != nil {
+		return err
+	}
+	if FilesExist(dir, "Manifest.toml") != nil {
+		slog.Debug("Instantiating project without manifest", slog.String("dir", dir))
+		return p.instantiate(dir)
+	}
+	return nil
+}
+
+// Upgrade updates the packages of the project to their latest compatible versions
+func (p JuliaEnvironment) Upgrade(dir string) error {
+	if err := RunCommand(dir, "julia", "--project=.", "-e", "using Pkg; Pkg.update()"); err != nil {
+		return fmt.Errorf("Pkg.update: %w", err)
+	}
+	return nil
+}
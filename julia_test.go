@@ -0,0 +1,46 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJulia records its arguments and writes the manifest like Pkg.instantiate
+const fakeJulia = `#!/bin/sh
+echo "$@" >> julia.log
+echo 'julia_version = "1.11.0"' > Manifest.toml
+`
+
+func TestJuliaEnvironment_Provision(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("julia is faked with a shell script")
+	}
+	t.Setenv("PATH", fakeBin(t, map[string]string{"julia": fakeJulia}))
+
+	dir := filepath.Join(t.TempDir(), "sim")
+	p := main.JuliaEnvironment{}
+	require.NoError(t, p.Ready())
+	require.NoError(t, p.Provision(dir))
+	require.NoError(t, p.Check(dir))
+
+	log, err := os.ReadFile(filepath.Join(dir, "julia.log"))
+	require.NoError(t, err)
+	require.Equal(t, "--project=. -e using Pkg; Pkg.instantiate()\n", string(log))
+
+	specType, err := main.DetectType(dir)
+	require.NoError(t, err)
+	require.Equal(t, main.JuliaSpec, specType)
+
+	// Reprovisioning only instantiates the project again when the manifest is missing
+	require.NoError(t, p.Reprovision(dir))
+	require.NoError(t, os.Remove(filepath.Join(dir, "Manifest.toml")))
+	require.NoError(t, p.Reprovision(dir))
+	log, err = os.ReadFile(filepath.Join(dir, "julia.log"))
+	require.NoError(t, err)
+	require.Equal(t, "--project=. -e using Pkg; Pkg.instantiate()\n--project=. -e using Pkg; Pkg.instantiate()\n", string(log))
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+var jdkTool = Tool{
+	Name: "javac",
+	Installers: map[string][]Installer{
+		"linux": {
+			{Requires: "apt-get", Command: "sudo apt-get install -y default-jdk"},
+			{Requires: "dnf", Command: "sudo dnf install -y java-21-openjdk-devel"},
+			{Requires: "pacman", Command: "sudo pacman -S --needed jdk-openjdk"},
+		},
+		"darwin":  {{Requires: "brew", Command: "brew install openjdk"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=Microsoft.OpenJDK.21 -e"}},
+	},
+}
+
+var gradleTool = Tool{
+	Name: "gradle",
+	Installers: map[string][]Installer{
+		"linux":  {{Requires: "pacman", Command: "sudo pacman -S --needed gradle"}, {Requires: "snap", Command: "sudo snap install gradle --classic"}},
+		"darwin": {{Requires: "brew", Command: "brew install gradle"}},
+	},
+}
+
+// JVM languages, which are also their environment types
+const (
+	Java   = "java"
+	Kotlin = "kotlin"
+)
+
+// pomFile marks Java projects built with Maven instead of Gradle
+const pomFile = "pom.xml"
+
+// JVMEnvironment represents a Java or Kotlin application built with Gradle, or a
+// Java one built with Maven when only Maven is installed
+type JVMEnvironment struct {
+	// Language is java or kotlin
+	Language string
+}
+
+// useMaven checks if the project is built with Maven, because Gradle is missing
+func (p JVMEnvironment) useMaven() bool {
+	return p.Language == Java && CommandsExist("gradle") != nil && CommandsExist("mvn") == nil
+}
+
+// Ready checks if a JDK is installed with Gradle, or Maven for java
+func (p JVMEnvironment) Ready() error {
+	if err := CommandsExist("java", "javac"); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	if p.useMaven() {
+		return nil
+	}
+	if err := CommandsExist("gradle"); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	return nil
+}
+
+// javaPackage turns the name of an environment into a Java package name, which
+// must be a lowercase identifier
+func javaPackage(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	pkg := b.String()
+	if pkg == "" || unicode.IsDigit(rune(pkg[0])) {
+		pkg = "scratch" + pkg
+	}
+	return pkg
+}
+
+// mavenFiles are the files of a minimal Maven project for package pkg
+func mavenFiles(pkg string) map[string]string {
+	return map[string]string{
+		pomFile: fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0"
+         xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+         xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 https://maven.apache.org/xsd/maven-4.0.0.xsd">
+  <modelVersion>4.0.0</modelVersion>
+
+  <groupId>scratch</groupId>
+  <artifactId>%[1]s</artifactId>
+  <version>0.1.0</version>
+
+  <properties>
+    <maven.compiler.release>17</maven.compiler.release>
+    <project.build.sourceEncoding>UTF-8</project.build.sourceEncoding>
+    <exec.mainClass>%[1]s.App</exec.mainClass>
+  </properties>
+</project>
+`, pkg),
+		filepath.Join("src", "main", "java", pkg, "App.java"): fmt.Sprintf(`package %s;
+
+public class App {
+    public static void main(String[] args) {
+        System.out.println("Hello from Java " + Runtime.version());
+    }
+}
+`, pkg),
+	}
+}
+
+// Provision creates the environment at provided directory
+func (p JVMEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	pkg := javaPackage(filepath.Base(dir))
+	if p.useMaven() {
+		if err := EnsureDirectory(filepath.Join(dir, "src", "main", "java", pkg)); err != nil {
+			return err
+		}
+		if err := writeMissingFiles(dir, mavenFiles(pkg)); err != nil {
+			return err
+		}
+	} else {
+		err := RunCommand(dir, "gradle", "init", "--type", p.Language+"-application", "--dsl", "kotlin",
+			"--project-name", filepath.Base(dir), "--package", pkg, "--no-daemon", "--console", "plain")
+		if err != nil {
+			return fmt.Errorf("gradle init: %w", err)
+		}
+	}
+
+	slog.Debug("Provisioned jvm environment", slog.String("language", p.Language), slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes projects with sources of the language, and builds either language could use
+func (p JVMEnvironment) Detect(dir string) float64 {
+	sources := filepath.Join("src", "main", p.Language)
+	scores := map[string]float64{
+		sources:                       StrongMatch,
+		filepath.Join("app", sources): StrongMatch,
+		"build.gradle.kts":            WeakMatch,
+		"settings.gradle.kts":         WeakMatch,
+	}
+	if p.Language == Java {
+		scores[pomFile] = LikelyMatch
+		scores["build.gradle"] = WeakMatch
+	}
+	return detectFiles(dir, scores)
+}
+
+// Describe summarizes java and kotlin environments
+func (p JVMEnvironment) Describe() Description {
+	summary := "A Kotlin application built with Gradle"
+	if p.Language == Java {
+		summary = "A Java application built with Gradle, or Maven when Gradle is missing"
+	}
+	return Description{Summary: summary, Examples: []string{fmt.Sprintf("scratch new app -t %s", p.Language)}}
+}
+
+// Tools returns the JDK, and Gradle unless the project is built with Maven
+func (p JVMEnvironment) Tools() []Tool {
+	if p.useMaven() {
+		return []Tool{jdkTool}
+	}
+	return []Tool{jdkTool, gradleTool}
+}
+
+// Check verifies the build configuration exists
+func (p JVMEnvironment) Check(dir string) error {
+	if FilesExist(dir, pomFile) == nil {
+		return nil
+	}
+	return FilesExist(dir, "settings.gradle.kts")
+}
+
+// Ignore returns the build output and caches of Gradle and Maven
+func (p JVMEnvironment) Ignore() []string {
+	return []string{"build", ".gradle", "target"}
+}
+
+// Rebuild downloads dependencies and compiles the project again
+func (p JVMEnvironment) Rebuild(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, pomFile)); err == nil {
+		if err := RunCommand(dir, "mvn", "--batch-mode", "--quiet", "compile"); err != nil {
+			return fmt.Errorf("mvn compile: %w", err)
+		}
+		return nil
+	}
+	if err := RunCommand(dir, "gradle", "assemble", "--no-daemon", "--console", "plain"); err != nil {
+		return fmt.Errorf("gradle assemble: %w", err)
+	}
+	return nil
+}
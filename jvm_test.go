@@ -0,0 +1,72 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGradle records its arguments and writes the files gradle init would for a java application
+const fakeGradle = `#!/bin/sh
+echo "$@" > gradle.args
+/bin/mkdir -p app/src/main/java
+: > settings.gradle.kts
+`
+
+// fakeBin returns a directory with executables named like tools writing script,
+// to be used as the only entry of PATH
+func fakeBin(t *testing.T, tools map[string]string) string {
+	t.Helper()
+	bin := t.TempDir()
+	for name, script := range tools {
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte(script), 0755))
+	}
+	return bin
+}
+
+func TestJVMEnvironment_Provision(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("tools are faked with shell scripts")
+	}
+	noop := "#!/bin/sh\n"
+
+	t.Run("gradle", func(t *testing.T) {
+		t.Setenv("PATH", fakeBin(t, map[string]string{"java": noop, "javac": noop, "gradle": fakeGradle}))
+		dir := filepath.Join(t.TempDir(), "my-app")
+		p := main.JVMEnvironment{Language: main.Java}
+		require.NoError(t, p.Ready())
+		require.NoError(t, p.Provision(dir))
+		require.NoError(t, p.Check(dir))
+
+		args, err := os.ReadFile(filepath.Join(dir, "gradle.args"))
+		require.NoError(t, err)
+		require.Equal(t, "init --type java-application --dsl kotlin --project-name my-app --package myapp --no-daemon --console plain\n", string(args))
+
+		specType, err := main.DetectType(dir)
+		require.NoError(t, err)
+		require.Equal(t, main.JavaSpec, specType)
+	})
+
+	t.Run("maven", func(t *testing.T) {
+		t.Setenv("PATH", fakeBin(t, map[string]string{"java": noop, "javac": noop, "mvn": noop}))
+		dir := filepath.Join(t.TempDir(), "2d")
+		p := main.JVMEnvironment{Language: main.Java}
+		require.NoError(t, p.Ready())
+		require.NoError(t, p.Provision(dir))
+		require.NoError(t, p.Check(dir))
+		require.FileExists(t, filepath.Join(dir, "src", "main", "java", "scratch2d", "App.java"))
+
+		specType, err := main.DetectType(dir)
+		require.NoError(t, err)
+		require.Equal(t, main.JavaSpec, specType)
+	})
+
+	t.Run("kotlin needs gradle", func(t *testing.T) {
+		t.Setenv("PATH", fakeBin(t, map[string]string{"java": noop, "javac": noop, "mvn": noop}))
+		require.ErrorContains(t, main.JVMEnvironment{Language: main.Kotlin}.Ready(), "gradle")
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringService and keyringAccount identify the key of the store in the OS keyring
+const (
+	keyringService = AppName
+	keyringAccount = "store"
+)
+
+// keyringSet saves secret in the OS keyring with security on macOS or secret-tool elsewhere
+func keyringSet(secret string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// Arguments are visible to every user in the process list, so the command
+		// is passed to the interactive mode of security on stdin instead
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n", keyringService, keyringAccount, secret))
+	case "windows", "plan9":
+		return fmt.Errorf("no OS keyring on %s, use a passphrase instead", runtime.GOOS)
+	default:
+		cmd = exec.Command("secret-tool", "store", "--label", "scratch store key", "service", keyringService, "account", keyringAccount)
+		cmd.Stdin = strings.NewReader(secret)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("save key in keyring: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// keyringGet reads the secret saved by keyringSet
+func keyringGet() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	case "windows", "plan9":
+		return "", fmt.Errorf("no OS keyring on %s", runtime.GOOS)
+	default:
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("read key from keyring: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
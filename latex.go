@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+var latexTool = Tool{
+	Name: "latexmk",
+	Installers: map[string][]Installer{
+		"linux": {
+			{Requires: "apt-get", Command: "sudo apt-get install -y texlive-latex-recommended latexmk"},
+			{Requires: "dnf", Command: "sudo dnf install -y texlive-scheme-basic latexmk"},
+			{Requires: "pacman", Command: "sudo pacman -S --needed texlive-basic texlive-latexrecommended texlive-binextra"},
+		},
+		"darwin":  {{Requires: "brew", Command: "brew install --cask mactex-no-gui"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=MiKTeX.MiKTeX -e"}},
+	},
+}
+
+// latexFiles are written to new LaTeX environments
+var latexFiles = map[string]string{
+	"main.tex": `\documentclass{article}
+\usepackage[utf8]{inputenc}
+
+\title{Scratch}
+\author{}
+\date{\today}
+
+\begin{document}
+\maketitle
+
+\end{document}
+`,
+	"latexmkrc": `$pdf_mode = 1;
+$out_dir = 'build';
+@default_files = ('main.tex');
+`,
+	"Makefile": `.PHONY: all watch clean
+
+all:
+	latexmk
+
+watch:
+	latexmk -pvc
+
+clean:
+	latexmk -C
+`,
+}
+
+// LatexEnvironment represents a LaTeX document to be created
+type LatexEnvironment struct{}
+
+// Ready checks if a TeX distribution with latexmk is installed
+func (p LatexEnvironment) Ready() error {
+	if err := CommandsExist("latexmk", "pdflatex"); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	return nil
+}
+
+// Provision creates the environment at provided directory
+func (p LatexEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	if err := writeMissingFiles(dir, latexFiles); err != nil {
+		return err
+	}
+
+	slog.Debug("Provisioned latex environment", slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes documents built with latexmk, and likely any with TeX sources
+func (p LatexEnvironment) Detect(dir string) float64 {
+	confidence := detectFiles(dir, map[string]float64{
+		"latexmkrc":  StrongMatch,
+		".latexmkrc": StrongMatch,
+	})
+	return max(confidence, detectGlob(dir, "*.tex", LikelyMatch))
+}
+
+// Describe summarizes latex environments
+func (p LatexEnvironment) Describe() Description {
+	return Description{Summary: "A LaTeX document built with latexmk", Examples: []string{"scratch new paper -t latex"}}
+}
+
+// Tools returns latexmk, which comes with a TeX distribution
+func (p LatexEnvironment) Tools() []Tool {
+	return []Tool{latexTool}
+}
+
+// Check verifies the document and build configuration exist
+func (p LatexEnvironment) Check(dir string) error {
+	return FilesExist(dir, slices.Sorted(maps.Keys(latexFiles))...)
+}
+
+// Ignore returns the build output
+func (p LatexEnvironment) Ignore() []string {
+	return []string{"build"}
+}
+
+// Rebuild builds the document again
+func (p LatexEnvironment) Rebuild(dir string) error {
+	if err := RunCommand(dir, "latexmk"); err != nil {
+		return fmt.Errorf("latexmk: %w", err)
+	}
+	return nil
+}
+
+// Reprovision writes the scaffolded files that are missing
+func (p LatexEnvironment) Reprovision(dir string) error {
+	return writeMissingFiles(dir, latexFiles)
+}
@@ -0,0 +1,31 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatexEnvironment_Provision(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "doc")
+	p := main.LatexEnvironment{}
+	require.NoError(t, p.Provision(dir))
+	require.NoError(t, p.Check(dir))
+
+	specType, err := main.DetectType(dir)
+	require.NoError(t, err)
+	require.Equal(t, main.LatexSpec, specType)
+
+	// Reprovisioning restores missing files and keeps edited ones
+	tex := filepath.Join(dir, "main.tex")
+	require.NoError(t, os.WriteFile(tex, []byte("edited"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "Makefile")))
+	require.NoError(t, p.Reprovision(dir))
+	require.NoError(t, p.Check(dir))
+	data, err := os.ReadFile(tex)
+	require.NoError(t, err)
+	require.Equal(t, "edited", string(data))
+}
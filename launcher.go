@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// Launchers like Alfred and Raycast build their results from environments listed
+// as JSON, opening the path of the item picked.
+const (
+	TableFormat   = "table"
+	AlfredFormat  = "alfred"
+	RaycastFormat = "raycast"
+)
+
+// launcherSubtitle describes spec under its name, with its type, age and
+// description before its location
+func launcherSubtitle(spec Spec, now time.Time) string {
+	parts := []string{string(spec.Type)}
+	if !spec.Created.IsZero() {
+		parts = append(parts, FormatDuration(now.Sub(spec.Created))+" old")
+	}
+	if spec.IsArchived() {
+		parts = append(parts, "archived")
+	}
+	if spec.Description != "" {
+		parts = append(parts, spec.Description)
+	}
+	return strings.Join(append(parts, spec.Location()), " · ")
+}
+
+// AlfredText is the text Alfred copies or shows in large type for an item
+type AlfredText struct {
+	Copy      string `json:"copy"`
+	LargeType string `json:"largetype"`
+}
+
+// AlfredItem is an item of an Alfred script filter
+type AlfredItem struct {
+	UID          string `json:"uid"`
+	Title        string `json:"title"`
+	Subtitle     string `json:"subtitle"`
+	Arg          string `json:"arg"`
+	Autocomplete string `json:"autocomplete"`
+	// Type file lets Alfred act on the directory like on any file
+	Type string `json:"type"`
+	// Valid is false for archived environments, which have no directory to open
+	Valid bool       `json:"valid"`
+	Text  AlfredText `json:"text"`
+}
+
+// alfredItems is the output of an Alfred script filter
+type alfredItems struct {
+	Items []AlfredItem `json:"items"`
+}
+
+// RaycastAccessory is text shown on the right of a Raycast list item
+type RaycastAccessory struct {
+	Text    string `json:"text"`
+	Tooltip string `json:"tooltip,omitempty"`
+}
+
+// RaycastItem has the props of a Raycast List.Item, with the path of the
+// environment for its actions
+type RaycastItem struct {
+	ID          string             `json:"id"`
+	Title       string             `json:"title"`
+	Subtitle    string             `json:"subtitle"`
+	Keywords    []string           `json:"keywords,omitempty"`
+	Accessories []RaycastAccessory `json:"accessories,omitempty"`
+	Path        string             `json:"path"`
+	Archived    bool               `json:"archived,omitempty"`
+}
+
+// WriteLauncherItems writes specs as the JSON the launcher of format reads
+func WriteLauncherItems(w io.Writer, format string, specs []Spec, now time.Time) error {
+	var v any
+	switch format {
+	case AlfredFormat:
+		items := []AlfredItem{}
+		for _, spec := range specs {
+			items = append(items, AlfredItem{
+				UID:          spec.UID,
+				Title:        spec.Name,
+				Subtitle:     launcherSubtitle(spec, now),
+				Arg:          spec.Location(),
+				Autocomplete: spec.Name,
+				Type:         "file",
+				Valid:        !spec.IsArchived(),
+				Text:         AlfredText{Copy: spec.Location(), LargeType: spec.Name},
+			})
+		}
+		v = alfredItems{Items: items}
+	case RaycastFormat:
+		items := []RaycastItem{}
+		for _, spec := range specs {
+			item := RaycastItem{
+				ID:          spec.UID,
+				Title:       spec.Name,
+				Subtitle:    spec.Description,
+				Keywords:    append([]string{string(spec.Type)}, spec.Tags...),
+				Accessories: []RaycastAccessory{{Text: string(spec.Type)}},
+				Path:        spec.Location(),
+				Archived:    spec.IsArchived(),
+			}
+			if !spec.Created.IsZero() {
+				item.Accessories = append(item.Accessories, RaycastAccessory{
+					Text:    FormatDuration(now.Sub(spec.Created)),
+					Tooltip: spec.Created.Local().Format(time.DateTime),
+				})
+			}
+			items = append(items, item)
+		}
+		v = items
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
@@ -0,0 +1,49 @@
+package main_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLauncherItems(t *testing.T) {
+	now := time.Now()
+	spec := main.NewSpec("launched", main.PythonSpec, "/tmp")
+	spec.Created = now.Add(-2 * time.Hour)
+	spec.Description = "demo"
+	archived := main.NewSpec("old", main.DataSpec, "/tmp")
+	archived.Archive = "/tmp/old.tar.gz"
+	specs := []main.Spec{spec, archived}
+
+	var out bytes.Buffer
+	require.NoError(t, main.WriteLauncherItems(&out, main.AlfredFormat, specs, now))
+	var alfred struct{ Items []main.AlfredItem }
+	require.NoError(t, json.Unmarshal(out.Bytes(), &alfred))
+	require.Len(t, alfred.Items, 2)
+	require.Equal(t, main.AlfredItem{
+		UID:          spec.UID,
+		Title:        "launched",
+		Subtitle:     "python · 2h old · demo · /tmp/launched",
+		Arg:          "/tmp/launched",
+		Autocomplete: "launched",
+		Type:         "file",
+		Valid:        true,
+		Text:         main.AlfredText{Copy: "/tmp/launched", LargeType: "launched"},
+	}, alfred.Items[0])
+	require.False(t, alfred.Items[1].Valid)
+	require.Equal(t, "/tmp/old.tar.gz", alfred.Items[1].Arg)
+
+	out.Reset()
+	require.NoError(t, main.WriteLauncherItems(&out, main.RaycastFormat, specs, now))
+	var raycast []main.RaycastItem
+	require.NoError(t, json.Unmarshal(out.Bytes(), &raycast))
+	require.Len(t, raycast, 2)
+	require.Equal(t, "/tmp/launched", raycast[0].Path)
+	require.Equal(t, "demo", raycast[0].Subtitle)
+	require.Len(t, raycast[0].Accessories, 2)
+	require.True(t, raycast[1].Archived)
+}
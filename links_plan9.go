@@ -0,0 +1,13 @@
+package main
+
+import "io/fs"
+
+// linkCount is not available on plan9 which has no hard links
+func linkCount(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// fileID is not available from os.FileInfo on plan9 so hard links are counted separately
+func fileID(info fs.FileInfo) (any, bool) {
+	return nil, false
+}
@@ -0,0 +1,26 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// linkCount returns the number of hard links to a file
+func linkCount(info fs.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Nlink), true
+}
+
+// fileID returns a key identifying the file across hard links
+func fileID(info fs.FileInfo) (any, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, false
+	}
+	return [2]uint64{uint64(st.Dev), uint64(st.Ino)}, true
+}
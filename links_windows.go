@@ -0,0 +1,13 @@
+package main
+
+import "io/fs"
+
+// linkCount is not available from os.FileInfo on Windows
+func linkCount(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// fileID is not available from os.FileInfo on Windows so hard links are counted separately
+func fileID(info fs.FileInfo) (any, bool) {
+	return nil, false
+}
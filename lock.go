@@ -0,0 +1,68 @@
+package main
+
+import "fmt"
+
+// CheckUnlocked returns ErrProtected if the environment is locked against deletion
+func (s Spec) CheckUnlocked() error {
+	if s.Locked {
+		return fmt.Errorf("%s is locked, unlock it with 'scratch unlock %s' or pass --include-locked: %w", s.ID(), s.Name, ErrProtected)
+	}
+	return nil
+}
+
+// withoutLocked returns the specs that are not locked, telling how many were skipped
+func withoutLocked(specs []Spec, action string) []Spec {
+	unlocked := []Spec{}
+	for _, spec := range specs {
+		if !spec.Locked {
+			unlocked = append(unlocked, spec)
+		}
+	}
+	if skipped := len(specs) - len(unlocked); skipped > 0 {
+		output.Info("Skipping %d locked environments, pass --include-locked to %s them", skipped, action)
+	}
+	return unlocked
+}
+
+// LockCmd represents the command to protect an environment from deletion
+type LockCmd struct {
+	IdentifyFlags
+}
+
+// Run marks the environment locked
+func (l LockCmd) Run(ctx *CLIContext) error {
+	return setLocked(ctx, l.IdentifyFlags, true)
+}
+
+// UnlockCmd represents the command to remove the protection of an environment
+type UnlockCmd struct {
+	IdentifyFlags
+}
+
+// Run marks the environment unlocked
+func (u UnlockCmd) Run(ctx *CLIContext) error {
+	return setLocked(ctx, u.IdentifyFlags, false)
+}
+
+// setLocked locks or unlocks the environment identified by flags
+func setLocked(ctx *CLIContext, flags IdentifyFlags, locked bool) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	spec, err := flags.Resolve(store)
+	if err != nil {
+		return err
+	}
+
+	spec.Locked = locked
+	if err := spec.Save(store); err != nil {
+		return err
+	}
+	if locked {
+		output.Success("Locked %s, delete and prune skip it unless --include-locked is passed", spec.ID())
+	} else {
+		output.Success("Unlocked %s", spec.ID())
+	}
+	return nil
+}
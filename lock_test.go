@@ -0,0 +1,31 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpec_CheckUnlocked(t *testing.T) {
+	spec := main.Spec{Name: "reference", Type: main.PythonSpec}
+	require.NoError(t, spec.CheckUnlocked())
+
+	spec.Locked = true
+	err := spec.CheckUnlocked()
+	require.ErrorIs(t, err, main.ErrProtected)
+	require.Contains(t, err.Error(), "scratch unlock reference")
+}
+
+func TestSpec_LockedRoundTrip(t *testing.T) {
+	mw := NewMemoryStore()
+	spec := main.NewSpec("reference", main.PythonSpec, t.TempDir())
+	spec.Locked = true
+	require.NoError(t, spec.Save(mw))
+
+	specs, err := main.LoadSpecs(mw)
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+	require.True(t, specs[0].Locked)
+	require.Contains(t, specs[0].Details(), "Locked:")
+}
@@ -1,23 +1,52 @@
 package main
 
 import (
-	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 )
 
 func main() {
-	ctx := kong.Parse(&CLI)
-	cliCtx := &CLIContext{}
+	start := time.Now()
+	ctx := kong.Parse(&CLI, kong.Vars{"type_options": "Options by type: " + TypeOptionsHelp()})
+	cliCtx := &CLIContext{backend: CLI.Store}
 	ctx.Bind(cliCtx)
 
-	if CLI.Verbose {
-		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		})))
+	SetupLogging(CLI.Verbose, CLI.Quiet, CLI.LogFormat)
+	if CLI.Profile {
+		profile = &Profile{}
 	}
 
-	err := ctx.Run()
-	ctx.FatalIfErrorf(err)
+	stop := timePhase("load config")
+	config, err := cliCtx.Config()
+	stop()
+	// The config commands report what is wrong with the config instead
+	if !strings.HasPrefix(ctx.Command(), "config ") {
+		fatalIfErrorf(ctx, err)
+	}
+	fatalIfErrorf(ctx, UseWorkspace(config, CLI.Workspace))
+	fatalIfErrorf(ctx, UseNotifications(config))
+
+	err = ctx.Run()
+	if cerr := cliCtx.Close(); err == nil {
+		err = cerr
+	}
+	if profile != nil {
+		profile.Write(os.Stderr, time.Since(start))
+	}
+	fatalIfErrorf(ctx, err)
+}
+
+// fatalIfErrorf terminates like kong's FatalIfErrorf when err is not nil, writing
+// the hints of err below the error
+func fatalIfErrorf(ctx *kong.Context, err error) {
+	if len(ErrorHints(err)) == 0 {
+		ctx.FatalIfErrorf(err)
+		return
+	}
+	ctx.Errorf("%s", err)
+	WriteHints(ctx.Stderr, err, useColor(os.Stderr))
+	ctx.Exit(ExitCode(err))
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// Maintainer is a store backed by database files that grow over time and can be
+// compacted and checked
+type Maintainer interface {
+	// Compact rewrites the database, dropping deleted and overwritten keys
+	Compact() error
+	// Stats reports the files of the database
+	Stats() StoreStats
+	// Verify reads every entry, checking the checksums and ordering of the files,
+	// and returns how many entries were checked
+	Verify() (int, error)
+}
+
+// StoreStats describes the files of the database on disk
+type StoreStats struct {
+	// Size includes tables, write-ahead logs and files waiting to be removed
+	Size ByteSize `json:"size"`
+	// Tables counts the sorted tables in each level, from level 0
+	Tables []int `json:"tables"`
+	// TableSize is the size of the sorted tables
+	TableSize ByteSize `json:"table_size"`
+	WALFiles  int      `json:"wal_files"`
+	WALSize   ByteSize `json:"wal_size"`
+}
+
+// TotalTables counts the sorted tables in every level
+func (s StoreStats) TotalTables() int {
+	total := 0
+	for _, n := range s.Tables {
+		total += n
+	}
+	return total
+}
+
+// Print writes the statistics for people
+func (s StoreStats) Print() {
+	levels := []string{}
+	for level, n := range s.Tables {
+		if n > 0 {
+			levels = append(levels, fmt.Sprintf("L%d %d", level, n))
+		}
+	}
+	tables := fmt.Sprintf("%d, %s", s.TotalTables(), s.TableSize)
+	if len(levels) > 0 {
+		tables += fmt.Sprintf(" (%s)", strings.Join(levels, ", "))
+	}
+
+	fmt.Printf("Size    %s\n", s.Size)
+	fmt.Printf("Tables  %s\n", tables)
+	fmt.Printf("WAL     %d files, %s\n", s.WALFiles, s.WALSize)
+}
+
+// Compact compacts every key in the database
+func (p *PebbleStore) Compact() error {
+	iter, err := p.db.NewIter(nil)
+	if err != nil {
+		return fmt.Errorf("compact store: %w", err)
+	}
+	var start, end []byte
+	if iter.First() {
+		start = append(start, iter.Key()...)
+		iter.Last()
+		// The end of the range is exclusive
+		end = append(append(end, iter.Key()...), 0)
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("compact store: %w", err)
+	}
+	if start == nil {
+		return nil
+	}
+
+	if err := p.db.Compact(start, end, true); err != nil {
+		return fmt.Errorf("compact store: %w", err)
+	}
+	return nil
+}
+
+// Stats reports the tables and write-ahead logs of the database
+func (p *PebbleStore) Stats() StoreStats {
+	metrics := p.db.Metrics()
+	stats := StoreStats{
+		Size:     ByteSize(metrics.DiskSpaceUsage()),
+		WALFiles: int(metrics.WAL.Files),
+		WALSize:  ByteSize(metrics.WAL.PhysicalSize),
+	}
+	for _, level := range metrics.Levels {
+		stats.Tables = append(stats.Tables, int(level.NumFiles))
+		stats.TableSize += ByteSize(level.Size)
+	}
+	return stats
+}
+
+// Verify reads every entry of the database, which checks the checksum of each
+// block read from the tables
+func (p *PebbleStore) Verify() (int, error) {
+	stats := pebble.CheckLevelsStats{}
+	if err := p.db.CheckLevels(&stats); err != nil {
+		return 0, fmt.Errorf("verify store: %w: %w", ErrStore, err)
+	}
+	return int(stats.NumPoints), nil
+}
+
+// maintainer returns the store of ctx if its backend has database files to maintain
+func maintainer(ctx *CLIContext) (Maintainer, error) {
+	store, err := rootStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := store.(*RemoteStore); ok {
+		return nil, fmt.Errorf("the daemon holds the store, stop it to maintain the database")
+	}
+	m, ok := store.(Maintainer)
+	if !ok {
+		backend, err := ctx.Backend()
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("the %s store backend is a single file with nothing to maintain", backend)
+	}
+	return m, nil
+}
+
+// StoreCmd represents the commands to maintain the database of the store
+type StoreCmd struct {
+	Compact StoreCompactCmd `cmd:"" help:"Compact the database, reclaiming space from deleted and overwritten keys"`
+	Stats   StoreStatsCmd   `cmd:"" help:"Show the size and files of the database and verify its checksums"`
+	Encrypt StoreEncryptCmd `cmd:"" help:"Encrypt environments with a key from the OS keyring or a passphrase"`
+	Decrypt StoreDecryptCmd `cmd:"" help:"Store environments unencrypted again"`
+}
+
+// StoreCompactCmd represents the command to compact the database
+type StoreCompactCmd struct{}
+
+// Run compacts the database, reporting how much space was reclaimed
+func (s StoreCompactCmd) Run(ctx *CLIContext) error {
+	m, err := maintainer(ctx)
+	if err != nil {
+		return err
+	}
+
+	before := m.Stats()
+	if err := m.Compact(); err != nil {
+		return err
+	}
+	after := m.Stats()
+	// Replaced tables are removed in the background, so only live tables are compared
+	output.Success("Compacted store from %d tables of %s to %d tables of %s", before.TotalTables(), before.TableSize, after.TotalTables(), after.TableSize)
+	return nil
+}
+
+// StoreStatsCmd represents the command to show statistics about the database
+type StoreStatsCmd struct {
+	JSON bool `help:"Print statistics as JSON"`
+}
+
+// Run reports the files of the database and verifies them
+func (s StoreStatsCmd) Run(ctx *CLIContext) error {
+	m, err := maintainer(ctx)
+	if err != nil {
+		return err
+	}
+
+	stats := m.Stats()
+	if s.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", " ")
+		if err := enc.Encode(stats); err != nil {
+			return err
+		}
+	} else {
+		stats.Print()
+	}
+
+	entries, err := m.Verify()
+	if err != nil {
+		return err
+	}
+	output.Success("Verified checksums of %d entries", entries)
+	return nil
+}
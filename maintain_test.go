@@ -0,0 +1,40 @@
+package main_test
+
+import (
+	"fmt"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPebbleStore_Maintain(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store, err := main.NewPebbleStore()
+	require.NoError(t, err)
+	defer store.Close()
+
+	// Compacting an empty store does nothing
+	require.NoError(t, store.Compact())
+
+	for i := range 10 {
+		require.NoError(t, store.Put(fmt.Sprintf("python:%d", i), []byte(`{}`)))
+	}
+	for i := range 5 {
+		require.NoError(t, store.Delete(fmt.Sprintf("python:%d", i)))
+	}
+	require.NoError(t, store.Compact())
+
+	stats := store.Stats()
+	require.Equal(t, 1, stats.TotalTables())
+	require.NotZero(t, stats.Size)
+
+	entries, err := store.Verify()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, entries, 5)
+}
+
+func TestStoreStats_TotalTables(t *testing.T) {
+	stats := main.StoreStats{Tables: []int{2, 0, 0, 0, 0, 1, 3}}
+	require.Equal(t, 6, stats.TotalTables())
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+)
+
+// manifestKeyPrefix prefixes the store keys of the hashes of scaffolded files
+const manifestKeyPrefix = "files/"
+
+// manifestIgnore are never hashed as tools, or scratch for its marker, change them on their own
+var manifestIgnore = []string{".git", markerFile}
+
+// Manifest maps the slash separated paths of files in an environment to their SHA-256 hashes
+type Manifest map[string]string
+
+// HashFiles hashes the regular files under dir. Entries whose base name matches an
+// ignore pattern are skipped, like dependencies that are recreated.
+func HashFiles(dir string, ignore ...string) (Manifest, error) {
+	ignore = slices.Concat(ignore, manifestIgnore)
+	manifest := Manifest{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && matchesAny(d.Name(), ignore) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		manifest[filepath.ToSlash(rel)] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hash files of %s: %w", dir, err)
+	}
+	return manifest, nil
+}
+
+// ignoredFiles returns the patterns of files p recreates, which are not hashed
+func ignoredFiles(p Provisioner) []string {
+	if ignorer, ok := p.(Ignorer); ok {
+		return ignorer.Ignore()
+	}
+	return nil
+}
+
+// manifestKey returns the store key of the hashes of files scaffolded in the environment
+func (s Spec) manifestKey() string {
+	return manifestKeyPrefix + s.UID
+}
+
+// SaveManifest stores the hashes of files scaffolded in spec
+func SaveManifest(store Writer, spec Spec, manifest Manifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("marshal file hashes: %w", err)
+	}
+	if err := store.Put(spec.manifestKey(), data); err != nil {
+		return fmt.Errorf("save file hashes: %w", err)
+	}
+	return nil
+}
+
+// LoadManifest loads the hashes of files scaffolded in spec
+func LoadManifest(store Reader, spec Spec) (Manifest, error) {
+	data, err := store.Get(spec.manifestKey())
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("no file hashes for %s: %w", spec.ID(), ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("unmarshal file hashes: %w", err)
+	}
+	return manifest, nil
+}
+
+// ContentReport compares the files of an environment with the ones scaffolded
+type ContentReport struct {
+	// Pristine files are unchanged since the environment was created
+	Pristine []string
+	Modified []string
+	Deleted  []string
+	// Added files were created after the environment was
+	Added []string
+}
+
+// Untouched checks if nothing was changed since the environment was created, so
+// deleting it loses no work
+func (r ContentReport) Untouched() bool {
+	return len(r.Modified) == 0 && len(r.Deleted) == 0 && len(r.Added) == 0
+}
+
+// CompareContent compares the files under dir with manifest, skipping ignored
+// entries like HashFiles
+func CompareContent(dir string, manifest Manifest, ignore ...string) (ContentReport, error) {
+	current, err := HashFiles(dir, ignore...)
+	if err != nil {
+		return ContentReport{}, err
+	}
+
+	report := ContentReport{}
+	for _, path := range slices.Sorted(maps.Keys(manifest)) {
+		hash, ok := current[path]
+		switch {
+		case !ok:
+			report.Deleted = append(report.Deleted, path)
+		case hash != manifest[path]:
+			report.Modified = append(report.Modified, path)
+		default:
+			report.Pristine = append(report.Pristine, path)
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(current)) {
+		if _, ok := manifest[path]; !ok {
+			report.Added = append(report.Added, path)
+		}
+	}
+	return report, nil
+}
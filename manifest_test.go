@@ -0,0 +1,71 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareContent(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.py":               "print('hi')",
+		"README.md":             "# test",
+		"pyproject.toml":        "[project]",
+		".venv/lib/site.py":     "ignored",
+		".git/HEAD":             "ref: refs/heads/main",
+		"src/pkg/__init__.py":   "",
+		"src/pkg/__pycache__/x": "ignored",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	ignore := main.PythonEnvironment{}.Ignore()
+	manifest, err := main.HashFiles(dir, ignore...)
+	require.NoError(t, err)
+	require.Len(t, manifest, 4)
+	require.Contains(t, manifest, "src/pkg/__init__.py")
+
+	report, err := main.CompareContent(dir, manifest, ignore...)
+	require.NoError(t, err)
+	require.True(t, report.Untouched())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.py"), []byte("print('bye')"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "README.md")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644))
+
+	report, err = main.CompareContent(dir, manifest, ignore...)
+	require.NoError(t, err)
+	require.False(t, report.Untouched())
+	require.Equal(t, []string{"pyproject.toml", "src/pkg/__init__.py"}, report.Pristine)
+	require.Equal(t, []string{"main.py"}, report.Modified)
+	require.Equal(t, []string{"README.md"}, report.Deleted)
+	require.Equal(t, []string{"notes.txt"}, report.Added)
+}
+
+func TestManifest_SaveLoad(t *testing.T) {
+	mw := NewMemoryStore()
+	spec := main.NewSpec("test", main.PythonSpec, t.TempDir())
+	require.NoError(t, spec.Save(mw))
+
+	_, err := main.LoadManifest(mw, spec)
+	require.ErrorIs(t, err, main.ErrNotFound)
+
+	manifest := main.Manifest{"main.py": "abc"}
+	require.NoError(t, main.SaveManifest(mw, spec, manifest))
+	loaded, err := main.LoadManifest(mw, spec)
+	require.NoError(t, err)
+	require.Equal(t, manifest, loaded)
+
+	specs, err := main.LoadSpecs(mw)
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+
+	require.NoError(t, spec.Delete(mw))
+	require.Empty(t, mw.Data)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// markerFile holds the spec of an environment in its directory, so the store can
+// be rebuilt from the directories if it is lost
+const markerFile = ".scratch.json"
+
+// WriteMarker writes spec into the marker file of its directory, leaving out what
+// only the store tracks, like disk usage and how often it was opened, and what it
+// keeps sealed when encrypted
+func WriteMarker(spec Spec) error {
+	spec = redactSealed(spec)
+	spec.Usage = nil
+	spec.LastUsed = time.Time{}
+	spec.Opens = 0
+	spec.Deleted = time.Time{}
+	spec.Trash = ""
+	spec.Archive = ""
+	data, err := json.MarshalIndent(specRecord{Schema: SpecSchema, Spec: spec}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", markerFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(spec.Path, markerFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", markerFile, err)
+	}
+	return nil
+}
+
+// ReadMarker reads the spec from the marker file in dir, at dir wherever it was
+// created, and reports false if there is no marker
+func ReadMarker(dir string) (Spec, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, markerFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return Spec{}, false, nil
+	}
+	if err != nil {
+		return Spec{}, false, fmt.Errorf("read %s: %w", markerFile, err)
+	}
+	spec, _, err := decodeSpec(data)
+	if err != nil {
+		return Spec{}, false, fmt.Errorf("%s of %s: %w", markerFile, dir, err)
+	}
+	spec.Path = dir
+	return spec, true, nil
+}
+
+// removeMarker removes the marker file from dir, so the directory is no longer
+// recognized as an environment once the store forgets it
+func removeMarker(dir string) error {
+	if err := os.Remove(filepath.Join(dir, markerFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove %s: %w", markerFile, err)
+	}
+	return nil
+}
+
+// refreshMarker rewrites the marker of spec after it changed, warning on failure
+// as the store remains the record
+func refreshMarker(spec Spec) {
+	if !spec.Exists() {
+		return
+	}
+	if err := WriteMarker(spec); err != nil {
+		output.Warn("%s", err)
+	}
+}
@@ -0,0 +1,93 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMarker(t *testing.T) {
+	spec := main.NewSpec("marked", main.DenoSpec, t.TempDir())
+	spec.Tags = []string{"demo"}
+	spec.Created = time.Now().Truncate(time.Second)
+	spec.Opens = 3
+	spec.Usage = &main.DiskUsage{Size: 1024}
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+
+	_, ok, err := main.ReadMarker(spec.Path)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, main.WriteMarker(spec))
+	moved := filepath.Join(filepath.Dir(spec.Path), "moved")
+	require.NoError(t, os.Rename(spec.Path, moved))
+	read, ok, err := main.ReadMarker(moved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, spec.UID, read.UID)
+	require.Equal(t, spec.Tags, read.Tags)
+	require.True(t, spec.Created.Equal(read.Created))
+	require.Equal(t, moved, read.Path)
+	require.Zero(t, read.Opens)
+	require.Nil(t, read.Usage)
+
+	require.NoError(t, os.WriteFile(filepath.Join(moved, ".scratch.json"), []byte("{"), 0644))
+	_, _, err = main.ReadMarker(moved)
+	require.Error(t, err)
+}
+
+func TestAdoptCmd_Marker(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	ctx := &main.CLIContext{}
+	store, err := ctx.Store()
+	require.NoError(t, err)
+
+	spec := main.NewSpec("marked", main.DenoSpec, t.TempDir())
+	spec.Tags = []string{"demo"}
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+	require.NoError(t, main.WriteMarker(spec))
+
+	require.NoError(t, main.AdoptCmd{Path: spec.Path}.Run(ctx))
+	adopted, err := main.LookupID(store, spec.UID)
+	require.NoError(t, err)
+	require.Equal(t, "marked", adopted.Name)
+	require.Equal(t, spec.Tags, adopted.Tags)
+
+	// A copy of a tracked environment is adopted under a new UID
+	copied := filepath.Join(filepath.Dir(spec.Path), "copied")
+	require.NoError(t, os.Mkdir(copied, 0755))
+	require.NoError(t, main.WriteMarker(main.Spec{UID: spec.UID, Name: "marked", Type: main.DenoSpec, Path: copied}))
+	require.NoError(t, main.AdoptCmd{Path: copied, Name: "copied"}.Run(ctx))
+	clone, err := main.ResolveQuery(store, "copied")
+	require.NoError(t, err)
+	require.NotEqual(t, spec.UID, clone.UID)
+	marker, ok, err := main.ReadMarker(copied)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, clone.UID, marker.UID)
+}
+
+func TestDoctorCmd_Marker(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	ctx := &main.CLIContext{}
+	store, err := ctx.Store()
+	require.NoError(t, err)
+
+	dataDir, err := main.DefaultDataDir()
+	require.NoError(t, err)
+	spec := main.NewSpec("lost", main.DenoSpec, dataDir)
+	require.NoError(t, os.MkdirAll(spec.Path, 0755))
+	require.NoError(t, main.WriteMarker(spec))
+
+	// Missing provisioners fail other checks, which does not stop the repair
+	main.DoctorCmd{Open: "code", Fix: true}.Run(ctx)
+	restored, err := main.LookupID(store, spec.UID)
+	require.NoError(t, err)
+	require.Equal(t, spec.Path, restored.Path)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// CPUTorchIndex serves torch wheels without CUDA, which are much smaller
+const CPUTorchIndex = "https://download.pytorch.org/whl/cpu"
+
+var (
+	mlDirs     = []string{"data", "notebooks", "checkpoints"}
+	mlPackages = []string{"jupyter", "matplotlib"}
+)
+
+// Accelerators torch can use
+const (
+	CUDA = "cuda"
+	MPS  = "mps"
+)
+
+// DetectAccelerator returns cuda when an NVIDIA driver is installed, mps on Apple
+// silicon, or "" when torch can only use the CPU
+func DetectAccelerator() string {
+	switch {
+	case CommandsExist("nvidia-smi") == nil:
+		return CUDA
+	case runtime.GOOS == "darwin" && runtime.GOARCH == "arm64":
+		return MPS
+	}
+	return ""
+}
+
+// MLEnvironment represents a Python workspace for machine learning experiments with torch
+type MLEnvironment struct {
+	DataEnvironment
+	// TorchIndex is the package index torch is installed from, PyPI when empty
+	TorchIndex string
+}
+
+// NewMLEnvironment creates an MLEnvironment installing torch from torchIndex
+func NewMLEnvironment(torchIndex string) MLEnvironment {
+	return MLEnvironment{DataEnvironment: DataEnvironment{Dirs: mlDirs, Packages: mlPackages}, TorchIndex: torchIndex}
+}
+
+// Describe summarizes ml environments
+func (p MLEnvironment) Describe() Description {
+	return Description{
+		Summary:  "A uv project with torch, jupyter and matplotlib installed for machine learning experiments",
+		Examples: []string{"scratch new mnist -t ml", "scratch new mnist -t ml --opt torch-index=" + CPUTorchIndex},
+	}
+}
+
+// Options returns the index torch is installed from
+func (p MLEnvironment) Options() []Option {
+	return []Option{{Name: "torch-index", Help: "The index torch is installed from, like " + CPUTorchIndex}}
+}
+
+// WithOptions returns the environment with the torch index of opts
+func (p MLEnvironment) WithOptions(opts map[string]string) Provisioner {
+	p.TorchIndex = cmp.Or(opts["torch-index"], p.TorchIndex)
+	return p
+}
+
+// warnAccelerator warns when torch would only use the CPU, or would be installed
+// without the CUDA support the machine has. It is not part of Ready, which doctor
+// and report call as well.
+func (p MLEnvironment) warnAccelerator() {
+	accelerator := DetectAccelerator()
+	cpuOnly := strings.TrimSuffix(p.TorchIndex, "/") == CPUTorchIndex
+	switch {
+	case accelerator == CUDA && cpuOnly:
+		output.Warn("An NVIDIA GPU was found but torch is installed from the CPU-only index %s", p.TorchIndex)
+	case accelerator != "" || cpuOnly:
+	case runtime.GOOS == "linux" && p.TorchIndex == "":
+		output.Warn("No NVIDIA GPU found, torch will only use the CPU. Pass --torch-index %s to skip downloading CUDA", CPUTorchIndex)
+	default:
+		output.Warn("No NVIDIA GPU or Apple silicon found, torch will only use the CPU")
+	}
+}
+
+// Provision creates the environment at provided directory
+func (p MLEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+	p.warnAccelerator()
+
+	if err := p.initProject(dir); err != nil {
+		return err
+	}
+	if err := p.writeLayout(dir); err != nil {
+		return err
+	}
+
+	slog.Debug("Provisioned ml environment", slog.String("dir", dir), slog.String("torch_index", p.TorchIndex))
+	return nil
+}
+
+// initProject creates a bare project and installs torch, from the torch index when
+// set, which uv records so syncing installs it from there again
+func (p MLEnvironment) initProject(dir string) error {
+	if err := p.DataEnvironment.initProject(dir); err != nil {
+		return err
+	}
+	args := []string{"add", "torch"}
+	if p.TorchIndex != "" {
+		args = append(args, "--index", "pytorch="+p.TorchIndex)
+	}
+	if err := RunCommand(dir, "uv", args...); err != nil {
+		return fmt.Errorf("uv add torch: %w", err)
+	}
+	return nil
+}
+
+// Detect recognizes Python projects depending on torch, which are more likely ml
+// than python or data environments
+func (p MLEnvironment) Detect(dir string) float64 {
+	confidence := p.PythonEnvironment.Detect(dir)
+	if confidence == NoMatch {
+		return NoMatch
+	}
+	project, err := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if err != nil || !strings.Contains(string(project), `"torch`) {
+		return confidence / 2
+	}
+	return min(confidence+0.07, 1)
+}
+
+// Reprovision initializes the project if pyproject.toml is missing, recreates the
+// virtual environment if .venv is missing and restores missing directories
+func (p MLEnvironment) Reprovision(dir string) error {
+	if FilesExist(dir, "pyproject.toml") != nil {
+		slog.Debug("Initializing missing project", slog.String("dir", dir))
+		if err := p.initProject(dir); err != nil {
+			return err
+		}
+		return p.writeLayout(dir)
+	}
+	return p.DataEnvironment.Reprovision(dir)
+}
@@ -0,0 +1,42 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUv records its arguments and writes the project file like uv init and uv add
+const fakeUv = `#!/bin/sh
+echo "$@" >> uv.log
+case "$1" in
+init) echo '[project]' > pyproject.toml; /bin/mkdir -p .venv ;;
+add) echo "dependencies = [\"$2\"]" >> pyproject.toml ;;
+esac
+`
+
+func TestMLEnvironment_Provision(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uv is faked with a shell script")
+	}
+	t.Setenv("PATH", fakeBin(t, map[string]string{"uv": fakeUv}))
+
+	dir := filepath.Join(t.TempDir(), "mnist")
+	p := main.NewMLEnvironment(main.CPUTorchIndex)
+	require.NoError(t, p.Ready())
+	require.NoError(t, p.Provision(dir))
+	require.NoError(t, p.Check(dir))
+
+	log, err := os.ReadFile(filepath.Join(dir, "uv.log"))
+	require.NoError(t, err)
+	require.Equal(t, "init --bare\nadd jupyter matplotlib\nadd torch --index pytorch=https://download.pytorch.org/whl/cpu\n", string(log))
+	require.DirExists(t, filepath.Join(dir, "checkpoints"))
+
+	specType, err := main.DetectType(dir)
+	require.NoError(t, err)
+	require.Equal(t, main.MLSpec, specType)
+}
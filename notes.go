@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// notesIndex links the notes of a notes environment
+const notesIndex = "index.md"
+
+// notePattern matches the dated notes, like 2024-05-01.md
+const notePattern = "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9].md"
+
+// NotesEnvironment represents a workspace of dated markdown notes, needing no tools
+type NotesEnvironment struct {
+	// Date is the day of the first note, today when zero
+	Date time.Time
+}
+
+// Ready always succeeds as there is nothing to install
+func (p NotesEnvironment) Ready() error {
+	return nil
+}
+
+// notesFiles returns the index and the note of the day in dir
+func (p NotesEnvironment) notesFiles(dir string) map[string]string {
+	date := p.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	day := date.Format(time.DateOnly)
+	return map[string]string{
+		notesIndex: fmt.Sprintf(`# %s
+
+Notes are named after their day, like [%s](%s.md). Attachments go into attachments/.
+
+- [%s](%s.md)
+`, filepath.Base(dir), day, day, day, day),
+		day + ".md": fmt.Sprintf("# %s\n\n", date.Format("Monday, January 2, 2006")),
+	}
+}
+
+// Provision creates the environment at provided directory
+func (p NotesEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(filepath.Join(dir, "attachments")); err != nil {
+		return err
+	}
+
+	if err := writeMissingFiles(dir, p.notesFiles(dir)); err != nil {
+		return err
+	}
+
+	slog.Debug("Provisioned notes environment", slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes dated notes, and weakly an index as other projects have markdown as well
+func (p NotesEnvironment) Detect(dir string) float64 {
+	confidence := detectFiles(dir, map[string]float64{notesIndex: WeakMatch})
+	return max(confidence, detectGlob(dir, notePattern, LikelyMatch))
+}
+
+// Describe summarizes notes environments
+func (p NotesEnvironment) Describe() Description {
+	return Description{Summary: "Dated markdown notes with an index", Examples: []string{"scratch new journal -t notes"}}
+}
+
+// Tools returns nothing, notes are written with any editor
+func (p NotesEnvironment) Tools() []Tool {
+	return nil
+}
+
+// Check verifies the index exists
+func (p NotesEnvironment) Check(dir string) error {
+	return FilesExist(dir, notesIndex)
+}
+
+// Reprovision writes the index if it is missing, without notes that were deleted
+func (p NotesEnvironment) Reprovision(dir string) error {
+	files := p.notesFiles(dir)
+	return writeMissingFiles(dir, map[string]string{notesIndex: files[notesIndex]})
+}
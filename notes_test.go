@@ -0,0 +1,39 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotesEnvironment_Provision(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "journal")
+	p := main.NotesEnvironment{Date: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)}
+	require.NoError(t, p.Ready())
+	require.NoError(t, p.Provision(dir))
+	require.NoError(t, p.Check(dir))
+	require.DirExists(t, filepath.Join(dir, "attachments"))
+
+	note, err := os.ReadFile(filepath.Join(dir, "2024-05-01.md"))
+	require.NoError(t, err)
+	require.Equal(t, "# Wednesday, May 1, 2024\n\n", string(note))
+	index, err := os.ReadFile(filepath.Join(dir, "index.md"))
+	require.NoError(t, err)
+	require.Contains(t, string(index), "# journal\n")
+	require.Contains(t, string(index), "- [2024-05-01](2024-05-01.md)\n")
+
+	specType, err := main.DetectType(dir)
+	require.NoError(t, err)
+	require.Equal(t, main.NotesSpec, specType)
+
+	// Reprovisioning restores the index but not deleted notes
+	require.NoError(t, os.Remove(filepath.Join(dir, "index.md")))
+	require.NoError(t, os.Remove(filepath.Join(dir, "2024-05-01.md")))
+	require.NoError(t, p.Reprovision(dir))
+	require.NoError(t, p.Check(dir))
+	require.NoFileExists(t, filepath.Join(dir, "2024-05-01.md"))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// notifyTimeout bounds how long a notification can hold up the command that triggered it
+const notifyTimeout = 30 * time.Second
+
+// NotifyRule runs a command or posts to a webhook when a matching event is recorded
+type NotifyRule struct {
+	// Events are the actions notified, like create, delete or expire, every action when empty
+	Events []HistoryAction `json:"events,omitempty"`
+	Types  []SpecType      `json:"types,omitempty"`
+	// MinDuration skips events of operations that took less, like quick provisions
+	MinDuration Duration `json:"min_duration,omitempty"`
+	// Command is run with the event as JSON on stdin and in SCRATCH_EVENT_* variables
+	Command []string `json:"command,omitempty"`
+	// Webhook receives the event as a JSON POST
+	Webhook string `json:"webhook,omitempty"`
+}
+
+// Matches checks if the rule notifies event
+func (r NotifyRule) Matches(event Event) bool {
+	if len(r.Events) > 0 && !slices.Contains(r.Events, event.Action) {
+		return false
+	}
+	if len(r.Types) > 0 && !slices.Contains(r.Types, event.Type) {
+		return false
+	}
+	return event.Seconds >= time.Duration(r.MinDuration).Seconds()
+}
+
+// String describes where the rule sends notifications
+func (r NotifyRule) String() string {
+	if r.Webhook != "" {
+		return r.Webhook
+	}
+	return strings.Join(r.Command, " ")
+}
+
+// notifyRules are the rules of the config, set once it is loaded
+var notifyRules []NotifyRule
+
+// UseNotifications notifies the events of this invocation with the rules of config
+func UseNotifications(config Config) error {
+	for i, rule := range config.Notify {
+		if (len(rule.Command) == 0) == (rule.Webhook == "") {
+			return fmt.Errorf("notify rule %d must have either a command or a webhook", i+1)
+		}
+	}
+	notifyRules = config.Notify
+	return nil
+}
+
+// Notify sends event to the command or webhook of rule
+func Notify(rule NotifyRule, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	if rule.Webhook != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.Webhook, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook responded with %s", resp.Status)
+		}
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, rule.Command[0], rule.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"SCRATCH_EVENT="+string(event.Action),
+		"SCRATCH_EVENT_ID="+event.ID,
+		"SCRATCH_EVENT_UID="+event.UID,
+		"SCRATCH_EVENT_TYPE="+string(event.Type),
+		"SCRATCH_EVENT_PATH="+event.Path,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s", notifyTimeout)
+		}
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// notify sends event to every matching rule, warning rather than failing the
+// operation when a notification cannot be sent
+func notify(event Event) {
+	for _, rule := range notifyRules {
+		if !rule.Matches(event) {
+			continue
+		}
+		if err := Notify(rule, event); err != nil {
+			output.Warn("Could not notify %s of %s: %s", rule, event.Action, err)
+		}
+	}
+}
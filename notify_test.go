@@ -0,0 +1,59 @@
+package main_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyRule_Matches(t *testing.T) {
+	event := main.NewEvent(main.ActionCreate, main.NewSpec("notified", main.PythonSpec, "/tmp"))
+	event.Seconds = 60
+
+	require.True(t, main.NotifyRule{}.Matches(event))
+	require.True(t, main.NotifyRule{Events: []main.HistoryAction{main.ActionCreate}, Types: []main.SpecType{main.PythonSpec}}.Matches(event))
+	require.False(t, main.NotifyRule{Events: []main.HistoryAction{main.ActionDelete}}.Matches(event))
+	require.False(t, main.NotifyRule{Types: []main.SpecType{main.DenoSpec}}.Matches(event))
+	require.True(t, main.NotifyRule{MinDuration: main.Duration(30 * time.Second)}.Matches(event))
+	require.False(t, main.NotifyRule{MinDuration: main.Duration(time.Hour)}.Matches(event))
+}
+
+func TestNotify(t *testing.T) {
+	event := main.NewEvent(main.ActionDelete, main.NewSpec("notified", main.PythonSpec, "/tmp"))
+
+	out := filepath.Join(t.TempDir(), "out")
+	rule := main.NotifyRule{Command: []string{"sh", "-c", `echo "$SCRATCH_EVENT $SCRATCH_EVENT_ID" > "$0"; cat >> "$0"`, out}}
+	require.NoError(t, main.Notify(rule, event))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "delete python:notified\n")
+	require.Contains(t, string(data), `"uid":"`+event.UID+`"`)
+
+	require.ErrorContains(t, main.Notify(main.NotifyRule{Command: []string{"sh", "-c", "echo broken; exit 1"}}, event), "broken")
+
+	received := make(chan main.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got main.Event
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &got))
+		received <- got
+	}))
+	defer server.Close()
+	require.NoError(t, main.Notify(main.NotifyRule{Webhook: server.URL}, event))
+	require.Equal(t, event.UID, (<-received).UID)
+}
+
+func TestUseNotifications(t *testing.T) {
+	require.NoError(t, main.UseNotifications(main.Config{Notify: []main.NotifyRule{{Webhook: "http://localhost"}}}))
+	require.Error(t, main.UseNotifications(main.Config{Notify: []main.NotifyRule{{}}}))
+	require.Error(t, main.UseNotifications(main.Config{Notify: []main.NotifyRule{{Webhook: "http://localhost", Command: []string{"true"}}}}))
+	require.NoError(t, main.UseNotifications(main.Config{}))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultOpener opens folders with the default program of the platform
+const DefaultOpener = "default"
+
+// Opener opens the folder of an environment
+type Opener interface {
+	// Open opens dir of the environment called name
+	Open(name string, dir string) error
+	// Program is the program the opener runs, which must be installed
+	Program() string
+	// Attaches reports if Open takes over the terminal until the user leaves
+	Attaches() bool
+}
+
+// NewOpener returns the built-in opener called program, or one running program with the folder
+func NewOpener(program string) Opener {
+	switch program {
+	case "tmux":
+		return TmuxOpener{}
+	case "zellij":
+		return ZellijOpener{}
+	}
+	return ProgramOpener(program)
+}
+
+// OpenerExists checks the program used to open folders with program is installed
+func OpenerExists(program string) error {
+	return CommandsExist(NewOpener(program).Program())
+}
+
+// ProgramOpener opens folders by passing them to a program like an editor or
+// file manager, or to the default program of the platform for DefaultOpener
+type ProgramOpener string
+
+// Open runs the program with dir
+func (p ProgramOpener) Open(name string, dir string) error {
+	cmd := OpenCommand(string(p), dir)
+	slog.Debug(fmt.Sprintf("Running %q", cmd.String()))
+	out, err := cmd.CombinedOutput()
+
+	// explorer exits with 1 even when it opened the folder
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && isExplorer(string(p)) {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("open folder: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// Program returns the program run to open folders
+func (p ProgramOpener) Program() string {
+	return openerProgram(string(p))
+}
+
+// Attaches is false as programs open folders in their own window
+func (p ProgramOpener) Attaches() bool {
+	return false
+}
+
+// isExplorer checks if program is the Windows file manager
+func isExplorer(program string) bool {
+	name := strings.ToLower(filepath.Base(program))
+	return name == "explorer" || name == "explorer.exe"
+}
+
+// invalidSessionChars matches characters multiplexers do not allow in session names
+var invalidSessionChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// SessionName returns the terminal session name of the environment called name
+func SessionName(name string) string {
+	return invalidSessionChars.ReplaceAllString(name, "-")
+}
+
+// runAttached runs cmd in the terminal of the user
+func runAttached(cmd *exec.Cmd) error {
+	slog.Debug(fmt.Sprintf("Running %q", cmd.String()))
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(cmd.Path), err)
+	}
+	return nil
+}
+
+// TmuxOpener opens environments in a tmux session named after them, creating
+// it in the folder or attaching to it if it already exists
+type TmuxOpener struct{}
+
+// Open attaches to the session of the environment, switching to it when already inside tmux
+func (t TmuxOpener) Open(name string, dir string) error {
+	session := SessionName(name)
+	if os.Getenv("TMUX") == "" {
+		return runAttached(exec.Command("tmux", "new-session", "-A", "-s", session, "-c", dir))
+	}
+
+	// Attaching from inside tmux would nest sessions, so the current client switches instead
+	if err := exec.Command("tmux", "has-session", "-t", "="+session).Run(); err != nil {
+		if out, err := exec.Command("tmux", "new-session", "-d", "-s", session, "-c", dir).CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux: %s: %w", strings.TrimSpace(string(out)), err)
+		}
+	}
+	if out, err := exec.Command("tmux", "switch-client", "-t", "="+session).CombinedOutput(); err != nil {
+		return fmt.Errorf("tmux: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// Program returns tmux
+func (t TmuxOpener) Program() string {
+	return "tmux"
+}
+
+// Attaches is true as tmux runs until the session is detached
+func (t TmuxOpener) Attaches() bool {
+	return true
+}
+
+// ZellijOpener opens environments in a zellij session named after them, creating
+// it in the folder or attaching to it if it already exists
+type ZellijOpener struct{}
+
+// Open attaches to the session of the environment
+func (z ZellijOpener) Open(name string, dir string) error {
+	if os.Getenv("ZELLIJ") != "" {
+		return fmt.Errorf("cannot open zellij session inside zellij, detach first")
+	}
+	cmd := exec.Command("zellij", "attach", "--create", SessionName(name))
+	cmd.Dir = dir
+	return runAttached(cmd)
+}
+
+// Program returns zellij
+func (z ZellijOpener) Program() string {
+	return "zellij"
+}
+
+// Attaches is true as zellij runs until the session is detached
+func (z ZellijOpener) Attaches() bool {
+	return true
+}
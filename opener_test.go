@@ -0,0 +1,59 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOpener(t *testing.T) {
+	opener := main.NewOpener("code")
+	require.Equal(t, "code", opener.Program())
+	require.False(t, opener.Attaches())
+
+	require.NotEqual(t, main.DefaultOpener, main.NewOpener(main.DefaultOpener).Program())
+
+	for _, program := range []string{"tmux", "zellij"} {
+		opener := main.NewOpener(program)
+		require.Equal(t, program, opener.Program())
+		require.True(t, opener.Attaches())
+	}
+}
+
+func TestSessionName(t *testing.T) {
+	require.Equal(t, "api-v2", main.SessionName("api.v2"))
+	require.Equal(t, "my_env", main.SessionName("my_env"))
+}
+
+func TestTmuxOpener(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("tmux is a shell script")
+	}
+	bin := t.TempDir()
+	args := filepath.Join(bin, "args")
+	script := "#!/bin/sh\necho \"$@\" >> " + args + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "tmux"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(filepath.ListSeparator)+os.Getenv("PATH"))
+	dir := t.TempDir()
+
+	t.Run("outside tmux", func(t *testing.T) {
+		t.Setenv("TMUX", "")
+		require.NoError(t, main.TmuxOpener{}.Open("api.v2", dir))
+		data, err := os.ReadFile(args)
+		require.NoError(t, err)
+		require.Equal(t, "new-session -A -s api-v2 -c "+dir+"\n", string(data))
+		require.NoError(t, os.Remove(args))
+	})
+
+	t.Run("inside tmux", func(t *testing.T) {
+		t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+		require.NoError(t, main.TmuxOpener{}.Open("api", dir))
+		data, err := os.ReadFile(args)
+		require.NoError(t, err)
+		require.Equal(t, "has-session -t =api\nswitch-client -t =api\n", string(data))
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !windows && !darwin
+
+package main
+
+import "os/exec"
+
+// OpenCommand builds the command opening dir with program, using xdg-open for the default
+func OpenCommand(program string, dir string) *exec.Cmd {
+	return exec.Command(openerProgram(program), dir)
+}
+
+// openerProgram is the program run to open folders with program
+func openerProgram(program string) string {
+	if program == DefaultOpener {
+		return "xdg-open"
+	}
+	return program
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// OpenCommand builds the command opening dir with program, using start for the default.
+// cmd.exe and explorer do not split arguments like other programs, so their command
+// lines are written out verbatim with the path quoted instead of escaped by exec.
+func OpenCommand(program string, dir string) *exec.Cmd {
+	dir = filepath.Clean(dir)
+	switch {
+	case program == DefaultOpener:
+		cmd := exec.Command("cmd")
+		cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: fmt.Sprintf(`cmd /c start "" "%s"`, dir)}
+		return cmd
+	case isExplorer(program):
+		cmd := exec.Command(program)
+		cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: fmt.Sprintf(`%s "%s"`, program, dir)}
+		return cmd
+	default:
+		return exec.Command(program, dir)
+	}
+}
+
+// openerProgram is the program run to open folders with program
+func openerProgram(program string) string {
+	if program == DefaultOpener {
+		return "cmd"
+	}
+	return program
+}
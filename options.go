@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Option is a setting of a type passed to its provisioner with --opt key=value
+type Option struct {
+	Name string
+	Help string
+	// Values lists the accepted values, any value is accepted when empty
+	Values  []string
+	Default string
+}
+
+// String describes the option for help and errors, like "framework=vanilla|react: The framework"
+func (o Option) String() string {
+	value := "<value>"
+	if len(o.Values) > 0 {
+		value = strings.Join(o.Values, "|")
+	}
+	s := fmt.Sprintf("%s=%s: %s", o.Name, value, o.Help)
+	if o.Default != "" {
+		s += fmt.Sprintf(", %s by default", o.Default)
+	}
+	return s
+}
+
+// Configurable is implemented by provisioners with settings passed with --opt
+type Configurable interface {
+	// Options lists the options the provisioner accepts
+	Options() []Option
+	// WithOptions returns the provisioner with opts applied, which were checked against Options
+	WithOptions(opts map[string]string) Provisioner
+}
+
+// ApplyOptions checks the options of an environment of specType are accepted by p and
+// returns p configured with them. Plugins are passed every option to check themselves.
+func ApplyOptions(specType SpecType, p Provisioner, opts map[string]string) (Provisioner, error) {
+	if len(opts) == 0 {
+		return p, nil
+	}
+	if plugin, ok := p.(PluginProvisioner); ok {
+		plugin.Options = opts
+		return plugin, nil
+	}
+	configurable, ok := p.(Configurable)
+	if !ok {
+		return nil, fmt.Errorf("%s environments take no options", specType)
+	}
+	options := configurable.Options()
+	for _, key := range slices.Sorted(maps.Keys(opts)) {
+		i := slices.IndexFunc(options, func(o Option) bool { return o.Name == key })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown option %q for %s environments, expected one of: %s", key, specType, describeOptions(options))
+		}
+		option := options[i]
+		if len(option.Values) > 0 && !slices.Contains(option.Values, opts[key]) {
+			return nil, fmt.Errorf("invalid option %s=%q for %s environments, expected one of %s", key, opts[key], specType, strings.Join(option.Values, ", "))
+		}
+	}
+	return configurable.WithOptions(opts), nil
+}
+
+// describeOptions lists options separated by semicolons
+func describeOptions(options []Option) string {
+	descriptions := make([]string, len(options))
+	for i, option := range options {
+		descriptions[i] = option.String()
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// TypeOptionsHelp describes the options of every built-in type that has some, for
+// the help of --opt
+func TypeOptionsHelp() string {
+	types := []string{}
+	for _, specType := range SpecTypes {
+		p, err := NewProvisioner(specType)
+		if err != nil {
+			continue
+		}
+		if configurable, ok := p.(Configurable); ok {
+			types = append(types, fmt.Sprintf("%s: %s", specType, describeOptions(configurable.Options())))
+		}
+	}
+	return strings.Join(types, ". ")
+}
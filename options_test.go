@@ -0,0 +1,38 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyOptions(t *testing.T) {
+	p, err := main.ApplyOptions(main.WebSpec, main.WebEnvironment{}, map[string]string{"framework": "vue"})
+	require.NoError(t, err)
+	require.Equal(t, main.WebEnvironment{Framework: "vue"}, p)
+
+	p, err = main.ApplyOptions(main.LatexSpec, main.LatexEnvironment{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, main.LatexEnvironment{}, p)
+
+	_, err = main.ApplyOptions(main.WebSpec, main.WebEnvironment{}, map[string]string{"framwork": "vue"})
+	require.ErrorContains(t, err, `unknown option "framwork" for web environments, expected one of: framework=vanilla|react|vue|svelte`)
+
+	_, err = main.ApplyOptions(main.WebSpec, main.WebEnvironment{}, map[string]string{"framework": "angular"})
+	require.ErrorContains(t, err, `invalid option framework="angular"`)
+
+	_, err = main.ApplyOptions(main.LatexSpec, main.LatexEnvironment{}, map[string]string{"engine": "lualatex"})
+	require.EqualError(t, err, "latex environments take no options")
+
+	// Plugins check their options themselves
+	p, err = main.ApplyOptions("rust", main.PluginProvisioner{Type: "rust"}, map[string]string{"edition": "2024"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"edition": "2024"}, p.(main.PluginProvisioner).Options)
+}
+
+func TestTypeOptionsHelp(t *testing.T) {
+	help := main.TypeOptionsHelp()
+	require.Contains(t, help, "web: framework=vanilla|react|vue|svelte")
+	require.Contains(t, help, "ml: torch-index=<value>")
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// MoveToOSTrash moves path to the Trash of the home directory, numbering its name like
+// Finder when it is taken. Put Back is not offered, as only Finder records where
+// items came from.
+func MoveToOSTrash(path string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	trash := filepath.Join(home, ".Trash")
+	base := filepath.Base(path)
+	name := base
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(filepath.Join(trash, name)); os.IsNotExist(err) {
+			break
+		}
+		name = base + " " + strconv.Itoa(i)
+	}
+	if err := MoveTree(path, filepath.Join(trash, name)); err != nil {
+		return fmt.Errorf("move %s to trash: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+//go:build !darwin && !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// MoveToOSTrash moves path to the home trash of the freedesktop.org trash specification,
+// with the .trashinfo file file managers need to restore it where it was
+func MoveToOSTrash(path string) error {
+	trash, err := xdgTrashDir()
+	if err != nil {
+		return err
+	}
+	files, info := filepath.Join(trash, "files"), filepath.Join(trash, "info")
+	for _, dir := range []string{files, info} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("create trash: %w", err)
+		}
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	name, infoPath, err := reserveTrashName(files, info, filepath.Base(abs))
+	if err != nil {
+		return err
+	}
+	// Paths are URL escaped and dates local without a time zone
+	trashInfo := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", (&url.URL{Path: abs}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	if err := os.WriteFile(infoPath, []byte(trashInfo), 0600); err != nil {
+		os.Remove(infoPath)
+		return fmt.Errorf("write trash info: %w", err)
+	}
+	// The home trash may be on another device, which the specification allows copying to
+	if err := MoveTree(abs, filepath.Join(files, name)); err != nil {
+		os.Remove(infoPath)
+		return fmt.Errorf("move %s to trash: %w", path, err)
+	}
+	return nil
+}
+
+// xdgTrashDir returns the home trash, which lives in the data directory of the user
+func xdgTrashDir() (string, error) {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "Trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
+
+// reserveTrashName finds a name free in the trash, numbering base like name.2, and
+// creates its info file exclusively so concurrent deletes cannot take the same one
+func reserveTrashName(files string, info string, base string) (string, string, error) {
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name += "." + strconv.Itoa(i)
+		}
+		if _, err := os.Lstat(filepath.Join(files, name)); err == nil {
+			continue
+		}
+		infoPath := filepath.Join(info, name+".trashinfo")
+		f, err := os.OpenFile(infoPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("write trash info: %w", err)
+		}
+		f.Close()
+		return name, infoPath, nil
+	}
+}
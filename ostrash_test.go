@@ -0,0 +1,35 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveToOSTrash(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("only the freedesktop.org trash can be redirected to a temporary directory")
+	}
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	trash := filepath.Join(data, "Trash")
+
+	for range 2 {
+		dir := filepath.Join(t.TempDir(), "my env")
+		require.NoError(t, os.Mkdir(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.py"), nil, 0644))
+		require.NoError(t, main.MoveToOSTrash(dir))
+		require.NoDirExists(t, dir)
+	}
+
+	require.FileExists(t, filepath.Join(trash, "files", "my env", "main.py"))
+	require.FileExists(t, filepath.Join(trash, "files", "my env.2", "main.py"))
+	info, err := os.ReadFile(filepath.Join(trash, "info", "my env.2.trashinfo"))
+	require.NoError(t, err)
+	require.Contains(t, string(info), "[Trash Info]\nPath=/")
+	require.Contains(t, string(info), "/my%20env\nDeletionDate=")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var procSHFileOperation = syscall.NewLazyDLL("shell32.dll").NewProc("SHFileOperationW")
+
+// Operation and flags of SHFileOperationW deleting to the Recycle Bin without dialogs
+const (
+	foDelete          = 0x3
+	fofSilent         = 0x4
+	fofNoConfirmation = 0x10
+	fofAllowUndo      = 0x40
+	fofNoErrorUI      = 0x400
+)
+
+// MoveToOSTrash moves path to the Recycle Bin, where Explorer can restore it. The shell
+// does not accept extended-length paths, so path is passed as is.
+func MoveToOSTrash(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	from, err := syscall.UTF16FromString(abs)
+	if err != nil {
+		return err
+	}
+	// pFrom is a list of paths ended by an empty one
+	from = append(from, 0)
+	op := newShFileOp(foDelete, &from[0], fofAllowUndo|fofNoConfirmation|fofSilent|fofNoErrorUI)
+	if r, _, _ := procSHFileOperation.Call(uintptr(unsafe.Pointer(&op))); r != 0 {
+		return fmt.Errorf("move %s to the Recycle Bin: SHFileOperation failed with %#x", path, r)
+	}
+	if op.aborted() {
+		return fmt.Errorf("move %s to the Recycle Bin: aborted", path)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// shFileOpStruct is SHFILEOPSTRUCTW, which 32-bit Windows packs without padding. Go
+// would align fAnyOperationsAborted after fFlags, so the fields from fFlags on are
+// kept as bytes: fFlags, fAnyOperationsAborted, hNameMappings and lpszProgressTitle.
+type shFileOpStruct struct {
+	hwnd  uintptr
+	wFunc uint32
+	pFrom *uint16
+	pTo   *uint16
+	rest  [14]byte
+}
+
+// The bytes must start where fFlags does in the struct of the shell. Go pads the
+// struct to 32 bytes, past the 30 the shell reads.
+var (
+	_ [unsafe.Offsetof(shFileOpStruct{}.rest) - 16]byte
+	_ [16 - unsafe.Offsetof(shFileOpStruct{}.rest)]byte
+)
+
+// newShFileOp returns the operation function on the paths in from with flags
+func newShFileOp(function uint32, from *uint16, flags uint16) shFileOpStruct {
+	op := shFileOpStruct{wFunc: function, pFrom: from}
+	binary.LittleEndian.PutUint16(op.rest[0:2], flags)
+	return op
+}
+
+// aborted reports if the user or the shell cancelled the operation
+func (op *shFileOpStruct) aborted() bool {
+	return binary.LittleEndian.Uint32(op.rest[2:6]) != 0
+}
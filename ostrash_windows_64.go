@@ -0,0 +1,33 @@
+//go:build windows && (amd64 || arm64)
+
+package main
+
+import "unsafe"
+
+// shFileOpStruct is SHFILEOPSTRUCTW, whose fields are naturally aligned on 64-bit Windows
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+// The struct must be as large as the one of the shell
+var (
+	_ [unsafe.Sizeof(shFileOpStruct{}) - 56]byte
+	_ [56 - unsafe.Sizeof(shFileOpStruct{})]byte
+)
+
+// newShFileOp returns the operation function on the paths in from with flags
+func newShFileOp(function uint32, from *uint16, flags uint16) shFileOpStruct {
+	return shFileOpStruct{wFunc: function, pFrom: from, fFlags: flags}
+}
+
+// aborted reports if the user or the shell cancelled the operation
+func (op *shFileOpStruct) aborted() bool {
+	return op.fAnyOperationsAborted != 0
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// ANSI colors of messages
+const (
+	colorReset  = "\x1b[0m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+// Output writes messages meant for people, as opposed to the debug logs written with slog
+type Output struct {
+	w     io.Writer
+	color bool
+	// quiet drops everything but errors
+	quiet bool
+	// logger receives messages instead of w when logs are structured
+	logger *slog.Logger
+}
+
+// NewOutput writes messages to f, colored when f is a terminal and NO_COLOR is not set
+func NewOutput(f *os.File) *Output {
+	return &Output{w: f, color: useColor(f)}
+}
+
+// NewPlainOutput writes uncolored messages to w
+func NewPlainOutput(w io.Writer) *Output {
+	return &Output{w: w}
+}
+
+// useColor checks if messages written to f should be colored, see https://no-color.org
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// Info writes a plain message
+func (o *Output) Info(format string, args ...any) {
+	o.write(slog.LevelInfo, "", "", format, args...)
+}
+
+// Success writes a message about a completed operation
+func (o *Output) Success(format string, args ...any) {
+	o.write(slog.LevelInfo, colorGreen, "✓ ", format, args...)
+}
+
+// Warn writes a message about something that needs attention
+func (o *Output) Warn(format string, args ...any) {
+	o.write(slog.LevelWarn, colorYellow, "warning: ", format, args...)
+}
+
+func (o *Output) write(level slog.Level, color string, prefix string, format string, args ...any) {
+	switch {
+	case o.quiet:
+		return
+	case o.logger != nil:
+		o.logger.Log(context.Background(), level, fmt.Sprintf(format, args...))
+		return
+	case o.color && prefix != "":
+		prefix = color + prefix + colorReset
+	}
+	fmt.Fprintf(o.w, prefix+format+"\n", args...)
+}
+
+// LogFormat is the format of logs
+type LogFormat string
+
+const (
+	LogText LogFormat = "text"
+	LogJSON LogFormat = "json"
+)
+
+// SetupLogging configures slog and output. Debug logs are enabled when verbose is
+// set and only errors are reported when quiet is set. With LogJSON, messages are
+// logged as JSON lines to stderr for scripts instead of being written for people.
+func SetupLogging(verbose bool, quiet bool, format LogFormat) {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	if quiet {
+		level = slog.LevelError
+	}
+	options := &slog.HandlerOptions{Level: level}
+
+	switch {
+	case format == LogJSON:
+		logger := slog.New(slog.NewJSONHandler(os.Stderr, options))
+		slog.SetDefault(logger)
+		output.logger = logger
+	case verbose:
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, options)))
+	default:
+		slog.SetLogLoggerLevel(level)
+	}
+	output.quiet = quiet
+}
+
+// output is where commands write messages for people
+var output = NewOutput(os.Stderr)
+
+// SetOutput makes o where messages are written, returning the previous Output
+func SetOutput(o *Output) *Output {
+	previous := output
+	output = o
+	return previous
+}
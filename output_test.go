@@ -0,0 +1,30 @@
+package main_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutput(t *testing.T) {
+	var b strings.Builder
+	out := main.NewPlainOutput(&b)
+	out.Info("plain %d", 1)
+	out.Success("done %s", "it")
+	out.Warn("careful")
+	require.Equal(t, "plain 1\n✓ done it\nwarning: careful\n", b.String())
+}
+
+func TestNewOutput_NoColor(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	require.NoError(t, err)
+	defer f.Close()
+
+	main.NewOutput(f).Success("done")
+	data, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	require.Equal(t, "✓ done\n", string(data))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandHome replaces a leading ~ in path with the home directory of the current user.
+// Shells only expand ~ at the start of a word, so --directory=~/envs reaches scratch as is.
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// CleanPath normalizes a path before it is stored, removing repeated and trailing
+// separators and using the separator of the platform. Empty paths stay empty.
+func CleanPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return filepath.Clean(path)
+}
+
+// removeTree removes path and everything under it, including paths too long for
+// the default limit on Windows
+func removeTree(path string) error {
+	return os.RemoveAll(longPath(path))
+}
+
+// EnvironmentRoots returns the directories environments can be deleted from, the data
+// directories of every workspace and the roots of config, with their symlinks resolved
+func EnvironmentRoots(config Config) ([]string, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return nil, err
+	}
+	current, err := DefaultDataDir()
+	if err != nil {
+		return nil, err
+	}
+	roots := []string{current}
+	for _, name := range config.AllWorkspaces() {
+		roots = append(roots, workspaceDirOf(dir, name))
+	}
+	for _, rule := range config.Roots {
+		root, err := ExpandHome(rule.Path)
+		if err != nil {
+			return nil, err
+		}
+		if root, err = filepath.Abs(root); err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+	for i, root := range roots {
+		roots[i] = resolveSymlinks(root)
+	}
+	return roots, nil
+}
+
+// resolveSymlinks resolves the symlinks of path, or of its longest existing parent when
+// path does not exist yet
+func resolveSymlinks(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(resolveSymlinks(parent), filepath.Base(path))
+}
+
+// CheckDeletable checks the directory at path is inside one of roots, and not a root itself,
+// once the symlinks of its parents are resolved. A symlinked parent pointing elsewhere, or
+// a path edited in the store, would otherwise remove a tree scratch never created. path
+// itself may be a symlink, as removing it removes the link and not its target.
+func CheckDeletable(path string, roots []string) error {
+	path = CleanPath(path)
+	resolved := filepath.Join(resolveSymlinks(filepath.Dir(path)), filepath.Base(path))
+	for _, root := range roots {
+		if resolved != root && pathWithin(root, resolved) {
+			return nil
+		}
+	}
+	if resolved != path {
+		return fmt.Errorf("%s resolves to %s, which is %w", path, resolved, ErrUnsafePath)
+	}
+	return fmt.Errorf("%s is %w", path, ErrUnsafePath)
+}
+
+// checkSpecDeletable checks the directory of spec can be deleted, passing environments
+// provisioned in place, whose directories are kept, and ones without a directory
+func checkSpecDeletable(spec Spec, roots []string) error {
+	if spec.InPlace || !spec.Exists() {
+		return nil
+	}
+	return CheckDeletable(spec.Path, roots)
+}
@@ -0,0 +1,66 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	for path, want := range map[string]string{
+		"~":              home,
+		"~/envs":         filepath.Join(home, "envs"),
+		"~other/envs":    "~other/envs",
+		"envs/~":         "envs/~",
+		"/tmp/~/scratch": "/tmp/~/scratch",
+	} {
+		got, err := main.ExpandHome(path)
+		require.NoError(t, err)
+		require.Equal(t, want, got, path)
+	}
+}
+
+func TestSpec_SaveCleansPaths(t *testing.T) {
+	mw := NewMemoryStore()
+	dir := t.TempDir()
+	spec := main.NewSpec("test", main.PythonSpec, dir)
+	spec.Path = dir + "//test/./"
+	spec.Parent = dir + "/repo/../repo/"
+	require.NoError(t, spec.Save(mw))
+
+	saved, err := main.LookupID(mw, spec.ID())
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "test"), saved.Path)
+	require.Equal(t, filepath.Join(dir, "repo"), saved.Parent)
+	require.Equal(t, "", main.CleanPath(""))
+}
+
+func TestCheckDeletable(t *testing.T) {
+	// Roots are resolved like EnvironmentRoots does, as temporary directories are behind symlinks on macOS
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(outside, "env"), 0755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+	roots := []string{root}
+
+	require.NoError(t, main.CheckDeletable(filepath.Join(root, "env"), roots))
+	require.NoError(t, main.CheckDeletable(filepath.Join(root, "sub", "env"), roots))
+	// A symlink inside the root is removed without following it
+	require.NoError(t, main.CheckDeletable(filepath.Join(root, "link"), roots))
+
+	require.ErrorIs(t, main.CheckDeletable(root, roots), main.ErrUnsafePath)
+	require.ErrorIs(t, main.CheckDeletable(filepath.Join(outside, "env"), roots), main.ErrUnsafePath)
+	require.ErrorIs(t, main.CheckDeletable(filepath.Join(root, "..", filepath.Base(outside), "env"), roots), main.ErrUnsafePath)
+	err = main.CheckDeletable(filepath.Join(root, "link", "env"), roots)
+	require.ErrorIs(t, err, main.ErrUnsafePath)
+	require.ErrorContains(t, err, "resolves to "+filepath.Join(outside, "env"))
+}
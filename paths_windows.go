@@ -0,0 +1,23 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// longPathPrefix lifts the MAX_PATH limit of 260 characters off absolute paths
+const longPathPrefix = `\\?\`
+
+// longPath returns path in the extended-length form, \\?\C:\dir or \\?\UNC\server\share\dir
+// for UNC shares. The os package only does so for some paths, and not for ones with . or
+// .. elements, which the prefix requires to be cleaned first.
+func longPath(path string) string {
+	if strings.HasPrefix(path, longPathPrefix) || !filepath.IsAbs(path) {
+		return path
+	}
+	path = filepath.Clean(path)
+	if strings.HasPrefix(path, `\\`) {
+		return longPathPrefix + `UNC\` + path[2:]
+	}
+	return longPathPrefix + path
+}
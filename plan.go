@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// PlannedAction is one change gc or prune would make
+type PlannedAction struct {
+	Action string `json:"action"`
+	Target string `json:"target"`
+	// UID identifies the environment for actions on environments
+	UID    string   `json:"uid,omitempty"`
+	Path   string   `json:"path"`
+	Size   ByteSize `json:"size"`
+	Reason string   `json:"reason"`
+}
+
+// PlanFlags control whether a command previews or applies its actions
+type PlanFlags struct {
+	Plan   bool   `help:"Only show the actions that would be taken (default unless --apply)"`
+	Output string `short:"o" help:"Format of the plan (text or json)" enum:"text,json" default:"text"`
+	Apply  bool   `help:"Take the planned actions"`
+}
+
+// Validate checks the plan is not both previewed and applied
+func (p PlanFlags) Validate() error {
+	if p.Plan && p.Apply {
+		return fmt.Errorf("--plan cannot be used with --apply")
+	}
+	return nil
+}
+
+// PrintPlan writes the actions in the selected format
+func (p PlanFlags) PrintPlan(actions []PlannedAction) error {
+	if p.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", " ")
+		return enc.Encode(actions)
+	}
+
+	var total ByteSize
+	for _, a := range actions {
+		total += a.Size
+		fmt.Printf("%-7s %10s  %s (%s)\n", a.Action, a.Size, a.Target, a.Reason)
+	}
+	if !p.Apply {
+		fmt.Printf("%d actions reclaiming %s, run with --apply to take them\n", len(actions), total)
+	}
+	return nil
+}
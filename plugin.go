@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// pluginPrefix is the prefix of executables on PATH that provision additional types
+const pluginPrefix = "scratch-provision-"
+
+// pluginProtocol is the version of the protocol spoken with plugins
+const pluginProtocol = 1
+
+// PluginPhase is the step a plugin is asked to perform
+type PluginPhase string
+
+const (
+	PhaseReady     PluginPhase = "ready"
+	PhaseProvision PluginPhase = "provision"
+)
+
+// PluginRequest is written as JSON to the stdin of a plugin
+type PluginRequest struct {
+	Version int         `json:"version"`
+	Phase   PluginPhase `json:"phase"`
+	Type    SpecType    `json:"type"`
+	// Dir is the directory to provision, empty for the ready phase
+	Dir string `json:"dir,omitempty"`
+	// Options are passed with --opt key=value, which the plugin checks itself
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// PluginResponse is read as JSON from the stdout of a plugin. An empty
+// Error means the phase succeeded.
+type PluginResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// PluginProvisioner provisions a type with an external executable
+type PluginProvisioner struct {
+	Type    SpecType
+	Path    string
+	Options map[string]string
+}
+
+// FindPlugin finds the plugin provisioning specType on PATH
+func FindPlugin(specType SpecType) (PluginProvisioner, bool) {
+	path, err := exec.LookPath(pluginPrefix + string(specType))
+	if err != nil {
+		return PluginProvisioner{}, false
+	}
+	return PluginProvisioner{Type: specType, Path: path}, true
+}
+
+// PluginTypes returns the types provisioned by plugins on PATH
+func PluginTypes() []SpecType {
+	types := []SpecType{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), pluginPrefix)
+			if !ok || entry.IsDir() {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			specType := SpecType(name)
+			if name != "" && !slices.Contains(types, specType) && !slices.Contains(SpecTypes, specType) {
+				types = append(types, specType)
+			}
+		}
+	}
+	slices.Sort(types)
+	return types
+}
+
+// AllSpecTypes returns the built-in types followed by the types of plugins
+func AllSpecTypes() []SpecType {
+	return append(slices.Clone(SpecTypes), PluginTypes()...)
+}
+
+// Ready asks the plugin if it can provision environments
+func (p PluginProvisioner) Ready() error {
+	return p.call(PluginRequest{Version: pluginProtocol, Phase: PhaseReady, Type: p.Type, Options: p.Options})
+}
+
+// Provision asks the plugin to create the environment at provided directory
+func (p PluginProvisioner) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+	return p.call(PluginRequest{Version: pluginProtocol, Phase: PhaseProvision, Type: p.Type, Dir: dir, Options: p.Options})
+}
+
+// Describe summarizes environments of the plugin
+func (p PluginProvisioner) Describe() Description {
+	return Description{
+		Summary:  "Provisioned by the plugin " + p.Path,
+		Examples: []string{fmt.Sprintf("scratch new demo -t %s", p.Type)},
+	}
+}
+
+// call runs the plugin with request on stdin in the directory of request and reads its response
+func (p PluginProvisioner) call(request PluginRequest) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("marshal plugin request: %w", err)
+	}
+
+	slog.Debug("Running plugin", slog.String("path", p.Path), slog.String("phase", string(request.Phase)))
+	cmd := exec.Command(p.Path)
+	cmd.Dir = request.Dir
+	cmd.Stdin = bytes.NewReader(data)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	runErr := cmd.Run()
+
+	var response PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		if runErr != nil {
+			return fmt.Errorf("plugin %s: %s: %w", filepath.Base(p.Path), strings.TrimSpace(stderr.String()), runErr)
+		}
+		return fmt.Errorf("plugin %s: invalid response: %w", filepath.Base(p.Path), err)
+	}
+	if response.Error != "" {
+		return fmt.Errorf("plugin %s: %s", filepath.Base(p.Path), response.Error)
+	}
+	if runErr != nil {
+		return fmt.Errorf("plugin %s: %w", filepath.Base(p.Path), runErr)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+// plugin reads the request and answers like a real plugin would
+const plugin = `#!/bin/sh
+request=$(cat)
+case "$request" in
+  *'"phase":"ready"'*) echo '{}' ;;
+  *'"phase":"provision"'*) touch Cargo.toml && echo '{}' ;;
+  *) echo '{"error":"unknown phase"}' ;;
+esac
+`
+
+func TestPluginProvisioner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin is a shell script")
+	}
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "scratch-provision-rust"), []byte(plugin), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "scratch-provision-broken"), []byte("#!/bin/sh\necho oops >&2\nexit 1\n"), 0755))
+	t.Setenv("PATH", bin+string(filepath.ListSeparator)+os.Getenv("PATH"))
+
+	require.Equal(t, []main.SpecType{"broken", "rust"}, main.PluginTypes())
+
+	p, err := main.NewProvisioner("rust")
+	require.NoError(t, err)
+	require.NoError(t, p.Ready())
+
+	dir := filepath.Join(t.TempDir(), "env")
+	require.NoError(t, p.Provision(dir))
+	require.FileExists(t, filepath.Join(dir, "Cargo.toml"))
+
+	broken, ok := main.FindPlugin("broken")
+	require.True(t, ok)
+	require.ErrorContains(t, broken.Ready(), "oops")
+
+	_, ok = main.FindPlugin("missing")
+	require.False(t, ok)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// PolicyEnv overrides the location of the policy file
+const PolicyEnv = "SCRATCH_POLICY"
+
+// Policy is the system-wide policy constraining user configuration on managed machines.
+// It takes precedence over flags and config.json.
+type Policy struct {
+	// AllowedTypes restricts the types of environment that can be created
+	AllowedTypes []SpecType `json:"allowed_types,omitempty" toml:"allowed_types"`
+	// MaxTTL is the longest an environment may live before it expires
+	MaxTTL Duration `json:"max_ttl,omitzero" toml:"max_ttl"`
+	// ForbiddenRoots are directories environments must not be created in
+	ForbiddenRoots []string `json:"forbidden_roots,omitempty" toml:"forbidden_roots"`
+}
+
+// DefaultPolicyPath returns the path of the system-wide policy file
+func DefaultPolicyPath() string {
+	if path := os.Getenv(PolicyEnv); path != "" {
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), AppName, "policy.toml")
+	}
+	return filepath.Join("/etc", AppName, "policy.toml")
+}
+
+// LoadPolicy loads the policy file, returning an empty Policy if it does not exist
+func LoadPolicy() (Policy, error) {
+	path := DefaultPolicyPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Policy{}, nil
+	}
+	if err != nil {
+		return Policy{}, fmt.Errorf("read policy: %w", err)
+	}
+
+	var p Policy
+	if err := toml.Unmarshal(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("unmarshal policy %q: %w", path, err)
+	}
+	return p, nil
+}
+
+// CheckType checks the type of environment is allowed
+func (p Policy) CheckType(specType SpecType) error {
+	if len(p.AllowedTypes) > 0 && !slices.Contains(p.AllowedTypes, specType) {
+		return fmt.Errorf("type %q is %w", specType, ErrPolicy)
+	}
+	return nil
+}
+
+// CheckPath checks path is not inside a forbidden root
+func (p Policy) CheckPath(path string) error {
+	for _, root := range p.ForbiddenRoots {
+		if pathWithin(root, path) {
+			return fmt.Errorf("%s is inside %s, which is %w", path, root, ErrPolicy)
+		}
+	}
+	return nil
+}
+
+// CheckTTL checks an environment expiring after ttl complies with the maximum TTL,
+// where zero means it never expires
+func (p Policy) CheckTTL(ttl time.Duration) error {
+	if p.MaxTTL == 0 {
+		return nil
+	}
+	if ttl == 0 || ttl > time.Duration(p.MaxTTL) {
+		return fmt.Errorf("environments expiring after %s or never are %w", p.MaxTTL, ErrPolicy)
+	}
+	return nil
+}
+
+// Check returns every way spec violates the policy
+func (p Policy) Check(spec Spec) []error {
+	errs := []error{}
+	if err := p.CheckType(spec.Type); err != nil {
+		errs = append(errs, err)
+	}
+	if err := p.CheckPath(spec.Path); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Constrain returns config with settings forbidden by the policy removed
+func (p Policy) Constrain(config Config) Config {
+	roots := []RootRule{}
+	for _, rule := range config.Roots {
+		if err := p.CheckPath(rule.Path); err != nil {
+			output.Warn("Ignoring root: %s", err)
+			continue
+		}
+		roots = append(roots, rule)
+	}
+	config.Roots = roots
+	return config
+}
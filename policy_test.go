@@ -0,0 +1,62 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.toml")
+	t.Setenv(main.PolicyEnv, path)
+
+	policy, err := main.LoadPolicy()
+	require.NoError(t, err)
+	require.Equal(t, main.Policy{}, policy)
+
+	data := `allowed_types = ["python"]
+max_ttl = "2w"
+forbidden_roots = ["/srv"]
+`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	policy, err = main.LoadPolicy()
+	require.NoError(t, err)
+	require.Equal(t, main.Policy{
+		AllowedTypes:   []main.SpecType{main.PythonSpec},
+		MaxTTL:         main.Duration(2 * main.Week),
+		ForbiddenRoots: []string{"/srv"},
+	}, policy)
+
+	require.NoError(t, os.WriteFile(path, []byte(`max_ttl = "soon"`), 0644))
+	_, err = main.LoadPolicy()
+	require.Error(t, err)
+}
+
+func TestPolicy_Check(t *testing.T) {
+	root := t.TempDir()
+	forbidden := filepath.Join(root, "shared")
+	policy := main.Policy{
+		AllowedTypes:   []main.SpecType{main.PythonSpec},
+		MaxTTL:         main.Duration(14 * main.Day),
+		ForbiddenRoots: []string{forbidden},
+	}
+
+	require.Empty(t, policy.Check(main.Spec{Name: "a", Type: main.PythonSpec, Path: filepath.Join(root, "a")}))
+	require.Len(t, policy.Check(main.Spec{Name: "a", Type: "node", Path: filepath.Join(forbidden, "a")}), 2)
+	require.Error(t, policy.CheckPath(forbidden))
+	require.NoError(t, policy.CheckPath(forbidden+"-other"))
+
+	require.NoError(t, policy.CheckTTL(7*main.Day))
+	require.Error(t, policy.CheckTTL(30*main.Day))
+	require.Error(t, policy.CheckTTL(0))
+	require.NoError(t, main.Policy{}.CheckTTL(0))
+}
+
+func TestPolicy_Constrain(t *testing.T) {
+	policy := main.Policy{ForbiddenRoots: []string{"/srv"}}
+	config := main.Config{Roots: []main.RootRule{{Path: "/srv/scratch"}, {Path: "/home/me/scratch"}}}
+	require.Equal(t, []main.RootRule{{Path: "/home/me/scratch"}}, policy.Constrain(config).Roots)
+}
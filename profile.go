@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timing is how long a phase of a command took
+type Timing struct {
+	Phase    string
+	Duration time.Duration
+	// Depth is how many phases were running when it started, which it is part of
+	Depth int
+}
+
+// Profile collects the timings of the phases of a command
+type Profile struct {
+	mu      sync.Mutex
+	running int
+	Timings []Timing
+}
+
+// profile collects the phases of the running command, nil unless --profile is passed
+// so long running commands like serve do not keep them
+var profile *Profile
+
+// Start starts timing phase and returns the function recording how long it took,
+// which is also logged with --verbose. Phases are listed in the order they start.
+func (p *Profile) Start(phase string) (stop func()) {
+	start := time.Now()
+	p.mu.Lock()
+	i := len(p.Timings)
+	p.Timings = append(p.Timings, Timing{Phase: phase, Depth: p.running})
+	p.running++
+	p.mu.Unlock()
+
+	return func() {
+		took := time.Since(start)
+		p.mu.Lock()
+		p.Timings[i].Duration = took
+		p.running--
+		p.mu.Unlock()
+		logPhase(phase, took)
+	}
+}
+
+// logPhase logs how long phase took for --verbose
+func logPhase(phase string, took time.Duration) {
+	slog.Debug("Finished phase", slog.String("phase", phase), slog.Duration("took", took.Round(time.Millisecond)))
+}
+
+// Write prints every phase with its share of total, indented under the phases it is part of
+func (p *Profile) Write(w io.Writer, total time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(w, "Profile, %s in total:\n", total.Round(time.Millisecond))
+	for _, timing := range p.Timings {
+		share := 0.0
+		if total > 0 {
+			share = float64(timing.Duration) / float64(total) * 100
+		}
+		fmt.Fprintf(w, "%10s %4.0f%%  %s%s\n", timing.Duration.Round(time.Millisecond), share, strings.Repeat("  ", timing.Depth), timing.Phase)
+	}
+}
+
+// timePhase starts timing phase of the running command, which is only logged
+// unless the command is profiled, see Profile.Start
+func timePhase(phase string) (stop func()) {
+	if profile != nil {
+		return profile.Start(phase)
+	}
+	start := time.Now()
+	return func() { logPhase(phase, time.Since(start)) }
+}
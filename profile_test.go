@@ -0,0 +1,34 @@
+package main_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile(t *testing.T) {
+	profile := &main.Profile{}
+	stopBuild := profile.Start("provision python")
+	stopCommand := profile.Start("uv init")
+	stopCommand()
+	stopBuild()
+	profile.Start("open folder")()
+
+	require.Len(t, profile.Timings, 3)
+	require.Equal(t, []int{0, 1, 0}, []int{profile.Timings[0].Depth, profile.Timings[1].Depth, profile.Timings[2].Depth})
+	require.GreaterOrEqual(t, profile.Timings[0].Duration, profile.Timings[1].Duration)
+
+	profile.Timings[0].Duration = time.Second
+	profile.Timings[1].Duration = 500 * time.Millisecond
+	profile.Timings[2].Duration = 0
+	var b bytes.Buffer
+	profile.Write(&b, 2*time.Second)
+	require.Equal(t, `Profile, 2s in total:
+        1s   50%  provision python
+     500ms   25%    uv init
+        0s    0%  open folder
+`, b.String())
+}
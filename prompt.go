@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// stdin buffers answers typed in reply to prompts
+var stdin = bufio.NewReader(os.Stdin)
+
+// prompting is turned off while serving, so requests fail instead of waiting on
+// the terminal of the server
+var prompting = true
+
+// canPrompt checks if questions can be asked on the terminal
+func canPrompt() bool {
+	return prompting && IsTerminal(os.Stdin)
+}
+
+// readAnswer reads one line from r, failing once input ends
+func readAnswer(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if errors.Is(err, io.EOF) && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read answer: %w", err)
+	}
+	return strings.ToLower(strings.TrimSpace(line)), nil
+}
+
+// ReadConfirmation writes prompt to w until r answers yes or no
+func ReadConfirmation(r *bufio.Reader, w io.Writer, prompt string) (bool, error) {
+	for {
+		fmt.Fprintf(w, "%s [y/n]: ", prompt)
+		input, err := readAnswer(r)
+		if err != nil {
+			return false, err
+		}
+
+		if input == "y" || input == "yes" {
+			return true, nil
+		}
+		if input == "n" || input == "no" {
+			return false, nil
+		}
+	}
+}
+
+// askForConfirmation asks a yes or no question, failing when stdin is not a
+// terminal so scripts must pass --force instead of hanging or guessing
+func askForConfirmation(prompt string) (bool, error) {
+	if !canPrompt() {
+		return false, fmt.Errorf("cannot ask %q: %w", prompt, ErrNotInteractive)
+	}
+	return ReadConfirmation(stdin, os.Stdout, prompt)
+}
+
+// askForSecret asks for a secret like a passphrase without echoing it
+func askForSecret(prompt string) (string, error) {
+	if !canPrompt() {
+		return "", fmt.Errorf("cannot ask for %s: %w", strings.ToLower(prompt), ErrNotInteractive)
+	}
+	fmt.Printf("%s: ", prompt)
+	restore, err := hideInput(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	line, err := stdin.ReadString('\n')
+	restore()
+	// The newline typed was not echoed
+	fmt.Println()
+	if errors.Is(err, io.EOF) && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", strings.ToLower(prompt), err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// confirmAll lists items and asks a single yes or no question for all of them
+func confirmAll(prompt string, items []string) (bool, error) {
+	if !canPrompt() {
+		return false, fmt.Errorf("cannot ask %q: %w", prompt, ErrNotInteractive)
+	}
+	for _, item := range items {
+		fmt.Printf("  %s\n", item)
+	}
+	return ReadConfirmation(stdin, os.Stdout, prompt)
+}
+
+// askForChoice asks to pick one of options and returns its index
+func askForChoice(prompt string, options []string) (int, error) {
+	fmt.Println(prompt)
+	for i, option := range options {
+		fmt.Printf("  %d) %s\n", i+1, option)
+	}
+	for {
+		fmt.Printf("Choose 1-%d: ", len(options))
+		input, err := readAnswer(stdin)
+		if err != nil {
+			return 0, err
+		}
+
+		if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main_test
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConfirmation(t *testing.T) {
+	cases := []struct {
+		input   string
+		want    bool
+		prompts int
+	}{
+		{"y\n", true, 1},
+		{"YES\n", true, 1},
+		{"n\n", false, 1},
+		{"\nmaybe\nno\n", false, 3},
+		// The last line may end without a newline
+		{"y", true, 1},
+	}
+	for _, c := range cases {
+		var out strings.Builder
+		ok, err := main.ReadConfirmation(bufio.NewReader(strings.NewReader(c.input)), &out, "Delete?")
+		require.NoError(t, err, c.input)
+		require.Equal(t, c.want, ok, c.input)
+		require.Equal(t, c.prompts, strings.Count(out.String(), "Delete? [y/n]: "), c.input)
+	}
+}
+
+func TestReadConfirmation_EOF(t *testing.T) {
+	// Input ending without an answer fails instead of asking forever
+	_, err := main.ReadConfirmation(bufio.NewReader(strings.NewReader("maybe\n")), io.Discard, "Delete?")
+	require.ErrorIs(t, err, io.EOF)
+}
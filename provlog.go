@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logKeyPrefix prefixes the store keys of provisioning logs
+const logKeyPrefix = "log/"
+
+// CommandRecord is a command run while provisioning an environment
+type CommandRecord struct {
+	Time     time.Time     `json:"time"`
+	Dir      string        `json:"dir,omitempty"`
+	Command  []string      `json:"command"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// ProvisionLog records how an environment was provisioned
+type ProvisionLog struct {
+	// Tools maps the programs the provisioner needs to their versions
+	Tools    map[string]string `json:"tools,omitempty"`
+	Commands []CommandRecord   `json:"commands"`
+}
+
+// recording collects the commands RunCommand runs while an environment is provisioned
+var recording struct {
+	sync.Mutex
+	log *ProvisionLog
+}
+
+// RecordCommands records every command RunCommand runs into log until stop is called
+func RecordCommands(log *ProvisionLog) (stop func()) {
+	recording.Lock()
+	defer recording.Unlock()
+	recording.log = log
+	return func() {
+		recording.Lock()
+		defer recording.Unlock()
+		recording.log = nil
+	}
+}
+
+// recordCommand adds record to the log being recorded, if any
+func recordCommand(record CommandRecord) {
+	recording.Lock()
+	defer recording.Unlock()
+	if recording.log != nil {
+		recording.log.Commands = append(recording.log.Commands, record)
+	}
+}
+
+// ToolVersions returns the first line printed by --version of each tool p needs
+func ToolVersions(p Provisioner) map[string]string {
+	user, ok := p.(ToolUser)
+	if !ok {
+		return nil
+	}
+	defer timePhase("check tool versions")()
+	versions := map[string]string{}
+	for _, tool := range user.Tools() {
+		out, err := exec.Command(tool.Name, "--version").Output()
+		if err != nil {
+			continue
+		}
+		version, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		versions[tool.Name] = version
+	}
+	return versions
+}
+
+// logKey returns the store key of the provisioning log of the environment
+func (s Spec) logKey() string {
+	return logKeyPrefix + s.UID
+}
+
+// SaveProvisionLog stores the provisioning log of spec
+func SaveProvisionLog(store Writer, spec Spec, log ProvisionLog) error {
+	data, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("marshal provisioning log: %w", err)
+	}
+	if err := store.Put(spec.logKey(), data); err != nil {
+		return fmt.Errorf("save provisioning log: %w", err)
+	}
+	return nil
+}
+
+// LoadProvisionLog loads the provisioning log of spec
+func LoadProvisionLog(store Reader, spec Spec) (ProvisionLog, error) {
+	data, err := store.Get(spec.logKey())
+	if errors.Is(err, ErrNotFound) {
+		return ProvisionLog{}, fmt.Errorf("no provisioning log for %s: %w", spec.ID(), ErrNotFound)
+	}
+	if err != nil {
+		return ProvisionLog{}, err
+	}
+	var log ProvisionLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return ProvisionLog{}, fmt.Errorf("unmarshal provisioning log: %w", err)
+	}
+	return log, nil
+}
+
+// Write prints the tool versions and each command with its output
+func (l ProvisionLog) Write(w io.Writer) {
+	for _, name := range slices.Sorted(maps.Keys(l.Tools)) {
+		fmt.Fprintf(w, "%s: %s\n", name, l.Tools[name])
+	}
+	for _, record := range l.Commands {
+		fmt.Fprintf(w, "\n%s $ %s  (in %s, %s)\n", record.Time.Local().Format(time.DateTime), strings.Join(record.Command, " "), record.Dir, record.Duration.Round(time.Millisecond))
+		for line := range strings.Lines(record.Output) {
+			fmt.Fprintf(w, "  %s", line)
+		}
+		if record.Output != "" && !strings.HasSuffix(record.Output, "\n") {
+			fmt.Fprintln(w)
+		}
+		if record.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", record.Error)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordCommands(t *testing.T) {
+	log := main.ProvisionLog{}
+	stop := main.RecordCommands(&log)
+	require.NoError(t, main.RunCommand("", "echo", "hello"))
+	require.Error(t, main.RunCommand("", "false"))
+	stop()
+	require.NoError(t, main.RunCommand("", "echo", "unrecorded"))
+
+	require.Len(t, log.Commands, 2)
+	require.Equal(t, []string{"echo", "hello"}, log.Commands[0].Command)
+	require.Equal(t, "hello\n", log.Commands[0].Output)
+	require.Empty(t, log.Commands[0].Error)
+	require.NotEmpty(t, log.Commands[1].Error)
+
+	var b strings.Builder
+	log.Write(&b)
+	require.Contains(t, b.String(), "$ echo hello")
+	require.Contains(t, b.String(), "  hello\n")
+}
+
+func TestProvisionLog_SaveLoad(t *testing.T) {
+	mw := NewMemoryStore()
+	spec := main.NewSpec("test", main.PythonSpec, t.TempDir())
+	require.NoError(t, spec.Save(mw))
+
+	_, err := main.LoadProvisionLog(mw, spec)
+	require.ErrorIs(t, err, main.ErrNotFound)
+
+	log := main.ProvisionLog{Tools: map[string]string{"uv": "uv 0.5.0"}, Commands: []main.CommandRecord{{Command: []string{"uv", "init"}}}}
+	require.NoError(t, main.SaveProvisionLog(mw, spec, log))
+	loaded, err := main.LoadProvisionLog(mw, spec)
+	require.NoError(t, err)
+	require.Equal(t, log.Tools, loaded.Tools)
+	require.Equal(t, log.Commands[0].Command, loaded.Commands[0].Command)
+
+	specs, err := main.LoadSpecs(mw)
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+
+	require.NoError(t, spec.Delete(mw))
+	require.Empty(t, mw.Data)
+}
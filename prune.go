@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// PruneCmd represents the command to delete environments that are no longer used
+type PruneCmd struct {
+	PlanFlags
+	FilterFlags
+	Type    SpecType `short:"t" help:"Only environments of this type"`
+	Stale   Duration `help:"Delete environments not modified for this long"`
+	Expired bool     `help:"Delete environments past their TTL"`
+	Unused  Duration `help:"Delete environments never opened again since they were created this long ago"`
+	Force   bool     `short:"f" help:"Apply without confirmation"`
+	// IncludeLocked prunes locked environments too, which are skipped otherwise
+	IncludeLocked bool `help:"Prune locked environments too"`
+	// InstallSchedule is empty unless passed, as kong enums need a default
+	InstallSchedule string `placeholder:"daily|weekly" help:"Install a job running prune --expired --apply --force daily or weekly, as a systemd timer on Linux, a launch agent on macOS or a scheduled task on Windows"`
+	RemoveSchedule  bool   `help:"Remove the job installed by --install-schedule"`
+}
+
+// Validate checks at least one rule was chosen
+func (p PruneCmd) Validate() error {
+	if p.InstallSchedule != "" || p.RemoveSchedule {
+		if p.InstallSchedule != "" && p.RemoveSchedule {
+			return fmt.Errorf("--install-schedule cannot be used with --remove-schedule")
+		}
+		if p.InstallSchedule != "" && p.InstallSchedule != DailySchedule && p.InstallSchedule != WeeklySchedule {
+			return fmt.Errorf("--install-schedule must be daily or weekly")
+		}
+		return nil
+	}
+	if p.Stale == 0 && !p.Expired && p.Unused == 0 {
+		return fmt.Errorf("must specify --stale, --expired or --unused")
+	}
+	return nil
+}
+
+// PruneRules select the environments prune deletes
+type PruneRules struct {
+	// Stale selects live environments not modified within this long, 0 to disable
+	Stale time.Duration
+	// Expired selects environments past their TTL, including archived ones
+	Expired bool
+	// Unused selects live environments created longer ago than this and never
+	// opened, run or entered with shell since, 0 to disable
+	Unused time.Duration
+}
+
+// PlanPrune finds the environments matching rules at now
+func PlanPrune(specs []Spec, rules PruneRules, now time.Time) ([]PlannedAction, error) {
+	actions := []PlannedAction{}
+	for _, spec := range specs {
+		reason, err := pruneReason(spec, rules, now)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" {
+			continue
+		}
+
+		var size ByteSize
+		location := spec.Location()
+		if _, err := os.Stat(location); err == nil {
+			if size, err = DirSize(location); err != nil {
+				return nil, err
+			}
+		}
+		actions = append(actions, PlannedAction{
+			Action: "delete",
+			Target: spec.ID(),
+			UID:    spec.UID,
+			Path:   location,
+			Size:   size,
+			Reason: reason,
+		})
+	}
+	return actions, nil
+}
+
+// pruneReason explains why spec should be pruned, or returns an empty string if it should be kept
+func pruneReason(spec Spec, rules PruneRules, now time.Time) (string, error) {
+	if rules.Expired && spec.IsExpired(now) {
+		return fmt.Sprintf("expired: TTL ended %s ago", FormatDuration(now.Sub(spec.Expires))), nil
+	}
+	if spec.IsArchived() || !spec.Exists() {
+		return "", nil
+	}
+	// Environments opened before opens were counted still have a last use
+	if rules.Unused > 0 && spec.Opens == 0 && spec.LastUsed.IsZero() && !spec.Created.IsZero() {
+		if age := now.Sub(spec.Created); age > rules.Unused {
+			return fmt.Sprintf("unused: never reopened since created %s ago", FormatDuration(age)), nil
+		}
+	}
+	if rules.Stale == 0 {
+		return "", nil
+	}
+	modified, err := LastModified(spec.Path)
+	if err != nil {
+		return "", err
+	}
+	if age := now.Sub(modified); age > rules.Stale {
+		return fmt.Sprintf("stale: not modified in %s", FormatDuration(age)), nil
+	}
+	return "", nil
+}
+
+// schedule installs or removes the job pruning expired environments
+func (p PruneCmd) schedule() error {
+	if p.RemoveSchedule {
+		if err := RemoveSchedule(); err != nil {
+			return err
+		}
+		output.Success("Removed scheduled prune")
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("find scratch executable: %w", err)
+	}
+	args := []string{exe, "prune", "--expired", "--apply", "--force"}
+	if workspace != DefaultWorkspace {
+		args = append(args, "--workspace", workspace)
+	}
+	where, err := InstallSchedule(p.InstallSchedule, args)
+	if err != nil {
+		return err
+	}
+	output.Success("Scheduled pruning expired environments %s with %s", p.InstallSchedule, where)
+	return nil
+}
+
+// Run prints the environments to delete and deletes them with --apply
+func (p PruneCmd) Run(ctx *CLIContext) error {
+	if p.InstallSchedule != "" || p.RemoveSchedule {
+		return p.schedule()
+	}
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	specs = p.Filter(p.Type).Apply(specs, now)
+	if !p.IncludeLocked {
+		specs = withoutLocked(specs, "prune")
+	}
+	rules := PruneRules{Stale: time.Duration(p.Stale), Expired: p.Expired, Unused: time.Duration(p.Unused)}
+	actions, err := PlanPrune(specs, rules, now)
+	if err != nil {
+		return err
+	}
+	if err := p.PrintPlan(actions); err != nil {
+		return err
+	}
+	if !p.Apply || len(actions) == 0 {
+		return nil
+	}
+
+	if !p.Force {
+		ok, err := askForConfirmation(fmt.Sprintf("Delete %d environments?", len(actions)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			output.Info("Not pruning environments")
+			return nil
+		}
+	}
+
+	if err := ctx.backupBefore(store, "prune"); err != nil {
+		return err
+	}
+	if err := ctx.purgeTrash(store); err != nil {
+		return err
+	}
+
+	config, err := ctx.Config()
+	if err != nil {
+		return err
+	}
+	roots, err := EnvironmentRoots(config)
+	if err != nil {
+		return err
+	}
+	byUID := make(map[string]Spec, len(specs))
+	for _, spec := range specs {
+		byUID[spec.UID] = spec
+	}
+	for _, action := range actions {
+		spec := byUID[action.UID]
+		err := checkSpecDeletable(spec, roots)
+		if err == nil && !spec.InPlace {
+			err = CheckGuard(spec, config)
+		}
+		if err != nil {
+			output.Warn("Skipping %s: %s", spec.ID(), err)
+			continue
+		}
+		if p.Expired && spec.IsExpired(now) {
+			recordEvent(NewEvent(ActionExpire, spec))
+		}
+		if err := trashEnvironment(store, spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanPrune(t *testing.T) {
+	dataDir := t.TempDir()
+	specs := []main.Spec{}
+	for _, name := range []string{"old", "fresh", "archived"} {
+		dir := filepath.Join(dataDir, name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.py"), []byte(name), 0644))
+		specs = append(specs, main.Spec{Name: name, Type: main.PythonSpec, Path: dir})
+	}
+	specs[2].Archive = filepath.Join(dataDir, ".archive", "archived")
+	specs = append(specs, main.Spec{Name: "missing", Type: main.PythonSpec, Path: filepath.Join(dataDir, "missing")})
+
+	old := time.Now().Add(-60 * main.Day)
+	for _, name := range []string{"old", "archived"} {
+		dir := filepath.Join(dataDir, name)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "main.py"), old, old))
+		require.NoError(t, os.Chtimes(dir, old, old))
+	}
+
+	actions, err := main.PlanPrune(specs, main.PruneRules{Stale: 30 * main.Day}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	require.Equal(t, "delete", actions[0].Action)
+	require.Equal(t, "python:old", actions[0].Target)
+	require.Equal(t, specs[0].Path, actions[0].Path)
+	require.Equal(t, main.ByteSize(3), actions[0].Size)
+	require.Contains(t, actions[0].Reason, "stale")
+}
+
+func TestPlanPrune_Expired(t *testing.T) {
+	dataDir := t.TempDir()
+	now := time.Now()
+	specs := []main.Spec{
+		{Name: "expired", Type: main.PythonSpec, Path: dataDir, Expires: now.Add(-2 * main.Day)},
+		{Name: "gone", Type: main.PythonSpec, Path: filepath.Join(dataDir, "gone"), Expires: now.Add(-time.Hour)},
+		{Name: "later", Type: main.PythonSpec, Path: dataDir, Expires: now.Add(main.Day)},
+		{Name: "forever", Type: main.PythonSpec, Path: dataDir},
+	}
+
+	actions, err := main.PlanPrune(specs, main.PruneRules{Expired: true}, now)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+	require.Equal(t, "python:expired", actions[0].Target)
+	require.Equal(t, "expired: TTL ended 2d ago", actions[0].Reason)
+	require.Equal(t, "python:gone", actions[1].Target)
+	require.Zero(t, actions[1].Size)
+}
+
+func TestPlanPrune_Unused(t *testing.T) {
+	dataDir := t.TempDir()
+	now := time.Now()
+	specs := []main.Spec{
+		{Name: "unused", Type: main.PythonSpec, Path: dataDir, Created: now.Add(-20 * main.Day)},
+		{Name: "opened", Type: main.PythonSpec, Path: dataDir, Created: now.Add(-20 * main.Day), Opens: 1, LastUsed: now},
+		{Name: "before-opens", Type: main.PythonSpec, Path: dataDir, Created: now.Add(-20 * main.Day), LastUsed: now},
+		{Name: "new", Type: main.PythonSpec, Path: dataDir, Created: now.Add(-main.Day)},
+		{Name: "undated", Type: main.PythonSpec, Path: dataDir},
+	}
+
+	actions, err := main.PlanPrune(specs, main.PruneRules{Unused: 14 * main.Day}, now)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	require.Equal(t, "python:unused", actions[0].Target)
+	require.Equal(t, "unused: never reopened since created 20d ago", actions[0].Reason)
+}
+
+func TestPlanFlags_Validate(t *testing.T) {
+	require.NoError(t, main.PlanFlags{Plan: true}.Validate())
+	require.NoError(t, main.PlanFlags{Apply: true}.Validate())
+	require.Error(t, main.PlanFlags{Plan: true, Apply: true}.Validate())
+}
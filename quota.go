@@ -0,0 +1,62 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// quotaOffenders is how many of the largest environments are named when over quota
+const quotaOffenders = 3
+
+// QuotaUsage is the disk usage of the environments of the workspace against the quota
+type QuotaUsage struct {
+	Used  ByteSize
+	Quota ByteSize
+	// Largest are the biggest environments, largest first
+	Largest []Spec
+}
+
+// MeasureQuota sums the usage of specs, which must be measured, against quota
+func MeasureQuota(specs []Spec, quota ByteSize) QuotaUsage {
+	usage := QuotaUsage{Quota: quota}
+	measured := []Spec{}
+	for _, spec := range specs {
+		if spec.Usage == nil {
+			continue
+		}
+		usage.Used += spec.Usage.Size
+		measured = append(measured, spec)
+	}
+	slices.SortFunc(measured, func(a, b Spec) int {
+		return cmp.Compare(b.Usage.Size, a.Usage.Size)
+	})
+	usage.Largest = measured[:min(len(measured), quotaOffenders)]
+	return usage
+}
+
+// Over checks if environments exceed the quota, never when there is no quota
+func (q QuotaUsage) Over() bool {
+	return q.Quota > 0 && q.Used > q.Quota
+}
+
+// Offenders lists the largest environments with their sizes
+func (q QuotaUsage) Offenders() string {
+	offenders := make([]string, len(q.Largest))
+	for i, spec := range q.Largest {
+		offenders[i] = fmt.Sprintf("%s (%s)", spec.ID(), spec.Usage.Size)
+	}
+	return strings.Join(offenders, ", ")
+}
+
+// checkQuota measures specs against the quota of config, caching their sizes in
+// store, and returns ErrQuota naming the largest if they exceed it
+func checkQuota(store Storer, specs []Spec, config Config) error {
+	defer timePhase("check quota")()
+	usage := MeasureQuota(measureSpecs(store, specs, false), config.Disk.Quota)
+	if !usage.Over() {
+		return nil
+	}
+	return fmt.Errorf("environments use %s of the %s quota, largest are %s: %w", usage.Used, usage.Quota, usage.Offenders(), ErrQuota)
+}
@@ -0,0 +1,26 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasureQuota(t *testing.T) {
+	sized := func(name string, size main.ByteSize) main.Spec {
+		spec := main.NewSpec(name, main.PythonSpec, "/tmp")
+		spec.Usage = &main.DiskUsage{Size: size}
+		return spec
+	}
+	specs := []main.Spec{sized("small", main.MB), sized("huge", 6*main.GB), sized("big", 2*main.GB), sized("medium", 500*main.MB), main.NewSpec("unmeasured", main.PythonSpec, "/tmp")}
+
+	usage := main.MeasureQuota(specs, 5*main.GB)
+	require.Equal(t, 8*main.GB+501*main.MB, usage.Used)
+	require.True(t, usage.Over())
+	require.Equal(t, "python:huge (6.0GB), python:big (2.0GB), python:medium (500.0MB)", usage.Offenders())
+
+	require.False(t, main.MeasureQuota(specs, 10*main.GB).Over())
+	require.False(t, main.MeasureQuota(specs, 0).Over())
+	require.Empty(t, main.MeasureQuota(nil, main.GB).Largest)
+}
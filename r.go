@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os/exec"
+	"slices"
+)
+
+var rTool = Tool{
+	Name: "Rscript",
+	Installers: map[string][]Installer{
+		"linux": {
+			{Requires: "apt-get", Command: "sudo apt-get install -y r-base"},
+			{Requires: "dnf", Command: "sudo dnf install -y R"},
+			{Requires: "pacman", Command: "sudo pacman -S --needed r"},
+		},
+		"darwin":  {{Requires: "brew", Command: "brew install r"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=RProject.R -e"}},
+	},
+}
+
+// rFiles are written to new R environments before renv is initialized, which
+// adds activating the project library to .Rprofile
+var rFiles = map[string]string{
+	"analysis.R": `# Install packages with install.packages() and record them with renv::snapshot()
+
+data <- mtcars
+summary(data)
+`,
+	".Rprofile": `options(
+  repos = c(CRAN = "https://cloud.r-project.org"),
+  width = 120
+)
+`,
+}
+
+// renvActivate is written by renv::init to activate the project library
+const renvActivate = "renv/activate.R"
+
+// REnvironment represents an R project with its own package library managed by renv
+type REnvironment struct{}
+
+// Ready checks if R is installed with the renv package
+func (p REnvironment) Ready() error {
+	if err := CommandsExist("Rscript"); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	if err := exec.Command("Rscript", "-e", `if (!requireNamespace("renv", quietly = TRUE)) quit(status = 1)`).Run(); err != nil {
+		return fmt.Errorf(`missing R package renv, install it with: Rscript -e 'install.packages("renv")'`)
+	}
+	return nil
+}
+
+// initRenv initializes renv in dir without installing packages
+func initRenv(dir string) error {
+	if err := RunCommand(dir, "Rscript", "-e", "renv::init(bare = TRUE, restart = FALSE)"); err != nil {
+		return fmt.Errorf("renv::init: %w", err)
+	}
+	return nil
+}
+
+// Provision creates the environment at provided directory
+func (p REnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	if err := writeMissingFiles(dir, rFiles); err != nil {
+		return err
+	}
+	if err := initRenv(dir); err != nil {
+		return err
+	}
+
+	slog.Debug("Provisioned r environment", slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes projects with a renv lock file or RStudio project, and likely any with R scripts
+func (p REnvironment) Detect(dir string) float64 {
+	confidence := detectFiles(dir, map[string]float64{
+		"renv.lock":   StrongMatch,
+		renvActivate:  StrongMatch,
+		".Rprofile":   LikelyMatch,
+		"DESCRIPTION": WeakMatch,
+	})
+	confidence = max(confidence, detectGlob(dir, "*.Rproj", StrongMatch))
+	return max(confidence, detectGlob(dir, "*.R", LikelyMatch))
+}
+
+// Describe summarizes r environments
+func (p REnvironment) Describe() Description {
+	return Description{Summary: "An R project with its own package library managed by renv", Examples: []string{"scratch new analysis -t r"}}
+}
+
+// Tools returns Rscript, which comes with R
+func (p REnvironment) Tools() []Tool {
+	return []Tool{rTool}
+}
+
+// Check verifies the scaffolded files and renv exist
+func (p REnvironment) Check(dir string) error {
+	return FilesExist(dir, append(slices.Sorted(maps.Keys(rFiles)), renvActivate)...)
+}
+
+// Ignore returns the project library renv installs packages into, and the R history
+func (p REnvironment) Ignore() []string {
+	return []string{"library", ".Rhistory"}
+}
+
+// Rebuild installs the packages recorded in renv.lock again
+func (p REnvironment) Rebuild(dir string) error {
+	if err := RunCommand(dir, "Rscript", "-e", "renv::restore(prompt = FALSE)"); err != nil {
+		return fmt.Errorf("renv::restore: %w", err)
+	}
+	return nil
+}
+
+// Reprovision writes the scaffolded files that are missing and initializes renv
+// again if it is missing
+func (p REnvironment) Reprovision(dir string) error {
+	if err := writeMissingFiles(dir, rFiles); err != nil {
+		return err
+	}
+	if FilesExist(dir, renvActivate) != nil {
+		slog.Debug("Initializing missing renv", slog.String("dir", dir))
+		return initRenv(dir)
+	}
+	return nil
+}
+
+// Upgrade updates the packages of the project library and records them in renv.lock
+func (p REnvironment) Upgrade(dir string) error {
+	if err := RunCommand(dir, "Rscript", "-e", "renv::update(prompt = FALSE); renv::snapshot(prompt = FALSE)"); err != nil {
+		return fmt.Errorf("renv::update: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRscript has every package installed, and records the other expressions it
+// evaluates and initializes renv like renv::init
+const fakeRscript = `#!/bin/sh
+case "$2" in
+*requireNamespace*) exit 0 ;;
+esac
+echo "$2" >> Rscript.log
+/bin/mkdir -p renv
+echo 'local({})' > renv/activate.R
+echo 'source("renv/activate.R")' >> .Rprofile
+`
+
+func TestREnvironment_Provision(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Rscript is faked with a shell script")
+	}
+	t.Setenv("PATH", fakeBin(t, map[string]string{"Rscript": fakeRscript}))
+
+	dir := filepath.Join(t.TempDir(), "analysis")
+	p := main.REnvironment{}
+	require.NoError(t, p.Ready())
+	require.NoError(t, p.Provision(dir))
+	require.NoError(t, p.Check(dir))
+
+	log, err := os.ReadFile(filepath.Join(dir, "Rscript.log"))
+	require.NoError(t, err)
+	require.Equal(t, "renv::init(bare = TRUE, restart = FALSE)\n", string(log))
+	// renv activates the project library after the options scratch sets
+	profile, err := os.ReadFile(filepath.Join(dir, ".Rprofile"))
+	require.NoError(t, err)
+	require.Contains(t, string(profile), "cloud.r-project.org")
+	require.Contains(t, string(profile), `source("renv/activate.R")`)
+
+	specType, err := main.DetectType(dir)
+	require.NoError(t, err)
+	require.Equal(t, main.RSpec, specType)
+
+	// Reprovisioning restores missing files and only initializes renv when it is missing
+	require.NoError(t, os.Remove(filepath.Join(dir, "analysis.R")))
+	require.NoError(t, p.Reprovision(dir))
+	require.NoError(t, p.Check(dir))
+	log, err = os.ReadFile(filepath.Join(dir, "Rscript.log"))
+	require.NoError(t, err)
+	require.Equal(t, "renv::init(bare = TRUE, restart = FALSE)\n", string(log))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readmeFile describes an environment in its directory
+const readmeFile = "README.md"
+
+// frontMatterFence delimits the metadata block at the top of the README
+const frontMatterFence = "---"
+
+// ReadmeMeta is the metadata of an environment kept in the front matter of its README
+type ReadmeMeta struct {
+	Name        string
+	Type        SpecType
+	Created     time.Time
+	Description string
+	// TTL is how long after creation the environment expires, 0 if it never does
+	TTL time.Duration
+}
+
+// NewReadmeMeta returns the metadata of spec
+func NewReadmeMeta(spec Spec) ReadmeMeta {
+	meta := ReadmeMeta{Name: spec.Name, Type: spec.Type, Created: spec.Created, Description: spec.Description}
+	if !spec.Expires.IsZero() && !spec.Created.IsZero() {
+		meta.TTL = spec.Expires.Sub(spec.Created)
+	}
+	return meta
+}
+
+// quoteFrontMatter quotes value when it would not be read back as is, which
+// also keeps the block valid YAML
+func quoteFrontMatter(value string) string {
+	if value != "" && strings.TrimSpace(value) == value && !strings.ContainsAny(value, ":#\"'\\\n\r\t") &&
+		!strings.ContainsAny(value[:1], "-?[]{},&*!|>%@`") {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// FrontMatter returns the block at the top of a README holding the metadata of spec
+func FrontMatter(spec Spec) []byte {
+	meta := NewReadmeMeta(spec)
+	var b bytes.Buffer
+	fmt.Fprintln(&b, frontMatterFence)
+	fmt.Fprintf(&b, "name: %s\n", quoteFrontMatter(meta.Name))
+	fmt.Fprintf(&b, "type: %s\n", quoteFrontMatter(string(meta.Type)))
+	if !meta.Created.IsZero() {
+		fmt.Fprintf(&b, "created: %s\n", meta.Created.UTC().Format(time.RFC3339))
+	}
+	if meta.Description != "" {
+		fmt.Fprintf(&b, "description: %s\n", quoteFrontMatter(meta.Description))
+	}
+	if meta.TTL > 0 {
+		fmt.Fprintf(&b, "ttl: %s\n", Duration(meta.TTL))
+	}
+	fmt.Fprintf(&b, "%s\n\n", frontMatterFence)
+	return b.Bytes()
+}
+
+// RenderReadme returns a README describing spec, with its metadata as front matter
+func RenderReadme(spec Spec) []byte {
+	b := bytes.NewBuffer(FrontMatter(spec))
+	fmt.Fprintf(b, "# %s\n\n", spec.Name)
+	if spec.Description != "" {
+		fmt.Fprintf(b, "%s\n\n", spec.Description)
+	}
+	fmt.Fprintf(b, "A %s scratch environment", spec.Type)
+	if !spec.Created.IsZero() {
+		fmt.Fprintf(b, " created on %s", spec.Created.Local().Format(time.DateOnly))
+	}
+	b.WriteString(". The block above is read back by `scratch adopt`.\n")
+	return b.Bytes()
+}
+
+// ParseReadme reads the metadata from the front matter of a README, and reports
+// false if it has none written by scratch
+func ParseReadme(data []byte) (ReadmeMeta, bool, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != frontMatterFence {
+		return ReadmeMeta{}, false, nil
+	}
+
+	fields := map[string]string{}
+	closed := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == frontMatterFence {
+			closed = true
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return ReadmeMeta{}, false, fmt.Errorf("invalid %s in front matter: %w", key, err)
+			}
+			value = unquoted
+		}
+		fields[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return ReadmeMeta{}, false, err
+	}
+	// Front matter of other tools has neither a name nor a type
+	if !closed || fields["name"] == "" || fields["type"] == "" {
+		return ReadmeMeta{}, false, nil
+	}
+
+	meta := ReadmeMeta{Name: fields["name"], Type: SpecType(fields["type"]), Description: fields["description"]}
+	if created := fields["created"]; created != "" {
+		t, err := time.Parse(time.RFC3339, created)
+		if err != nil {
+			return ReadmeMeta{}, false, fmt.Errorf("invalid created in front matter: %w", err)
+		}
+		meta.Created = t
+	}
+	if ttl := fields["ttl"]; ttl != "" {
+		d, err := ParseDuration(ttl)
+		if err != nil {
+			return ReadmeMeta{}, false, fmt.Errorf("invalid ttl in front matter: %w", err)
+		}
+		meta.TTL = d
+	}
+	return meta, true, nil
+}
+
+// ReadReadme reads the metadata from the README in dir, and reports false if
+// there is no README or it has no metadata
+func ReadReadme(dir string) (ReadmeMeta, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, readmeFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return ReadmeMeta{}, false, nil
+	}
+	if err != nil {
+		return ReadmeMeta{}, false, fmt.Errorf("read %s: %w", readmeFile, err)
+	}
+	meta, ok, err := ParseReadme(data)
+	if err != nil {
+		return ReadmeMeta{}, false, fmt.Errorf("%s: %w", readmeFile, err)
+	}
+	return meta, ok, nil
+}
+
+// WriteReadme writes the README of spec into its directory. A README already there,
+// like one from a template, keeps its content below the metadata, and is left
+// alone if it starts with front matter of its own. It reports if it wrote the README.
+// The description is left out when the store is encrypted.
+func WriteReadme(spec Spec) (bool, error) {
+	spec = redactSealed(spec)
+	path := filepath.Join(spec.Path, readmeFile)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		data = RenderReadme(spec)
+	case err != nil:
+		return false, fmt.Errorf("read %s: %w", readmeFile, err)
+	case bytes.HasPrefix(data, []byte(frontMatterFence)):
+		return false, nil
+	default:
+		data = append(FrontMatter(spec), data...)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, fmt.Errorf("write %s: %w", readmeFile, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,89 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReadme(t *testing.T) {
+	spec := main.NewSpec("described", main.DenoSpec, "/tmp")
+	spec.Created = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	spec.Expires = spec.Created.Add(14 * main.Day)
+	spec.Description = `trying: "quotes" # and colons`
+
+	meta, ok, err := main.ParseReadme(main.RenderReadme(spec))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, main.ReadmeMeta{Name: "described", Type: main.DenoSpec, Created: spec.Created, Description: spec.Description, TTL: 14 * main.Day}, meta)
+
+	for _, readme := range []string{"# Project\n", "---\ntitle: Jekyll\n---\n", "---\nname: unclosed\ntype: deno\n"} {
+		_, ok, err := main.ParseReadme([]byte(readme))
+		require.NoError(t, err)
+		require.False(t, ok, readme)
+	}
+
+	_, _, err = main.ParseReadme([]byte("---\nname: broken\ntype: deno\nttl: soon\n---\n"))
+	require.ErrorContains(t, err, "ttl")
+}
+
+func TestWriteReadme(t *testing.T) {
+	spec := main.NewSpec("described", main.DenoSpec, t.TempDir())
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+	wrote, err := main.WriteReadme(spec)
+	require.NoError(t, err)
+	require.True(t, wrote)
+	meta, ok, err := main.ReadReadme(spec.Path)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "described", meta.Name)
+
+	t.Run("existing", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Template\n"), 0644))
+		wrote, err := main.WriteReadme(main.Spec{Name: "templated", Type: main.DenoSpec, Path: dir})
+		require.NoError(t, err)
+		require.True(t, wrote)
+		data, err := os.ReadFile(filepath.Join(dir, "README.md"))
+		require.NoError(t, err)
+		require.Equal(t, "---\nname: templated\ntype: deno\n---\n\n# Template\n", string(data))
+
+		// Front matter of the template is kept as is
+		wrote, err = main.WriteReadme(main.Spec{Name: "other", Type: main.DenoSpec, Path: dir})
+		require.NoError(t, err)
+		require.False(t, wrote)
+	})
+}
+
+func TestAdoptCmd_Readme(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	ctx := &main.CLIContext{}
+
+	spec := main.NewSpec("described", main.DenoSpec, t.TempDir())
+	spec.Created = time.Now().Add(-time.Hour).Truncate(time.Second)
+	spec.Expires = spec.Created.Add(7 * main.Day)
+	spec.Description = "moved between machines"
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+	_, err := main.WriteReadme(spec)
+	require.NoError(t, err)
+	// The directory name is not the name in the README
+	moved := filepath.Join(filepath.Dir(spec.Path), "copy")
+	require.NoError(t, os.Rename(spec.Path, moved))
+
+	require.NoError(t, main.AdoptCmd{Path: moved}.Run(ctx))
+	store, err := ctx.Store()
+	require.NoError(t, err)
+	specs, err := main.LoadSpecs(store)
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+	require.Equal(t, "described", specs[0].Name)
+	require.Equal(t, main.DenoSpec, specs[0].Type)
+	require.Equal(t, spec.Description, specs[0].Description)
+	require.True(t, spec.Created.Equal(specs[0].Created))
+	require.True(t, spec.Expires.Equal(specs[0].Expires))
+}
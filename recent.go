@@ -0,0 +1,58 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LastActive returns when the environment was last used, or created if it was never opened
+func (s Spec) LastActive() time.Time {
+	if s.LastUsed.IsZero() {
+		return s.Created
+	}
+	return s.LastUsed
+}
+
+// SortByRecent sorts specs by when they were last active, most recent first
+func SortByRecent(specs []Spec) {
+	slices.SortStableFunc(specs, func(a, b Spec) int {
+		return cmp.Or(b.LastActive().Compare(a.LastActive()), cmp.Compare(a.ID(), b.ID()))
+	})
+}
+
+// SortByOpens sorts specs by how often they were opened, most first, then by recent use
+func SortByOpens(specs []Spec) {
+	slices.SortStableFunc(specs, func(a, b Spec) int {
+		return cmp.Or(cmp.Compare(b.Opens, a.Opens), b.LastActive().Compare(a.LastActive()))
+	})
+}
+
+// ParseRecentRef parses a reference like @2 to the position of an environment in
+// most recently used order, starting from 1
+func ParseRecentRef(ref string) (int, error) {
+	digits, ok := strings.CutPrefix(ref, "@")
+	if !ok {
+		return 0, fmt.Errorf("invalid reference %q, expected @N", ref)
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid reference %q, expected @N with N of at least 1", ref)
+	}
+	return n, nil
+}
+
+// RecentSpec returns the nth most recently used environment that is not archived or missing
+func RecentSpec(specs []Spec, n int) (Spec, error) {
+	live := slices.DeleteFunc(slices.Clone(specs), func(s Spec) bool {
+		return s.IsArchived() || !s.Exists()
+	})
+	SortByRecent(live)
+	if n > len(live) {
+		return Spec{}, fmt.Errorf("only %d environments to choose from, not @%d", len(live), n)
+	}
+	return live[n-1], nil
+}
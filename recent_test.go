@@ -0,0 +1,61 @@
+package main_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecentRef(t *testing.T) {
+	n, err := main.ParseRecentRef("@2")
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	for _, ref := range []string{"2", "@0", "@x", "@"} {
+		_, err := main.ParseRecentRef(ref)
+		require.Error(t, err, ref)
+	}
+}
+
+func TestRecentSpec(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	specs := []main.Spec{
+		{Name: "created", Type: main.PythonSpec, Path: dir, Created: now.Add(-time.Hour)},
+		{Name: "opened", Type: main.PythonSpec, Path: dir, Created: now.Add(-main.Week), LastUsed: now},
+		{Name: "archived", Type: main.PythonSpec, Path: dir, Archive: dir, LastUsed: now.Add(time.Minute)},
+		{Name: "missing", Type: main.PythonSpec, Path: filepath.Join(dir, "missing"), LastUsed: now.Add(time.Minute)},
+		{Name: "unknown", Type: main.PythonSpec, Path: dir},
+	}
+
+	names := []string{}
+	for n := 1; n <= 3; n++ {
+		spec, err := main.RecentSpec(specs, n)
+		require.NoError(t, err)
+		names = append(names, spec.Name)
+	}
+	require.Equal(t, []string{"opened", "created", "unknown"}, names)
+
+	_, err := main.RecentSpec(specs, 4)
+	require.Error(t, err)
+}
+
+func TestSortByOpens(t *testing.T) {
+	now := time.Now()
+	specs := []main.Spec{
+		{Name: "once", Opens: 1, LastUsed: now},
+		{Name: "never", Created: now},
+		{Name: "often", Opens: 5, LastUsed: now.Add(-main.Week)},
+		{Name: "once-earlier", Opens: 1, LastUsed: now.Add(-time.Hour)},
+	}
+	main.SortByOpens(specs)
+
+	names := []string{}
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+	require.Equal(t, []string{"often", "once", "once-earlier", "never"}, names)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RenameCmd represents the command to give an environment a new name
+type RenameCmd struct {
+	Query string `arg:"" help:"All or part of the name of environment, or @N for the Nth most recently used"`
+	To    string `arg:"" help:"The new name of environment"`
+}
+
+// Run renames the environment and its directory
+func (r RenameCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+
+	spec, err := ResolveQuery(store, r.Query)
+	if err != nil {
+		return err
+	}
+
+	renamed, err := RenameSpec(store, spec, r.To)
+	if err != nil {
+		return err
+	}
+
+	recordEvent(NewEvent(ActionRename, renamed).With("from", spec.ID()))
+
+	output.Success("Renamed %s to %s", spec.ID(), renamed.ID())
+	return nil
+}
+
+// RenameSpec renames spec in store, moving its directory too when it is named after the environment
+func RenameSpec(store Storer, spec Spec, name string) (Spec, error) {
+	if err := ValidateName(name); err != nil {
+		return Spec{}, err
+	}
+	if name == spec.Name {
+		return Spec{}, fmt.Errorf("environment is already called %q", name)
+	}
+
+	renamed := spec
+	renamed.Name = name
+	move := filepath.Base(spec.Path) == spec.Name && !spec.InPlace
+	if move {
+		renamed.Path = filepath.Join(filepath.Dir(spec.Path), name)
+	}
+	if err := checkDuplicate(store, renamed); err != nil {
+		return Spec{}, err
+	}
+
+	moved := false
+	if move && spec.Exists() {
+		if _, err := os.Stat(renamed.Path); err == nil {
+			return Spec{}, fmt.Errorf("directory %s %w", renamed.Path, ErrExists)
+		}
+		if err := os.Rename(spec.Path, renamed.Path); err != nil {
+			return Spec{}, fmt.Errorf("move environment: %w", err)
+		}
+		moved = true
+	}
+
+	// The old keys are replaced in one batch so the environment is never lost or
+	// tracked twice. The provisioning log and file hashes are kept by UID.
+	err := store.Batch(func(w Writer) error {
+		if err := w.Delete(spec.Key()); err != nil {
+			return err
+		}
+		if spec.UID != "" {
+			if err := w.Delete(spec.nameKey()); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+		}
+		return renamed.Save(w)
+	})
+	if err != nil {
+		if moved {
+			if err := os.Rename(renamed.Path, spec.Path); err != nil {
+				output.Warn("Could not move %s back to %s: %v", renamed.Path, spec.Path, err)
+			}
+		}
+		return Spec{}, err
+	}
+	refreshMarker(renamed)
+	return renamed, nil
+}
@@ -0,0 +1,41 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameSpec(t *testing.T) {
+	tdir := t.TempDir()
+	mw := NewMemoryStore()
+	spec := main.NewSpec("old", main.PythonSpec, tdir)
+	require.NoError(t, os.Mkdir(spec.Path, 0755))
+	require.NoError(t, spec.Save(mw))
+	require.NoError(t, main.SaveProvisionLog(mw, spec, main.ProvisionLog{}))
+
+	renamed, err := main.RenameSpec(mw, spec, "new")
+	require.NoError(t, err)
+	require.Equal(t, spec.UID, renamed.UID)
+	require.Equal(t, filepath.Join(tdir, "new"), renamed.Path)
+	require.DirExists(t, renamed.Path)
+	require.NoDirExists(t, spec.Path)
+
+	specs, err := main.LoadSpecs(mw)
+	require.NoError(t, err)
+	require.Equal(t, []main.Spec{renamed}, specs)
+	_, err = main.LoadProvisionLog(mw, renamed)
+	require.NoError(t, err)
+
+	found, err := main.FindSpecs(mw, main.PythonSpec, "old")
+	require.NoError(t, err)
+	require.Empty(t, found)
+
+	_, err = main.RenameSpec(mw, renamed, "new")
+	require.Error(t, err)
+	_, err = main.RenameSpec(mw, renamed, "a/b")
+	require.Error(t, err)
+}
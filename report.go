@@ -0,0 +1,251 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Priority orders findings in the health report
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityMedium
+	PriorityHigh
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "HIGH"
+	case PriorityMedium:
+		return "MEDIUM"
+	default:
+		return "LOW"
+	}
+}
+
+// Finding is a problem in the health report with the command that fixes it
+type Finding struct {
+	Priority Priority
+	Subject  string
+	Problem  string
+	Fix      string
+}
+
+// LastModified returns the latest modification time of files under dir,
+// skipping hidden and dependency directories that tools update on their own
+func LastModified(dir string) (time.Time, error) {
+	var latest time.Time
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
+
+// healthFindings checks every environment and the installation
+func healthFindings(ctx *CLIContext, stale time.Duration) ([]Finding, error) {
+	findings := []Finding{}
+
+	notReady := map[SpecType]bool{}
+	types := AllSpecTypes()
+	for i, err := range CheckReadiness(types, DefaultReadyTimeout) {
+		t := types[i]
+		if err != nil {
+			notReady[t] = true
+			findings = append(findings, Finding{PriorityHigh, "provisioner " + string(t), err.Error(), "scratch doctor"})
+		}
+	}
+
+	store, err := ctx.ReadOnlyStore()
+	if err != nil {
+		return nil, err
+	}
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, spec := range specs {
+		if spec.IsArchived() {
+			continue
+		}
+		name := fmt.Sprintf("--name %q --type %s", spec.Name, spec.Type)
+		if spec.IsExpired(time.Now()) {
+			findings = append(findings, Finding{PriorityMedium, spec.ID(), fmt.Sprintf("expired %s ago", FormatDuration(time.Since(spec.Expires))), "scratch prune --expired --apply"})
+		}
+		if !spec.Exists() {
+			findings = append(findings, Finding{PriorityHigh, spec.ID(), fmt.Sprintf("directory %s does not exist", spec.Path), "scratch doctor --fix"})
+			continue
+		}
+		// Environments of types that are not ready would all fail the same way
+		if err := CheckSpec(spec); err != nil && !notReady[spec.Type] {
+			findings = append(findings, Finding{PriorityMedium, spec.ID(), err.Error(), "scratch rebuild " + name})
+		}
+		if stale > 0 {
+			modified, err := LastModified(spec.Path)
+			if err != nil {
+				return nil, err
+			}
+			if age := time.Since(modified); age > stale {
+				findings = append(findings, Finding{PriorityLow, spec.ID(), fmt.Sprintf("not modified in %s", FormatDuration(age)), "scratch archive " + name})
+			}
+		}
+	}
+
+	orphans, err := findOrphans(ctx, specs)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range orphans {
+		findings = append(findings, Finding{PriorityLow, dir, "not tracked by any environment", fmt.Sprintf("scratch adopt %q", dir)})
+	}
+
+	archiveDir, err := DefaultArchiveDir()
+	if err != nil {
+		return nil, err
+	}
+	items, err := PlanGC(archiveDir, specs)
+	if err != nil {
+		return nil, err
+	}
+	var reclaimable ByteSize
+	for _, item := range items {
+		reclaimable += item.Size
+	}
+	if reclaimable > 0 {
+		findings = append(findings, Finding{PriorityLow, "archive", fmt.Sprintf("%s reclaimable", reclaimable), "scratch gc --apply"})
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+	return findings, nil
+}
+
+// YearSummary summarizes the history of one calendar year
+type YearSummary struct {
+	Year int
+	// Created counts environments created, cloned or adopted in each month
+	Created [12]int
+	Types   map[SpecType]int
+	Deleted int
+	// Freed is the disk space of deleted environments
+	Freed ByteSize
+}
+
+// SummarizeYear summarizes the events that happened in year
+func SummarizeYear(events []Event, year int) YearSummary {
+	summary := YearSummary{Year: year, Types: map[SpecType]int{}}
+	for _, event := range events {
+		if event.Time.Local().Year() != year {
+			continue
+		}
+		switch event.Action {
+		case ActionCreate, ActionClone, ActionAdopt:
+			summary.Created[event.Time.Local().Month()-1]++
+			summary.Types[event.Type]++
+		case ActionDelete:
+			summary.Deleted++
+			summary.Freed += event.Size
+		}
+	}
+	return summary
+}
+
+// TotalCreated returns the number of environments created in the year
+func (y YearSummary) TotalCreated() int {
+	total := 0
+	for _, n := range y.Created {
+		total += n
+	}
+	return total
+}
+
+// Print writes the summary with a bar per month
+func (y YearSummary) Print() {
+	fmt.Printf("Year in scratch %d\n\n", y.Year)
+	fmt.Printf("Created %d environments, deleted %d freeing %s\n\n", y.TotalCreated(), y.Deleted, y.Freed)
+
+	for i, n := range y.Created {
+		bar := fmt.Sprintf("%s %3d %s", time.Month(i + 1).String()[:3], n, strings.Repeat("#", n))
+		fmt.Println(strings.TrimSpace(bar))
+	}
+
+	types := slices.SortedFunc(maps.Keys(y.Types), func(a, b SpecType) int {
+		return cmp.Or(cmp.Compare(y.Types[b], y.Types[a]), cmp.Compare(a, b))
+	})
+	if len(types) > 0 {
+		fmt.Println("\nMost used types")
+		for _, t := range types {
+			fmt.Printf("%-10s %d\n", t, y.Types[t])
+		}
+	}
+}
+
+// ReportCmd represents the command to summarize the state of scratch
+type ReportCmd struct {
+	Health bool     `help:"Report problems with environments and how to fix them" xor:"report"`
+	Stale  Duration `help:"Report environments not modified for this long, 0 to disable" default:"30d"`
+	Year   bool     `help:"Summarize this year from the local history" xor:"report"`
+}
+
+// Validate checks a report was selected
+func (r ReportCmd) Validate() error {
+	if !r.Health && !r.Year {
+		return errors.New("must specify --health or --year")
+	}
+	return nil
+}
+
+// Run prints the selected report
+func (r ReportCmd) Run(ctx *CLIContext) error {
+	if r.Year {
+		path, err := DefaultHistoryPath()
+		if err != nil {
+			return err
+		}
+		events, err := LoadEvents(path)
+		if err != nil {
+			return err
+		}
+		SummarizeYear(events, time.Now().Year()).Print()
+		return nil
+	}
+
+	findings, err := healthFindings(ctx, time.Duration(r.Stale))
+	if err != nil {
+		return err
+	}
+
+	if len(findings) == 0 {
+		fmt.Println("No problems found")
+		return nil
+	}
+
+	for _, f := range findings {
+		fmt.Printf("%-6s  %s: %s\n", f.Priority, f.Subject, f.Problem)
+		fmt.Printf("        fix: %s\n", f.Fix)
+	}
+	return nil
+}
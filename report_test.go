@@ -0,0 +1,51 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastModified(t *testing.T) {
+	tdir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	recent := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tdir, ".venv"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tdir, "main.py"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tdir, ".venv", "lib.py"), nil, 0644))
+	require.NoError(t, os.Chtimes(filepath.Join(tdir, "main.py"), recent, recent))
+	for _, path := range []string{filepath.Join(tdir, ".venv", "lib.py"), filepath.Join(tdir, ".venv"), tdir} {
+		require.NoError(t, os.Chtimes(path, old, old))
+	}
+
+	modified, err := main.LastModified(tdir)
+	require.NoError(t, err)
+	require.True(t, modified.Equal(recent), modified)
+}
+
+func TestSummarizeYear(t *testing.T) {
+	at := func(year int, month time.Month) time.Time {
+		return time.Date(year, month, 15, 12, 0, 0, 0, time.Local)
+	}
+	events := []main.Event{
+		{Time: at(2025, time.December), Action: main.ActionCreate, Type: main.PythonSpec},
+		{Time: at(2026, time.January), Action: main.ActionCreate, Type: main.PythonSpec},
+		{Time: at(2026, time.January), Action: main.ActionClone, Type: main.PythonSpec},
+		{Time: at(2026, time.March), Action: main.ActionAdopt, Type: "node"},
+		{Time: at(2026, time.March), Action: main.ActionDelete, Type: main.PythonSpec, Size: 100},
+		{Time: at(2026, time.April), Action: main.ActionDelete, Type: main.PythonSpec, Size: 50},
+	}
+
+	summary := main.SummarizeYear(events, 2026)
+	require.Equal(t, 2, summary.Created[0])
+	require.Equal(t, 1, summary.Created[2])
+	require.Equal(t, 3, summary.TotalCreated())
+	require.Equal(t, map[main.SpecType]int{main.PythonSpec: 2, "node": 1}, summary.Types)
+	require.Equal(t, 2, summary.Deleted)
+	require.Equal(t, main.ByteSize(150), summary.Freed)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// shellQuote quotes arg for POSIX shells, leaving words that need no quoting as they are
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// shellLine joins args into a command line for POSIX shells
+func shellLine(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// NewArgs returns the arguments of scratch new creating an environment like s,
+// in the same directory and from the same template. Environments created with
+// --here are created in the working directory, so they have to be run from it.
+func (s Spec) NewArgs() []string {
+	args := []string{"new", s.Name, "--type", string(s.Type)}
+	if s.InPlace {
+		args = append(args, "--here")
+	} else if s.Path != "" {
+		args = append(args, "--directory", filepath.Dir(s.Path))
+	}
+	switch {
+	case s.Template == "":
+	case s.Type == TemplateSpec:
+		args = append(args, "--from", string(s.Template))
+	case s.Type == CookiecutterSpec || s.Type == CopierSpec:
+		args = append(args, "--template", string(s.Template))
+	}
+	if s.Framework != "" {
+		args = append(args, "--framework", s.Framework)
+	}
+	if s.Parent != "" {
+		args = append(args, "--for", s.Parent)
+	}
+	if s.Description != "" {
+		args = append(args, "--description", s.Description)
+	}
+	if !s.Expires.IsZero() && !s.Created.IsZero() && s.Expires.After(s.Created) {
+		args = append(args, "--ttl", FormatDuration(s.Expires.Sub(s.Created)))
+	}
+	if s.Locked {
+		args = append(args, "--locked")
+	}
+	return args
+}
+
+// Reproduce returns the shell commands recreating s: scratch new, then scratch
+// edit for its tags and metadata. What scratch does not record, like values of
+// .env variables, is listed in comments, along with the versions of the tools
+// in log it was provisioned with.
+func (s Spec) Reproduce(log ProvisionLog) string {
+	var b strings.Builder
+	if len(log.Tools) > 0 {
+		tools := []string{}
+		for _, tool := range slices.Sorted(maps.Keys(log.Tools)) {
+			tools = append(tools, tool+": "+log.Tools[tool])
+		}
+		fmt.Fprintf(&b, "# provisioned with %s\n", strings.Join(tools, "; "))
+	}
+	if s.InPlace {
+		fmt.Fprintf(&b, "cd %s\n", shellQuote(s.Path))
+	}
+	b.WriteString(shellLine(append([]string{"scratch"}, s.NewArgs()...)...) + "\n")
+
+	edit := []string{}
+	for _, tag := range s.Tags {
+		edit = append(edit, "--add-tag", tag)
+	}
+	for _, key := range slices.Sorted(maps.Keys(s.Meta)) {
+		edit = append(edit, "--set-meta", key+"="+s.Meta[key])
+	}
+	if len(edit) > 0 {
+		b.WriteString(shellLine(append([]string{"scratch", "edit", "--match", s.Name, "--force"}, edit...)...) + "\n")
+	}
+	if len(s.Env) > 0 {
+		fmt.Fprintf(&b, "# .env also set %s, add them with --env KEY=VALUE\n", strings.Join(s.Env, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package main_test
+
+import (
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpec_Reproduce(t *testing.T) {
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	spec := main.Spec{
+		Name:        "demo",
+		Type:        main.PythonSpec,
+		Path:        "/tmp/scratch/demo",
+		Tags:        []string{"ml"},
+		Meta:        map[string]string{"owner": "me"},
+		Description: "it's a test",
+		Created:     created,
+		Expires:     created.Add(14 * main.Day),
+		Env:         []string{"API_KEY"},
+		Locked:      true,
+	}
+	log := main.ProvisionLog{Tools: map[string]string{"uv": "uv 0.4.0", "python": "Python 3.12.1"}}
+
+	assert.Equal(t, `# provisioned with python: Python 3.12.1; uv: uv 0.4.0
+scratch new demo --type python --directory /tmp/scratch --description 'it'\''s a test' --ttl 14d --locked
+scratch edit --match demo --force --add-tag ml --set-meta owner=me
+# .env also set API_KEY, add them with --env KEY=VALUE
+`, spec.Reproduce(log))
+}
+
+func TestSpec_NewArgs(t *testing.T) {
+	here := main.Spec{Name: "tool", Type: main.PythonSpec, Path: "/src/repo/tool", InPlace: true, Parent: "/src/repo"}
+	assert.Equal(t, []string{"new", "tool", "--type", "python", "--here", "--for", "/src/repo"}, here.NewArgs())
+
+	copier := main.Spec{Name: "app", Type: main.CopierSpec, Path: "/tmp/app", Template: "gh:user/template"}
+	assert.Equal(t, []string{"new", "app", "--type", "copier", "--directory", "/tmp", "--template", "gh:user/template"}, copier.NewArgs())
+
+	template := main.Spec{Name: "site", Type: main.TemplateSpec, Path: "/tmp/site", Template: "gh:user/site"}
+	assert.Equal(t, []string{"new", "site", "--type", "template", "--directory", "/tmp", "--from", "gh:user/site"}, template.NewArgs())
+}
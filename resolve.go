@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Match quality of a query against a name, from best to worst
+const (
+	matchNone = iota
+	matchSubsequence
+	matchSubstring
+	matchPrefix
+	matchExact
+)
+
+// matchName rates how well query matches name, ignoring case
+func matchName(query string, name string) int {
+	query, name = strings.ToLower(query), strings.ToLower(name)
+	switch {
+	case query == name:
+		return matchExact
+	case strings.HasPrefix(name, query):
+		return matchPrefix
+	case strings.Contains(name, query):
+		return matchSubstring
+	}
+
+	// Every character of query appears in order, like "pdt" in "pandas-test"
+	rest := name
+	for _, r := range query {
+		i := strings.IndexRune(rest, r)
+		if i < 0 {
+			return matchNone
+		}
+		rest = rest[i+len(string(r)):]
+	}
+	return matchSubsequence
+}
+
+// MatchSpecs returns the specs whose names best match query. Only the best kind
+// of match is returned, so an exact name wins over names merely containing it.
+func MatchSpecs(specs []Spec, query string) []Spec {
+	best := matchNone
+	matches := []Spec{}
+	for _, spec := range specs {
+		quality := matchName(query, spec.Name)
+		switch {
+		case quality == matchNone || quality < best:
+			continue
+		case quality > best:
+			best = quality
+			matches = matches[:0]
+		}
+		matches = append(matches, spec)
+	}
+	return matches
+}
+
+// ResolveQuery finds the environment referred to by query, which is either @N for
+// the Nth most recently used environment or all or part of a name
+func ResolveQuery(store ReadLister, query string) (Spec, error) {
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	if strings.HasPrefix(query, "@") {
+		n, err := ParseRecentRef(query)
+		if err != nil {
+			return Spec{}, err
+		}
+		return RecentSpec(specs, n)
+	}
+
+	matches := MatchSpecs(specs, query)
+	if len(matches) == 0 {
+		return Spec{}, fmt.Errorf("no environment matches %q: %w", query, ErrNotFound)
+	}
+	return chooseSpec(fmt.Sprintf("environments match %q", query), matches)
+}
+
+// chooseSpec returns the only spec or asks which one is meant, failing with the
+// candidates when not run in a terminal or when output is captured, like by cd $(scratch path foo)
+func chooseSpec(what string, specs []Spec) (Spec, error) {
+	if len(specs) == 1 {
+		return specs[0], nil
+	}
+
+	if !canPrompt() || !IsTerminal(os.Stdout) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d %s, specify --id with one of:", len(specs), what)
+		for _, spec := range specs {
+			fmt.Fprintf(&b, "\n  %s  %s  %s", spec.UID, spec.ID(), spec.Path)
+		}
+		return Spec{}, fmt.Errorf("%w: %s", ErrAmbiguous, b.String())
+	}
+
+	options := make([]string, len(specs))
+	for i, spec := range specs {
+		options[i] = fmt.Sprintf("%s  %s", spec.ID(), spec.Path)
+	}
+	i, err := askForChoice(fmt.Sprintf("%d %s:", len(specs), what), options)
+	if err != nil {
+		return Spec{}, err
+	}
+	return specs[i], nil
+}
@@ -0,0 +1,51 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func specNames(specs []main.Spec) []string {
+	names := []string{}
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+	return names
+}
+
+func TestMatchSpecs(t *testing.T) {
+	specs := []main.Spec{
+		{Name: "pandas-test"},
+		{Name: "polars-test"},
+		{Name: "Pan"},
+		{Name: "experiment"},
+	}
+
+	require.Equal(t, []string{"Pan"}, specNames(main.MatchSpecs(specs, "pan")))
+	require.Equal(t, []string{"pandas-test"}, specNames(main.MatchSpecs(specs, "pand")))
+	require.Equal(t, []string{"pandas-test", "polars-test"}, specNames(main.MatchSpecs(specs, "test")))
+	require.Equal(t, []string{"polars-test"}, specNames(main.MatchSpecs(specs, "plrs")))
+	require.Empty(t, main.MatchSpecs(specs, "rust"))
+}
+
+func TestResolveQuery(t *testing.T) {
+	tdir := t.TempDir()
+	mw := NewMemoryStore()
+	for _, name := range []string{"pandas-test", "polars-test"} {
+		require.NoError(t, main.NewSpec(name, main.PythonSpec, tdir).Save(mw))
+	}
+
+	spec, err := main.ResolveQuery(mw, "pand")
+	require.NoError(t, err)
+	require.Equal(t, "pandas-test", spec.Name)
+
+	_, err = main.ResolveQuery(mw, "rust")
+	require.ErrorIs(t, err, main.ErrNotFound)
+
+	// Tests do not run in a terminal, so ambiguous queries list the candidates
+	_, err = main.ResolveQuery(mw, "test")
+	require.ErrorContains(t, err, "2 environments match")
+	require.ErrorContains(t, err, "python:polars-test")
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// EnvironmentCommand returns cmd set up to run in the directory of spec with
+// the variables of its .env file, which take precedence over inherited ones
+func EnvironmentCommand(spec Spec, name string, args ...string) (*exec.Cmd, error) {
+	if !spec.Exists() {
+		return nil, fmt.Errorf("directory %s does not exist", spec.Path)
+	}
+	env, err := LoadDotEnv(spec.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Dir = spec.Path
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd, nil
+}
+
+// runInEnvironment runs a program interactively in the environment and marks it used,
+// exiting with the status of the program when it fails
+func runInEnvironment(ctx *CLIContext, store Writer, spec Spec, name string, args ...string) error {
+	cmd, err := EnvironmentCommand(spec, name, args...)
+	if err != nil {
+		return err
+	}
+
+	spec, err = markUsed(store, spec, name)
+	if err != nil {
+		return err
+	}
+	// The program may run scratch itself, so the store must not stay locked
+	if err := ctx.Close(); err != nil {
+		return err
+	}
+
+	slog.Debug("Running in environment", slog.String("id", spec.ID()), slog.String("command", strings.Join(cmd.Args, " ")))
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &CodedError{fmt.Sprintf("%s: %s", name, exitErr), exitErr.ExitCode()}
+	}
+	if err != nil {
+		return fmt.Errorf("run %s: %w", name, err)
+	}
+	return nil
+}
+
+// RunCmd represents the command to run a program inside an environment
+type RunCmd struct {
+	Query   string   `arg:"" help:"All or part of the name of environment, or @N for the Nth most recently used"`
+	Command []string `arg:"" passthrough:"" help:"The program to run and its arguments"`
+}
+
+// Run runs the program in the environment directory with its .env loaded
+func (r RunCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	spec, err := ResolveQuery(store, r.Query)
+	if err != nil {
+		return err
+	}
+	command := r.Command
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+	if len(command) == 0 {
+		return fmt.Errorf("must specify a program to run")
+	}
+	return runInEnvironment(ctx, store, spec, command[0], command[1:]...)
+}
+
+// ShellCmd represents the command to start a shell inside an environment
+type ShellCmd struct {
+	Query string `arg:"" help:"All or part of the name of environment, or @N for the Nth most recently used"`
+}
+
+// userShell returns the interactive shell of the user
+func userShell() string {
+	if runtime.GOOS == "windows" {
+		if shell := os.Getenv("COMSPEC"); shell != "" {
+			return shell
+		}
+		return "cmd.exe"
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// Run starts the shell of the user in the environment directory with its .env loaded
+func (s ShellCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	spec, err := ResolveQuery(store, s.Query)
+	if err != nil {
+		return err
+	}
+	output.Info("Starting a shell in %s, exit it to return", spec.ID())
+	return runInEnvironment(ctx, store, spec, userShell())
+}
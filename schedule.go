@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// The scheduled prune runs as a user job of the service manager of the platform
+const (
+	scheduleUnit  = "scratch-prune"
+	scheduleLabel = "com.github.chargeflux.scratch.prune"
+	scheduleTask  = "scratch prune"
+	// scheduleHour is when the job runs, in local time
+	scheduleHour = 10
+)
+
+// Schedules are how often the scheduled prune runs
+const (
+	DailySchedule  = "daily"
+	WeeklySchedule = "weekly"
+)
+
+// systemdQuote quotes arg for ExecStart, which splits on spaces and expands %
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// SystemdUnits returns the user service running args and the timer starting it on
+// schedule, at 10:00 and on Mondays when weekly
+func SystemdUnits(schedule string, args []string) (service string, timer string) {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = systemdQuote(arg)
+	}
+	calendar := fmt.Sprintf("*-*-* %02d:00:00", scheduleHour)
+	if schedule == WeeklySchedule {
+		calendar = "Mon " + calendar
+	}
+	service = fmt.Sprintf(`[Unit]
+Description=Delete expired scratch environments
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, strings.Join(quoted, " "))
+	timer = fmt.Sprintf(`[Unit]
+Description=Delete expired scratch environments %s
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, schedule, calendar)
+	return service, timer
+}
+
+// xmlEscape escapes s for the text of an XML element
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// LaunchdPlist returns the launch agent running args on schedule, at 10:00 and on Mondays when weekly
+func LaunchdPlist(schedule string, args []string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + scheduleLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, arg := range args {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("\t</array>\n\t<key>StartCalendarInterval</key>\n\t<dict>\n")
+	if schedule == WeeklySchedule {
+		b.WriteString("\t\t<key>Weekday</key>\n\t\t<integer>1</integer>\n")
+	}
+	fmt.Fprintf(&b, "\t\t<key>Hour</key>\n\t\t<integer>%d</integer>\n\t\t<key>Minute</key>\n\t\t<integer>0</integer>\n\t</dict>\n</dict>\n</plist>\n", scheduleHour)
+	return b.String()
+}
+
+// windowsQuote quotes arg for the command line of a scheduled task
+func windowsQuote(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
+
+// SchtasksCreateArgs returns the arguments of schtasks creating the task running args on schedule
+func SchtasksCreateArgs(schedule string, args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = windowsQuote(arg)
+	}
+	return []string{"/Create", "/F", "/TN", scheduleTask, "/SC", strings.ToUpper(schedule), "/ST", fmt.Sprintf("%02d:00", scheduleHour), "/TR", strings.Join(quoted, " ")}
+}
+
+// runScheduler runs a command of the service manager
+func runScheduler(name string, args ...string) error {
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s %s: %s: %w", name, strings.Join(args, " "), strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// schedulePaths returns the files describing the scheduled job on this platform
+func schedulePaths() ([]string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		return []string{filepath.Join(home, "Library", "LaunchAgents", scheduleLabel+".plist")}, nil
+	case "windows", "plan9":
+		return nil, nil
+	default:
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(dir, "systemd", "user")
+		return []string{filepath.Join(dir, scheduleUnit+".service"), filepath.Join(dir, scheduleUnit+".timer")}, nil
+	}
+}
+
+// InstallSchedule installs a job running args on schedule and returns where it was installed
+func InstallSchedule(schedule string, args []string) (string, error) {
+	paths, err := schedulePaths()
+	if err != nil {
+		return "", err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		if err := EnsureDirectory(filepath.Dir(paths[0])); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(paths[0], []byte(LaunchdPlist(schedule, args)), 0644); err != nil {
+			return "", fmt.Errorf("write launch agent: %w", err)
+		}
+		// Loading fails if an older version of the agent is still loaded
+		runScheduler("launchctl", "unload", paths[0])
+		return paths[0], runScheduler("launchctl", "load", "-w", paths[0])
+	case "windows":
+		return "Task Scheduler as " + scheduleTask, runScheduler("schtasks", SchtasksCreateArgs(schedule, args)...)
+	case "plan9":
+		return "", fmt.Errorf("no scheduler on %s", runtime.GOOS)
+	default:
+		if err := EnsureDirectory(filepath.Dir(paths[0])); err != nil {
+			return "", err
+		}
+		service, timer := SystemdUnits(schedule, args)
+		if err := os.WriteFile(paths[0], []byte(service), 0644); err != nil {
+			return "", fmt.Errorf("write systemd service: %w", err)
+		}
+		if err := os.WriteFile(paths[1], []byte(timer), 0644); err != nil {
+			return "", fmt.Errorf("write systemd timer: %w", err)
+		}
+		if err := runScheduler("systemctl", "--user", "daemon-reload"); err != nil {
+			return "", err
+		}
+		return paths[1], runScheduler("systemctl", "--user", "enable", "--now", scheduleUnit+".timer")
+	}
+}
+
+// RemoveSchedule stops and removes the job installed by InstallSchedule
+func RemoveSchedule() error {
+	paths, err := schedulePaths()
+	if err != nil {
+		return err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		runScheduler("launchctl", "unload", "-w", paths[0])
+	case "windows":
+		return runScheduler("schtasks", "/Delete", "/F", "/TN", scheduleTask)
+	case "plan9":
+		return fmt.Errorf("no scheduler on %s", runtime.GOOS)
+	default:
+		// The timer may be missing already, which is what removing it is for
+		runScheduler("systemctl", "--user", "disable", "--now", scheduleUnit+".timer")
+	}
+
+	removed := false
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err == nil {
+			removed = true
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if !removed {
+		return fmt.Errorf("no scheduled prune is installed: %w", ErrNotFound)
+	}
+	if runtime.GOOS != "darwin" {
+		return runScheduler("systemctl", "--user", "daemon-reload")
+	}
+	return nil
+}
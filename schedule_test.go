@@ -0,0 +1,33 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+var scheduledArgs = []string{"/opt/my tools/scratch", "prune", "--expired", "--apply", "--force"}
+
+func TestSystemdUnits(t *testing.T) {
+	service, timer := main.SystemdUnits(main.WeeklySchedule, scheduledArgs)
+	require.Contains(t, service, "ExecStart=\"/opt/my tools/scratch\" prune --expired --apply --force\n")
+	require.Contains(t, timer, "OnCalendar=Mon *-*-* 10:00:00\n")
+
+	_, timer = main.SystemdUnits(main.DailySchedule, []string{"/bin/scratch"})
+	require.Contains(t, timer, "OnCalendar=*-*-* 10:00:00\n")
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	plist := main.LaunchdPlist(main.WeeklySchedule, append(scheduledArgs, "--workspace", "a&b"))
+	require.Contains(t, plist, "<string>/opt/my tools/scratch</string>")
+	require.Contains(t, plist, "<string>a&amp;b</string>")
+	require.Contains(t, plist, "<key>Weekday</key>")
+
+	require.NotContains(t, main.LaunchdPlist(main.DailySchedule, scheduledArgs), "Weekday")
+}
+
+func TestSchtasksCreateArgs(t *testing.T) {
+	require.Equal(t, []string{"/Create", "/F", "/TN", "scratch prune", "/SC", "DAILY", "/ST", "10:00", "/TR", `"/opt/my tools/scratch" prune --expired --apply --force`},
+		main.SchtasksCreateArgs(main.DailySchedule, scheduledArgs))
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SpecSchema is the version of the spec format written by Save. Specs saved before
+// versioning have no Schema field and are version 0.
+const SpecSchema = 1
+
+// specMigrations upgrade the fields of a decoded spec by one version, so
+// specMigrations[v] turns version v into version v+1
+var specMigrations = []func(fields map[string]json.RawMessage) error{
+	migrateSpecV0,
+}
+
+// specRecord is a spec as saved in the store, stamped with its schema version
+type specRecord struct {
+	Schema int
+	Spec
+}
+
+// marshalSpec encodes spec with the current schema version, sealed if the store is encrypted
+func marshalSpec(spec Spec) ([]byte, error) {
+	data, err := json.MarshalIndent(specRecord{Schema: SpecSchema, Spec: spec}, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	return sealSpec(data)
+}
+
+// specVersion returns the schema version of an encoded spec
+func specVersion(fields map[string]json.RawMessage) (int, error) {
+	raw, ok := fields["Schema"]
+	if !ok {
+		return 0, nil
+	}
+	var version int
+	if err := json.Unmarshal(raw, &version); err != nil {
+		return 0, fmt.Errorf("invalid schema version %s: %w", raw, err)
+	}
+	return version, nil
+}
+
+// decodeSpec decodes data and applies the migrations from its schema version to
+// the current one, returning the version it was saved with
+func decodeSpec(data []byte) (Spec, int, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Spec{}, 0, fmt.Errorf("unmarshal spec: %w", err)
+	}
+	if sealed, ok := fields["Encrypted"]; ok {
+		data, err := unsealSpec(sealed)
+		if err != nil {
+			return Spec{}, 0, err
+		}
+		return decodeSpec(data)
+	}
+
+	version, err := specVersion(fields)
+	if err != nil {
+		return Spec{}, 0, err
+	}
+	if version > SpecSchema {
+		return Spec{}, version, fmt.Errorf("spec has schema version %d but this version of scratch reads up to %d, upgrade scratch", version, SpecSchema)
+	}
+	for v := version; v < SpecSchema; v++ {
+		if err := specMigrations[v](fields); err != nil {
+			return Spec{}, version, fmt.Errorf("migrate spec from schema version %d: %w", v, err)
+		}
+	}
+	delete(fields, "Schema")
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return Spec{}, version, fmt.Errorf("marshal migrated spec: %w", err)
+	}
+	var s Spec
+	if err := json.Unmarshal(migrated, &s); err != nil {
+		return Spec{}, version, fmt.Errorf("unmarshal spec: %w", err)
+	}
+	return s, version, nil
+}
+
+// migrateSpecV0 cleans up tags of specs saved before versioning, which could be
+// edited by hand in the JSON store: tags are trimmed and empty or repeated tags dropped
+func migrateSpecV0(fields map[string]json.RawMessage) error {
+	raw, ok := fields["Tags"]
+	if !ok {
+		return nil
+	}
+	var tags []string
+	if err := json.Unmarshal(raw, &tags); err != nil {
+		return fmt.Errorf("decode tags: %w", err)
+	}
+
+	cleaned := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(cleaned, tag) {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	if len(cleaned) == 0 {
+		delete(fields, "Tags")
+		return nil
+	}
+
+	data, err := json.Marshal(cleaned)
+	if err != nil {
+		return err
+	}
+	fields["Tags"] = data
+	return nil
+}
+
+// MigrateSpecs saves every spec stored with an older schema version in the current one
+func MigrateSpecs(store Storer) (int, error) {
+	outdated := []Spec{}
+	err := store.ListFunc(func(key string, data []byte) error {
+		if !isSpecKey(key) {
+			return nil
+		}
+		spec, version, err := decodeSpec(data)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", key, err)
+		}
+		if version < SpecSchema {
+			outdated = append(outdated, spec)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, spec := range outdated {
+		if err := spec.Save(store); err != nil {
+			return 0, fmt.Errorf("save %s: %w", spec.ID(), err)
+		}
+	}
+	return len(outdated), nil
+}
@@ -0,0 +1,80 @@
+package main_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSpec_Fixtures(t *testing.T) {
+	cases := []struct {
+		fixture string
+		want    main.Spec
+	}{
+		{"v0.json", main.Spec{
+			Name: "analysis", Type: main.PythonSpec, Path: "/home/user/scratch/analysis",
+			Tags: []string{"ml", "pandas"}, Meta: map[string]string{"ticket": "DATA-12"},
+		}},
+		{"v0_ulid.json", main.Spec{
+			UID: "01JBX4ZQ7N3D9V5K2M8R6T1W0Y", Name: "site", Type: main.DenoSpec, Path: "/home/user/scratch/site",
+			Description: "landing page",
+			Created:     time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC),
+			LastUsed:    time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC),
+		}},
+		{"v1.json", main.Spec{
+			UID: "01JBX52K4E8QG0S6HC3VJ7PN1A", Name: "paper", Type: main.LatexSpec, Path: "/home/user/scratch/paper",
+			Tags: []string{"draft"}, Created: time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC),
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "specs", c.fixture))
+			require.NoError(t, err)
+
+			spec, err := main.LoadSpec(data)
+			require.NoError(t, err)
+			require.Equal(t, c.want, spec)
+
+			// Saving writes the current schema and loads back unchanged
+			store := NewMemoryStore()
+			require.NoError(t, spec.Save(store))
+			var saved struct{ Schema int }
+			require.NoError(t, json.Unmarshal(store.Data[spec.Key()], &saved))
+			require.Equal(t, main.SpecSchema, saved.Schema)
+
+			loaded, err := main.LookupSpec(store, spec.Key())
+			require.NoError(t, err)
+			require.Equal(t, spec, loaded)
+		})
+	}
+}
+
+func TestLoadSpec_NewerSchema(t *testing.T) {
+	_, err := main.LoadSpec([]byte(`{"Schema": 99, "Name": "test", "Type": "python"}`))
+	require.ErrorContains(t, err, "upgrade scratch")
+}
+
+func TestMigrateSpecs(t *testing.T) {
+	store := NewMemoryStore()
+	for _, fixture := range []string{"v0_ulid.json", "v1.json"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "specs", fixture))
+		require.NoError(t, err)
+		spec, err := main.LoadSpec(data)
+		require.NoError(t, err)
+		store.Data[spec.Key()] = data
+	}
+
+	count, err := main.MigrateSpecs(store)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Contains(t, string(store.Data["spec/01JBX4ZQ7N3D9V5K2M8R6T1W0Y"]), `"Schema": 1`)
+
+	count, err = main.MigrateSpecs(store)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
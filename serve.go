@@ -0,0 +1,401 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Server serves the environments over HTTP for editor extensions and launchers.
+// Requests run one at a time, and the store is released after each so commands
+// run in a terminal meanwhile are not locked out.
+type Server struct {
+	ctx *CLIContext
+	mu  sync.Mutex
+	mux *http.ServeMux
+	// token authenticates requests, which send it as a bearer token
+	token string
+}
+
+// NewServer returns a server running requests with ctx that carry token
+func NewServer(ctx *CLIContext, token string) *Server {
+	s := &Server{ctx: ctx, mux: http.NewServeMux(), token: token}
+	s.mux.HandleFunc("GET /environments", s.handle(s.list))
+	s.mux.HandleFunc("POST /environments", s.handle(s.create))
+	s.mux.HandleFunc("GET /environments/{id}", s.handle(s.get))
+	s.mux.HandleFunc("DELETE /environments/{id}", s.handle(s.delete))
+	s.mux.HandleFunc("POST /environments/{id}/open", s.handle(s.open))
+	return s
+}
+
+// ServeHTTP routes the request to its endpoint once it is checked to come from
+// a client on this machine holding the token
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status, err := s.authorize(r); err != nil {
+		slog.Debug("Refused request", slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.Any("error", err))
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorize checks the request was sent to a loopback address, which a page
+// rebinding its domain to this machine cannot fake, with the token and a JSON
+// body, which forms cannot send
+func (s *Server) authorize(r *http.Request) (int, error) {
+	if !isLoopbackHost(r.Host) {
+		return http.StatusForbidden, fmt.Errorf("host %q is not a loopback address", r.Host)
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		return http.StatusUnauthorized, errors.New("missing or wrong token")
+	}
+	if r.Method == http.MethodPost {
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			return http.StatusUnsupportedMediaType, errors.New("content type must be application/json")
+		}
+	}
+	return 0, nil
+}
+
+// isLoopbackHost checks host, with or without a port, is localhost or a loopback IP
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// errBadRequest marks errors in the request rather than in handling it
+var errBadRequest = errors.New("bad request")
+
+// httpStatus returns the status code responding with err
+func httpStatus(err error) int {
+	if errors.Is(err, errBadRequest) {
+		return http.StatusBadRequest
+	}
+	var preflight PreflightError
+	if errors.As(err, &preflight) {
+		return http.StatusUnprocessableEntity
+	}
+	var coded *CodedError
+	if !errors.As(err, &coded) {
+		return http.StatusInternalServerError
+	}
+	switch coded.ExitCode() {
+	case ExitNotFound:
+		return http.StatusNotFound
+	case ExitExists, ExitAmbiguous:
+		return http.StatusConflict
+	case ExitPolicy:
+		return http.StatusForbidden
+	case ExitProvisioner:
+		return http.StatusUnprocessableEntity
+	case ExitStore, ExitLocked:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// writeJSON responds with status and v encoded as JSON
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("Writing response failed", slog.Any("error", err))
+	}
+}
+
+// handle runs endpoint with the lock held and responds with its result, or with
+// no content when it returns nil
+func (s *Server) handle(endpoint func(r *http.Request) (int, any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		defer s.ctx.Close()
+
+		start := time.Now()
+		status, v, err := endpoint(r)
+		if err != nil {
+			status = httpStatus(err)
+			v = map[string]string{"error": err.Error()}
+		}
+		slog.Debug("Served request", slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.Int("status", status), slog.Duration("duration", time.Since(start)))
+		if v == nil {
+			w.WriteHeader(status)
+			return
+		}
+		writeJSON(w, status, v)
+	}
+}
+
+// list responds with the environments, filtered by the type and tag parameters.
+// Hidden environments are included with all=true.
+func (s *Server) list(r *http.Request) (int, any, error) {
+	store, err := s.ctx.Store()
+	if err != nil {
+		return 0, nil, err
+	}
+	query := r.URL.Query()
+	specType := SpecType(query.Get("type"))
+	specs, err := LoadSpecsOfType(store, specType)
+	if err != nil {
+		return 0, nil, err
+	}
+	specs = FilterFlags{Tag: query["tag"]}.Filter(specType).Apply(specs, time.Now())
+	if query.Get("all") != "true" {
+		specs = slices.DeleteFunc(specs, func(spec Spec) bool { return spec.Hidden })
+	}
+	return http.StatusOK, specs, nil
+}
+
+// get responds with the environment identified by its ULID or type:name ID
+func (s *Server) get(r *http.Request) (int, any, error) {
+	store, err := s.ctx.Store()
+	if err != nil {
+		return 0, nil, err
+	}
+	spec, err := LookupID(store, r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, spec, nil
+}
+
+// CreateRequest is the body of a request creating an environment, with the
+// meaning of the flags of scratch new
+type CreateRequest struct {
+	Name        string            `json:"name"`
+	Type        SpecType          `json:"type,omitempty"`
+	Directory   string            `json:"directory,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Vars        map[string]string `json:"vars,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	TTL         Duration          `json:"ttl,omitempty"`
+	From        string            `json:"from,omitempty"`
+	Template    string            `json:"template,omitempty"`
+	Locked      bool              `json:"locked,omitempty"`
+	// Open is the program opening the environment once created, which is not opened when empty
+	Open string `json:"open,omitempty"`
+}
+
+// decodeBody decodes the JSON body of r into v, which may be empty when optional
+func decodeBody(r *http.Request, v any, optional bool) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if optional && errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: decode body: %w", errBadRequest, err)
+	}
+	return nil
+}
+
+// create creates an environment like scratch new and responds with it
+func (s *Server) create(r *http.Request) (int, any, error) {
+	var req CreateRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		return 0, nil, err
+	}
+	if req.Name == "" {
+		return 0, nil, fmt.Errorf("%w: name is required", errBadRequest)
+	}
+	if err := checkOpener(req.Open); err != nil {
+		return 0, nil, err
+	}
+
+	cmd := NewCmd{
+		Name:        req.Name,
+		Type:        cmp.Or(req.Type, PythonSpec),
+		Directory:   req.Directory,
+		Description: req.Description,
+		Vars:        req.Vars,
+		Env:         req.Env,
+		TTL:         req.TTL,
+		From:        req.From,
+		Template:    req.Template,
+		Locked:      req.Locked,
+		Open:        req.Open,
+		NoOpen:      req.Open == "",
+	}
+	spec, err := cmd.Create(s.ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, spec, nil
+}
+
+// delete deletes the environment like scratch delete --force, keeping locked
+// environments unless force=true
+func (s *Server) delete(r *http.Request) (int, any, error) {
+	query := r.URL.Query()
+	cmd := DeleteCmd{
+		IdentifyFlags: IdentifyFlags{ID: r.PathValue("id")},
+		Force:         true,
+		Permanent:     query.Get("permanent") == "true",
+		IncludeLocked: query.Get("force") == "true",
+	}
+	if err := cmd.Run(s.ctx); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+// OpenRequest is the body of a request opening an environment
+type OpenRequest struct {
+	// Program opens the folder, code by default
+	Program string `json:"program,omitempty"`
+}
+
+// checkOpener checks program can open folders for a request, as sessions taking
+// over the terminal cannot be attached to from a server
+func checkOpener(program string) error {
+	if program == "" {
+		return nil
+	}
+	if NewOpener(program).Attaches() {
+		return fmt.Errorf("%w: %s sessions cannot be opened by the server", errBadRequest, program)
+	}
+	if err := OpenerExists(program); err != nil {
+		return fmt.Errorf("cannot open folder: %w", err)
+	}
+	return nil
+}
+
+// open opens the environment like scratch open
+func (s *Server) open(r *http.Request) (int, any, error) {
+	var req OpenRequest
+	if err := decodeBody(r, &req, true); err != nil {
+		return 0, nil, err
+	}
+	req.Program = cmp.Or(req.Program, "code")
+	if err := checkOpener(req.Program); err != nil {
+		return 0, nil, err
+	}
+
+	store, err := s.ctx.Store()
+	if err != nil {
+		return 0, nil, err
+	}
+	spec, err := LookupID(store, r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := openEnvironment(s.ctx, store, NewOpener(req.Program), spec); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+// DefaultServeTokenPath returns the file holding the token of the running server,
+// which clients read to authenticate
+func DefaultServeTokenPath() (string, error) {
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "serve.token"), nil
+}
+
+// ServeCmd represents the command to serve environments over HTTP
+type ServeCmd struct {
+	Listen string `help:"The loopback address to listen on" default:"127.0.0.1:7070"`
+}
+
+// Validate checks the address is on the loopback interface, as requests to other
+// hosts are refused
+func (s ServeCmd) Validate() error {
+	if _, _, err := net.SplitHostPort(s.Listen); err != nil {
+		return fmt.Errorf("invalid address %q: %w", s.Listen, err)
+	}
+	if !isLoopbackHost(s.Listen) {
+		return fmt.Errorf("--listen must be a loopback address, not %s", s.Listen)
+	}
+	return nil
+}
+
+// Run serves until interrupted, with a new token written for clients each time
+func (s ServeCmd) Run(ctx *CLIContext) error {
+	// An encrypted store asks for its key once, before prompts are turned off
+	if _, err := ctx.Store(); err != nil {
+		return err
+	}
+	if err := ctx.Close(); err != nil {
+		return err
+	}
+	prompting = false
+
+	tokenPath, err := DefaultServeTokenPath()
+	if err != nil {
+		return err
+	}
+	// Writing over a token file left behind would keep whatever mode it has, so
+	// it is removed and created again only readable by the user
+	if err := os.Remove(tokenPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove stale token: %w", err)
+	}
+	file, err := os.OpenFile(tokenPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("write token: %w", err)
+	}
+	defer os.Remove(tokenPath)
+	token := rand.Text()
+	_, err = file.WriteString(token + "\n")
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write token: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", s.Listen)
+	if err != nil {
+		return err
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server := &http.Server{
+		Handler:           NewServer(ctx, token),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		// Creating an environment waits for it to be provisioned
+		WriteTimeout: 30 * time.Minute,
+		IdleTimeout:  2 * time.Minute,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			output.Warn("%s", err)
+			stop()
+		}
+	}()
+	output.Info("Serving environments on http://%s with the token in %s", listener.Addr(), tokenPath)
+
+	<-sigCtx.Done()
+	output.Info("Stopping server")
+	// Requests in flight, like a create provisioning, get a while to finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,92 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	ctx := &main.CLIContext{}
+	store, err := ctx.Store()
+	require.NoError(t, err)
+	// Environments outside the data directory cannot be deleted without --unsafe
+	dataDir, err := main.DefaultDataDir()
+	require.NoError(t, err)
+	spec := main.NewSpec("served", main.PythonSpec, dataDir)
+	require.NoError(t, os.MkdirAll(spec.Path, 0755))
+	require.NoError(t, spec.Save(store))
+	hidden := main.NewSpec("hidden", main.PythonSpec, t.TempDir())
+	hidden.Hidden = true
+	require.NoError(t, hidden.Save(store))
+	require.NoError(t, ctx.Close())
+
+	server := httptest.NewServer(main.NewServer(ctx, "secret"))
+	defer server.Close()
+	send := func(req *http.Request) *http.Response {
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	newRequest := func(method string, path string, body string) *http.Request {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	request := func(method string, path string, body string) *http.Response {
+		return send(newRequest(method, path, body))
+	}
+
+	// Requests need the token, a loopback host and a JSON body
+	req := newRequest("GET", "/environments", "")
+	req.Header.Del("Authorization")
+	require.Equal(t, http.StatusUnauthorized, send(req).StatusCode)
+	req = newRequest("GET", "/environments", "")
+	req.Header.Set("Authorization", "Bearer wrong")
+	require.Equal(t, http.StatusUnauthorized, send(req).StatusCode)
+	req = newRequest("GET", "/environments", "")
+	req.Host = "attacker.example:7070"
+	require.Equal(t, http.StatusForbidden, send(req).StatusCode)
+	req = newRequest("POST", "/environments", `{"name":"form"}`)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	require.Equal(t, http.StatusUnsupportedMediaType, send(req).StatusCode)
+
+	resp := request("GET", "/environments", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var specs []main.Spec
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&specs))
+	require.Equal(t, []main.Spec{spec}, specs)
+
+	resp = request("GET", "/environments?all=true", "")
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&specs))
+	require.Len(t, specs, 2)
+
+	resp = request("GET", "/environments/"+spec.UID, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, http.StatusNotFound, request("GET", "/environments/python:missing", "").StatusCode)
+	require.Equal(t, http.StatusBadRequest, request("POST", "/environments", "{").StatusCode)
+	require.Equal(t, http.StatusBadRequest, request("POST", "/environments/"+spec.UID+"/open", `{"program":"tmux"}`).StatusCode)
+
+	require.Equal(t, http.StatusNoContent, request("DELETE", "/environments/"+spec.UID+"?permanent=true", "").StatusCode)
+	require.NoDirExists(t, spec.Path)
+	require.Equal(t, http.StatusNotFound, request("DELETE", "/environments/"+spec.UID, "").StatusCode)
+}
+
+func TestServeCmd_Validate(t *testing.T) {
+	require.NoError(t, main.ServeCmd{Listen: "127.0.0.1:7070"}.Validate())
+	require.NoError(t, main.ServeCmd{Listen: "[::1]:7070"}.Validate())
+	require.NoError(t, main.ServeCmd{Listen: "localhost:7070"}.Validate())
+	require.Error(t, main.ServeCmd{Listen: "0.0.0.0:7070"}.Validate())
+	require.Error(t, main.ServeCmd{Listen: "7070"}.Validate())
+}
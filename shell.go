@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// shellScript is the executable script of shell environments
+const shellScript = "run.sh"
+
+// shellFiles are written to new shell environments
+var shellFiles = map[string]string{
+	shellScript: `#!/bin/sh
+set -eu
+
+echo "Hello from $(basename "$(pwd)")"
+`,
+	"Makefile": `.PHONY: run lint
+
+run:
+	./run.sh
+
+lint:
+	shellcheck run.sh
+`,
+}
+
+// ShellEnvironment represents a shell script to experiment with, needing nothing
+// but a shell
+type ShellEnvironment struct{}
+
+// Ready always succeeds as there is nothing to install
+func (p ShellEnvironment) Ready() error {
+	return nil
+}
+
+// writeShellFiles writes the missing files and makes the script executable
+func writeShellFiles(dir string) error {
+	if err := writeMissingFiles(dir, shellFiles); err != nil {
+		return err
+	}
+	if err := os.Chmod(filepath.Join(dir, shellScript), 0755); err != nil {
+		return fmt.Errorf("make %s executable: %w", shellScript, err)
+	}
+	return nil
+}
+
+// Provision creates the environment at provided directory
+func (p ShellEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	if err := writeShellFiles(dir); err != nil {
+		return err
+	}
+
+	slog.Debug("Provisioned sh environment", slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes the script of shell environments, and weakly any shell script
+// as other projects have them as well
+func (p ShellEnvironment) Detect(dir string) float64 {
+	confidence := detectFiles(dir, map[string]float64{shellScript: LikelyMatch})
+	return max(confidence, detectGlob(dir, "*.sh", WeakMatch))
+}
+
+// Describe summarizes sh environments
+func (p ShellEnvironment) Describe() Description {
+	return Description{Summary: "An executable run.sh and a Makefile, needing nothing but a shell", Examples: []string{"scratch new try -t sh"}}
+}
+
+// Tools returns nothing, the shell comes with the system
+func (p ShellEnvironment) Tools() []Tool {
+	return nil
+}
+
+// Check verifies the script and Makefile exist
+func (p ShellEnvironment) Check(dir string) error {
+	return FilesExist(dir, slices.Sorted(maps.Keys(shellFiles))...)
+}
+
+// Reprovision writes the files that are missing
+func (p ShellEnvironment) Reprovision(dir string) error {
+	return writeShellFiles(dir)
+}
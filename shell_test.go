@@ -0,0 +1,40 @@
+package main_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellEnvironment_Provision(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("run.sh needs a POSIX shell")
+	}
+	dir := filepath.Join(t.TempDir(), "try")
+	p := main.ShellEnvironment{}
+	require.NoError(t, p.Ready())
+	require.NoError(t, p.Provision(dir))
+	require.NoError(t, p.Check(dir))
+
+	cmd := exec.Command("./run.sh")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	require.Equal(t, "Hello from try\n", string(out))
+
+	specType, err := main.DetectType(dir)
+	require.NoError(t, err)
+	require.Equal(t, main.ShellSpec, specType)
+
+	// A script restored by reprovisioning is executable again
+	require.NoError(t, os.Remove(filepath.Join(dir, "run.sh")))
+	require.NoError(t, p.Reprovision(dir))
+	info, err := os.Stat(filepath.Join(dir, "run.sh"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+}
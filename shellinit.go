@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/alecthomas/kong"
+)
+
+// PathCmd represents the command to print the path of an environment
+type PathCmd struct {
+	Query string `arg:"" help:"All or part of the name of environment, or @N for the Nth most recently used"`
+}
+
+// Run prints only the absolute path of the environment, so it can be used like cd $(scratch path foo)
+func (p PathCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.ReadOnlyStore()
+	if err != nil {
+		return err
+	}
+	spec, err := ResolveQuery(store, p.Query)
+	if err != nil {
+		return err
+	}
+	if !spec.Exists() {
+		return fmt.Errorf("directory %s of %s does not exist: %w", spec.Path, spec.ID(), ErrNotFound)
+	}
+
+	path, err := filepath.Abs(spec.Path)
+	if err != nil {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
+
+// shellInitScripts define functions for each shell, like zoxide init: scd changes to
+// the directory of an environment, snew creates one and changes to it, and both
+// complete environment names like scratch completes its commands
+var shellInitScripts = map[string]string{
+	"bash": `scd() {
+	local dir
+	dir="$(command scratch path "$@")" && cd "$dir"
+}
+
+snew() {
+	command scratch new --no-open "$@" && scd @1
+}
+
+_scratch_names() {
+	COMPREPLY=($(compgen -W "$(command scratch list --names 2>/dev/null)" -- "${COMP_WORDS[COMP_CWORD]}"))
+}
+
+_scratch() {
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W "{{.Commands}}" -- "${COMP_WORDS[1]}"))
+	else
+		_scratch_names
+	fi
+}
+
+complete -F _scratch_names scd
+complete -o default -F _scratch scratch
+`,
+	"zsh": `scd() {
+	local dir
+	dir="$(command scratch path "$@")" && cd "$dir"
+}
+
+snew() {
+	command scratch new --no-open "$@" && scd @1
+}
+
+_scratch_names() {
+	compadd -- ${(f)"$(command scratch list --names 2>/dev/null)"}
+}
+
+_scratch() {
+	if (( CURRENT == 2 )); then
+		compadd -- {{.Commands}}
+	else
+		_scratch_names
+	fi
+}
+
+if (( $+functions[compdef] )); then
+	compdef _scratch_names scd
+	compdef _scratch scratch
+fi
+`,
+	"fish": `function scd
+	set -l dir (command scratch path $argv); and cd $dir
+end
+
+function snew
+	command scratch new --no-open $argv; and scd @1
+end
+
+complete -c scd -f -a '(command scratch list --names 2>/dev/null)'
+complete -c scratch -f -n __fish_use_subcommand -a '{{.Commands}}'
+complete -c scratch -f -n 'not __fish_use_subcommand' -a '(command scratch list --names 2>/dev/null)'
+`,
+	"powershell": `function scd {
+	$dir = scratch path @args
+	if ($LASTEXITCODE -eq 0) { Set-Location $dir }
+}
+
+function snew {
+	scratch new --no-open @args
+	if ($LASTEXITCODE -eq 0) { scd '@1' }
+}
+
+Register-ArgumentCompleter -Native -CommandName scd, scratch -ScriptBlock {
+	param($wordToComplete, $commandAst, $cursorPosition)
+	$words = $commandAst.CommandElements
+	if ($words[0].Value -eq 'scratch' -and $words.Count -le 2 -and $wordToComplete -eq $words[-1].Value) {
+		$candidates = '{{.Commands}}' -split ' '
+	} else {
+		$candidates = scratch list --names 2>$null
+	}
+	$candidates | Where-Object { $_ -like "$wordToComplete*" }
+}
+`,
+}
+
+// ShellInit returns the shell integration script for shell, completing commands
+func ShellInit(shell string, commands []string) (string, error) {
+	script, ok := shellInitScripts[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %q", shell)
+	}
+	tmpl, err := template.New(shell).Parse(script)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, map[string]string{"Commands": strings.Join(commands, " ")}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ShellInitCmd represents the command to print the shell integration script
+type ShellInitCmd struct {
+	Shell string `arg:"" enum:"bash,zsh,fish,powershell" help:"The shell to integrate with (${enum})"`
+}
+
+// Run prints the script for the shell to evaluate, like eval "$(scratch shell-init bash)"
+func (s ShellInitCmd) Run(kctx *kong.Context) error {
+	commands := []string{}
+	for _, node := range kctx.Model.Children {
+		if !node.Hidden {
+			commands = append(commands, node.Name)
+		}
+	}
+	script, err := ShellInit(s.Shell, commands)
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
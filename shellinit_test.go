@@ -0,0 +1,22 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellInit(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		script, err := main.ShellInit(shell, []string{"new", "open"})
+		require.NoError(t, err, shell)
+		require.Contains(t, script, "scratch path", shell)
+		require.Contains(t, script, "snew", shell)
+		require.Contains(t, script, "list --names", shell)
+		require.Contains(t, script, "new open", shell)
+	}
+
+	_, err := main.ShellInit("tcsh", nil)
+	require.Error(t, err)
+}
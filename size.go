@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes that parses and prints human readable sizes like "200MB"
+type ByteSize uint64
+
+const (
+	KB ByteSize = 1 << (10 * (iota + 1))
+	MB
+	GB
+	TB
+)
+
+var sizeUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"TB", TB},
+	{"GB", GB},
+	{"MB", MB},
+	{"KB", KB},
+	{"B", 1},
+}
+
+// ParseByteSize parses sizes like "512", "200MB" or "1.5G"
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(strings.Replace(str, "IB", "B", 1), "B")
+
+	unit := ByteSize(1)
+	for _, u := range sizeUnits {
+		prefix := strings.TrimSuffix(u.suffix, "B")
+		if prefix != "" && strings.HasSuffix(str, prefix) {
+			str = strings.TrimSuffix(str, prefix)
+			unit = u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return ByteSize(n * float64(unit)), nil
+}
+
+// String formats the size with the largest fitting unit
+func (b ByteSize) String() string {
+	for _, u := range sizeUnits {
+		if b >= u.size && u.size > 1 {
+			return strconv.FormatFloat(float64(b)/float64(u.size), 'f', 1, 64) + u.suffix
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// MarshalJSON encodes the size as a human readable string
+func (b ByteSize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// UnmarshalJSON decodes the size from a human readable string or number of bytes
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var n uint64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*b = ByteSize(n)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("size must be a string or number: %w", err)
+	}
+	size, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
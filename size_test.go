@@ -0,0 +1,51 @@
+package main_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]main.ByteSize{
+		"512":    512,
+		"512B":   512,
+		"200MB":  200 * main.MB,
+		"200mb":  200 * main.MB,
+		"1.5GiB": 1536 * main.MB,
+		"2G":     2 * main.GB,
+		"10 KB":  10 * main.KB,
+	}
+	for input, expected := range cases {
+		got, err := main.ParseByteSize(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, got, input)
+	}
+
+	_, err := main.ParseByteSize("lots")
+	require.Error(t, err)
+	_, err = main.ParseByteSize("-1MB")
+	require.Error(t, err)
+}
+
+func TestByteSize_String(t *testing.T) {
+	assert.Equal(t, "512B", main.ByteSize(512).String())
+	assert.Equal(t, "1.5KB", main.ByteSize(1536).String())
+	assert.Equal(t, "200.0MB", (200 * main.MB).String())
+}
+
+func TestByteSize_JSON(t *testing.T) {
+	var sizes map[string]main.ByteSize
+	require.NoError(t, json.Unmarshal([]byte(`{"a": "1GB", "b": 1024}`), &sizes))
+	assert.Equal(t, main.GB, sizes["a"])
+	assert.Equal(t, main.KB, sizes["b"])
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	tdir := t.TempDir()
+	require.NoError(t, main.CheckDiskSpace(tdir+"/does/not/exist", main.KB))
+	require.Error(t, main.CheckDiskSpace(tdir, 1<<62))
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sourceHosts expands shorthands for git hosts in template sources
+var sourceHosts = map[string]string{
+	"gh:": "https://github.com/",
+	"gl:": "https://gitlab.com/",
+}
+
+var gitTool = Tool{
+	Name: "git",
+	Installers: map[string][]Installer{
+		"linux": {
+			{Requires: "apt-get", Command: "sudo apt-get install -y git"},
+			{Requires: "dnf", Command: "sudo dnf install -y git"},
+			{Requires: "pacman", Command: "sudo pacman -S --needed git"},
+		},
+		"darwin":  {{Requires: "brew", Command: "brew install git"}, {Command: "xcode-select --install"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=Git.Git -e"}},
+	},
+}
+
+// TemplateSource is a git repository or local directory that environments are created from
+type TemplateSource string
+
+// ParseTemplateSource parses gh:user/repo, gl:user/repo, a git URL or a local directory.
+// Local directories are made absolute.
+func ParseTemplateSource(source string) (TemplateSource, error) {
+	if source == "" {
+		return "", fmt.Errorf("empty template source")
+	}
+	if _, ok := TemplateSource(source).Repository(); ok {
+		return TemplateSource(source), nil
+	}
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return "", err
+	}
+	return TemplateSource(abs), nil
+}
+
+// Repository returns the URL of the git repository of a remote source
+func (s TemplateSource) Repository() (string, bool) {
+	str := string(s)
+	for prefix, host := range sourceHosts {
+		if repo, ok := strings.CutPrefix(str, prefix); ok {
+			return host + strings.TrimSuffix(repo, ".git") + ".git", true
+		}
+	}
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "file://", "git@"} {
+		if strings.HasPrefix(str, prefix) {
+			return str, true
+		}
+	}
+	return "", false
+}
+
+// TemplateEnvironment represents an environment copied from a template source
+type TemplateEnvironment struct {
+	Source TemplateSource
+	Data   TemplateData
+}
+
+// Ready checks git is installed for remote sources and local sources are valid templates
+func (p TemplateEnvironment) Ready() error {
+	if p.Source == "" {
+		return fmt.Errorf("no template source, pass --from")
+	}
+	if _, ok := p.Source.Repository(); ok {
+		if err := CommandsExist("git"); err != nil {
+			return fmt.Errorf("missing required commands: %w", err)
+		}
+		return nil
+	}
+	if info, err := os.Stat(string(p.Source)); err != nil || !info.IsDir() {
+		return fmt.Errorf("template directory %s not found", p.Source)
+	}
+	return CheckTemplate(string(p.Source), p.Data)
+}
+
+// Describe summarizes template environments
+func (p TemplateEnvironment) Describe() Description {
+	return Description{Summary: "A copy of a git repository or local directory, rendering .tmpl files", Examples: []string{"scratch new demo --from gh:user/repo"}}
+}
+
+// Tools returns git, which is needed for remote sources
+func (p TemplateEnvironment) Tools() []Tool {
+	return []Tool{gitTool}
+}
+
+// Provision copies the template into the directory without its version control history,
+// rendering files ending in .tmpl
+func (p TemplateEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	src := string(p.Source)
+	if repo, ok := p.Source.Repository(); ok {
+		tmp, err := os.MkdirTemp("", "scratch-template-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		if err := RunCommand("", "git", "clone", "--depth", "1", repo, tmp); err != nil {
+			return fmt.Errorf("clone template: %w", err)
+		}
+		src = tmp
+	}
+
+	if err := RenderTemplate(src, dir, p.Data); err != nil {
+		return fmt.Errorf("render template: %w", err)
+	}
+
+	slog.Debug("Provisioned environment from template", slog.String("source", string(p.Source)), slog.String("dir", dir))
+	return nil
+}
@@ -0,0 +1,72 @@
+package main_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTemplateSource(t *testing.T) {
+	source, err := main.ParseTemplateSource("gh:user/repo")
+	require.NoError(t, err)
+	repo, ok := source.Repository()
+	require.True(t, ok)
+	require.Equal(t, "https://github.com/user/repo.git", repo)
+
+	source, err = main.ParseTemplateSource("git@example.com:user/repo.git")
+	require.NoError(t, err)
+	repo, ok = source.Repository()
+	require.True(t, ok)
+	require.Equal(t, "git@example.com:user/repo.git", repo)
+
+	source, err = main.ParseTemplateSource("skeleton")
+	require.NoError(t, err)
+	_, ok = source.Repository()
+	require.False(t, ok)
+	require.True(t, filepath.IsAbs(string(source)))
+}
+
+func TestTemplateEnvironment_Provision(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(src, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, ".git", "HEAD"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "README.md.tmpl"), []byte("# {{.Name}}"), 0644))
+
+	p := main.TemplateEnvironment{Source: main.TemplateSource(src), Data: main.TemplateData{"Name": "demo"}}
+	require.NoError(t, p.Ready())
+	dir := filepath.Join(t.TempDir(), "demo")
+	require.NoError(t, p.Provision(dir))
+
+	data, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, "# demo", string(data))
+	require.NoDirExists(t, filepath.Join(dir, ".git"))
+
+	require.Error(t, main.TemplateEnvironment{Source: main.TemplateSource(src)}.Ready())
+}
+
+func TestTemplateEnvironment_ProvisionRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "main.py"), nil, 0644))
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-qm", "init"},
+	} {
+		require.NoError(t, main.RunCommand(src, "git", args...))
+	}
+
+	p := main.TemplateEnvironment{Source: main.TemplateSource("file://" + filepath.ToSlash(src))}
+	require.NoError(t, p.Ready())
+	dir := filepath.Join(t.TempDir(), "demo")
+	require.NoError(t, p.Provision(dir))
+	require.FileExists(t, filepath.Join(dir, "main.py"))
+	require.NoDirExists(t, filepath.Join(dir, ".git"))
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// statsMostOpened is how many of the most opened environments are shown
+const statsMostOpened = 5
+
+// statsMonths is how many months of creation trends are shown
+const statsMonths = 12
+
+// EnvironmentStat identifies an environment in statistics
+type EnvironmentStat struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created,omitzero"`
+	Opens   int       `json:"opens,omitempty"`
+}
+
+// MonthCount counts environments created in a month
+type MonthCount struct {
+	// Month is formatted like 2006-01
+	Month   string `json:"month"`
+	Created int    `json:"created"`
+}
+
+// Stats summarizes the environments and their history
+type Stats struct {
+	Total    int              `json:"total"`
+	Archived int              `json:"archived"`
+	Types    map[SpecType]int `json:"types"`
+	// Size is the disk usage of the environments that could be measured
+	Size   ByteSize         `json:"size"`
+	Oldest *EnvironmentStat `json:"oldest,omitempty"`
+	Newest *EnvironmentStat `json:"newest,omitempty"`
+	// MostOpened lists the environments opened most often, most first
+	MostOpened []EnvironmentStat `json:"most_opened"`
+	// Created counts environments created, cloned or adopted in each of the last
+	// months from the history, oldest first
+	Created []MonthCount `json:"created"`
+}
+
+// environmentStat returns the statistics entry of spec
+func environmentStat(spec Spec) *EnvironmentStat {
+	return &EnvironmentStat{ID: spec.ID(), Created: spec.Created, Opens: spec.Opens}
+}
+
+// ComputeStats summarizes specs and the events of the months up to now
+func ComputeStats(specs []Spec, events []Event, now time.Time, months int) Stats {
+	stats := Stats{Total: len(specs), Types: map[SpecType]int{}, MostOpened: []EnvironmentStat{}, Created: []MonthCount{}}
+
+	opened := []Spec{}
+	for _, spec := range specs {
+		stats.Types[spec.Type]++
+		if spec.IsArchived() {
+			stats.Archived++
+		}
+		if spec.Usage != nil {
+			stats.Size += spec.Usage.Size
+		}
+		if spec.Opens > 0 {
+			opened = append(opened, spec)
+		}
+		// Environments created before creation times were recorded have no age
+		if spec.Created.IsZero() {
+			continue
+		}
+		if stats.Oldest == nil || spec.Created.Before(stats.Oldest.Created) {
+			stats.Oldest = environmentStat(spec)
+		}
+		if stats.Newest == nil || spec.Created.After(stats.Newest.Created) {
+			stats.Newest = environmentStat(spec)
+		}
+	}
+
+	slices.SortFunc(opened, func(a, b Spec) int {
+		return cmp.Or(cmp.Compare(b.Opens, a.Opens), cmp.Compare(a.ID(), b.ID()))
+	})
+	for _, spec := range opened[:min(len(opened), statsMostOpened)] {
+		stats.MostOpened = append(stats.MostOpened, *environmentStat(spec))
+	}
+
+	now = now.Local()
+	first := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.Local)
+	index := map[string]int{}
+	for i := range months {
+		month := first.AddDate(0, i, 0).Format("2006-01")
+		index[month] = i
+		stats.Created = append(stats.Created, MonthCount{Month: month})
+	}
+	for _, event := range events {
+		switch event.Action {
+		case ActionCreate, ActionClone, ActionAdopt:
+			if i, ok := index[event.Time.Local().Format("2006-01")]; ok {
+				stats.Created[i].Created++
+			}
+		}
+	}
+	return stats
+}
+
+// Print writes the statistics for people
+func (s Stats) Print(now time.Time) {
+	fmt.Printf("%d environments, %d archived, using %s\n", s.Total, s.Archived, s.Size)
+
+	types := slices.SortedFunc(maps.Keys(s.Types), func(a, b SpecType) int {
+		return cmp.Or(cmp.Compare(s.Types[b], s.Types[a]), cmp.Compare(a, b))
+	})
+	if len(types) > 0 {
+		fmt.Println("\nTypes")
+		for _, t := range types {
+			fmt.Printf("%-12s %d\n", t, s.Types[t])
+		}
+	}
+
+	if s.Oldest != nil {
+		fmt.Println()
+		fmt.Printf("Oldest  %s, created %s ago\n", s.Oldest.ID, FormatDuration(now.Sub(s.Oldest.Created)))
+		fmt.Printf("Newest  %s, created %s ago\n", s.Newest.ID, FormatDuration(now.Sub(s.Newest.Created)))
+	}
+
+	if len(s.MostOpened) > 0 {
+		fmt.Println("\nMost opened")
+		for _, e := range s.MostOpened {
+			fmt.Printf("%4d  %s\n", e.Opens, e.ID)
+		}
+	}
+
+	fmt.Println("\nCreated per month")
+	for _, m := range s.Created {
+		fmt.Println(strings.TrimSpace(fmt.Sprintf("%s %3d %s", m.Month, m.Created, strings.Repeat("#", m.Created))))
+	}
+}
+
+// StatsCmd represents the command to show statistics about environments
+type StatsCmd struct {
+	JSON bool `help:"Print statistics as JSON"`
+}
+
+// Run summarizes the environments and the creation trend from the history
+func (s StatsCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return err
+	}
+	// Sizes are measured once an hour like du, as walking every directory is slow.
+	// Environments that cannot be measured are still counted.
+	usage := map[string]*DiskUsage{}
+	for _, spec := range measureSpecs(store, specs, false) {
+		usage[spec.ID()] = spec.Usage
+	}
+	for i := range specs {
+		specs[i].Usage = usage[specs[i].ID()]
+	}
+
+	path, err := DefaultHistoryPath()
+	if err != nil {
+		return err
+	}
+	events, err := LoadEvents(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	stats := ComputeStats(specs, events, now, statsMonths)
+	if !s.JSON {
+		stats.Print(now)
+		return nil
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", " ")
+	return enc.Encode(stats)
+}
@@ -0,0 +1,45 @@
+package main_test
+
+import (
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStats(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.Local)
+	specs := []main.Spec{
+		{Name: "old", Type: main.PythonSpec, Created: now.AddDate(-1, 0, 0), Opens: 2, Usage: &main.DiskUsage{Size: 2 * main.KB}},
+		{Name: "new", Type: main.DenoSpec, Created: now.Add(-time.Hour), Opens: 9, Usage: &main.DiskUsage{Size: main.KB}},
+		{Name: "legacy", Type: main.PythonSpec, Archive: "/archive/legacy"},
+	}
+	events := []main.Event{
+		{Time: now.AddDate(0, -2, 0), Action: main.ActionCreate},
+		{Time: now.AddDate(0, 0, -1), Action: main.ActionClone},
+		{Time: now, Action: main.ActionAdopt},
+		{Time: now, Action: main.ActionDelete},
+		{Time: now.AddDate(-2, 0, 0), Action: main.ActionCreate},
+	}
+
+	stats := main.ComputeStats(specs, events, now, 3)
+	require.Equal(t, 3, stats.Total)
+	require.Equal(t, 1, stats.Archived)
+	require.Equal(t, map[main.SpecType]int{main.PythonSpec: 2, main.DenoSpec: 1}, stats.Types)
+	require.Equal(t, 3*main.KB, stats.Size)
+	require.Equal(t, "python:old", stats.Oldest.ID)
+	require.Equal(t, "deno:new", stats.Newest.ID)
+	require.Equal(t, []main.EnvironmentStat{
+		{ID: "deno:new", Created: specs[1].Created, Opens: 9},
+		{ID: "python:old", Created: specs[0].Created, Opens: 2},
+	}, stats.MostOpened)
+	require.Equal(t, []main.MonthCount{{"2025-04", 1}, {"2025-05", 0}, {"2025-06", 2}}, stats.Created)
+}
+
+func TestSpec_MarkUsed(t *testing.T) {
+	now := time.Now()
+	spec := main.Spec{Opens: 1}.MarkUsed(now)
+	require.Equal(t, now, spec.LastUsed)
+	require.Equal(t, 2, spec.Opens)
+}
@@ -3,11 +3,14 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"iter"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"time"
 
 	"github.com/cockroachdb/pebble"
 )
@@ -41,9 +44,20 @@ func DefaultConfigDir() (string, error) {
 	}
 }
 
-// DefaultConfigDir gets data directory defined by XDG_DATA_HOME
-// or defaults to platform equivalent of $HOME/.local/share/scratch
+// DefaultDataDir gets the data directory of the current workspace, which for
+// the default workspace is defined by XDG_DATA_HOME or defaults to platform
+// equivalent of $HOME/.local/share/scratch
 func DefaultDataDir() (string, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	return workspaceDir(dir), nil
+}
+
+// appDataDir gets data directory defined by XDG_DATA_HOME
+// or defaults to platform equivalent of $HOME/.local/share/scratch
+func appDataDir() (string, error) {
 	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
 		return filepath.Join(xdg, AppName), nil
 	}
@@ -90,6 +104,15 @@ type Writer interface {
 type Lister interface {
 	List() iter.Seq2[string, error]
 	ListFunc(handle func(key string, data []byte) error) error
+	// ListPrefix processes only the key-value pairs whose key starts with prefix
+	ListPrefix(prefix string, handle func(key string, data []byte) error) error
+}
+
+// Batcher interface for writing several key-value pairs atomically
+type Batcher interface {
+	// Batch commits the writes made by apply all at once, or none of them if apply
+	// fails or the process dies midway
+	Batch(apply func(w Writer) error) error
 }
 
 // Storer interface for a storage backend for key-value pairs
@@ -97,11 +120,73 @@ type Storer interface {
 	Reader
 	Writer
 	Lister
+	Batcher
+	io.Closer
+}
+
+const (
+	lockRetries  = 10
+	lockInterval = 200 * time.Millisecond
+)
+
+// StoreBackend describes supported storage backends
+type StoreBackend string
+
+var (
+	PebbleBackend StoreBackend = "pebble"
+	JSONBackend   StoreBackend = "json"
+)
+
+// OpenStore opens the storage backend at the default config folder
+func OpenStore(backend StoreBackend) (Storer, error) {
+	switch backend {
+	case PebbleBackend, "":
+		return NewPebbleStore()
+	case JSONBackend:
+		dir, err := DefaultConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		return NewJSONStore(filepath.Join(dir, "store.json"))
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", backend)
+	}
+}
+
+// OpenSnapshot opens a read-only snapshot of the storage backend
+func OpenSnapshot(backend StoreBackend) (Storer, error) {
+	switch backend {
+	case PebbleBackend, "":
+		return NewPebbleSnapshot()
+	case JSONBackend:
+		dir, err := DefaultConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		return ReadJSONStore(filepath.Join(dir, "store.json"))
+	default:
+		return OpenStore(backend)
+	}
+}
+
+// CopyStore copies all key-value pairs from src to dst and returns the number copied
+func CopyStore(src Lister, dst Writer) (int, error) {
+	count := 0
+	err := src.ListFunc(func(key string, data []byte) error {
+		if err := dst.Put(key, data); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
 }
 
 // PebbleStore is a Storer for Pebble DB
 type PebbleStore struct {
 	db *pebble.DB
+	// snapshotDir is the temporary copy backing a read-only snapshot
+	snapshotDir string
 }
 
 // pebbleLogger is a logger for Pebble DB
@@ -115,25 +200,107 @@ func (pl pebbleLogger) Fatalf(format string, args ...any) {
 	slog.Debug(fmt.Sprintf(format, args...), slog.String("component", "pebble"))
 }
 
-// NewPebbleStore initializes the database at the default config folder
-func NewPebbleStore() (*PebbleStore, error) {
+// pebbleDir returns the directory of the database
+func pebbleDir() (string, error) {
 	dir, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "data"), nil
+}
+
+// NewPebbleStore initializes the database at the default config folder,
+// retrying briefly if another process holds the lock
+func NewPebbleStore() (*PebbleStore, error) {
+	dir, err := pebbleDir()
 	if err != nil {
 		return nil, err
 	}
-	db, err := pebble.Open(filepath.Join(dir, "data"), &pebble.Options{
-		Logger: pebbleLogger{},
+
+	for attempt := 1; ; attempt++ {
+		db, err := pebble.Open(dir, &pebble.Options{
+			Logger: pebbleLogger{},
+		})
+		if err == nil {
+			return &PebbleStore{db: db}, nil
+		}
+		if !isLockError(err) {
+			return &PebbleStore{}, err
+		}
+		if attempt == lockRetries {
+			return &PebbleStore{}, ErrStoreLocked
+		}
+		slog.Debug("Store is locked, retrying", slog.Int("attempt", attempt))
+		time.Sleep(lockInterval)
+	}
+}
+
+// NewPebbleSnapshot opens a read-only copy of the database so it can be
+// read while another process holds the lock
+func NewPebbleSnapshot() (*PebbleStore, error) {
+	dir, err := pebbleDir()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", AppName+"-snapshot-")
+	if err != nil {
+		return nil, fmt.Errorf("create snapshot dir: %w", err)
+	}
+
+	if err := copySnapshot(dir, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return nil, fmt.Errorf("copy snapshot: %w", err)
+	}
+
+	db, err := pebble.Open(tmp, &pebble.Options{
+		Logger:   pebbleLogger{},
+		ReadOnly: true,
 	})
 	if err != nil {
-		return &PebbleStore{}, err
+		os.RemoveAll(tmp)
+		return nil, fmt.Errorf("open snapshot: %w", err)
+	}
+	return &PebbleStore{db: db, snapshotDir: tmp}, nil
+}
+
+// copySnapshot copies database files except the lock file
+func copySnapshot(src string, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == "LOCK" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(src, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dst, entry.Name()), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database and removes any snapshot copy
+func (p *PebbleStore) Close() error {
+	err := p.db.Close()
+	if p.snapshotDir != "" {
+		os.RemoveAll(p.snapshotDir)
+	}
+	if err != nil {
+		return fmt.Errorf("close store: %w", err)
 	}
-	return &PebbleStore{db}, nil
+	return nil
 }
 
 // Exists checks if a key exists
 func (p *PebbleStore) Exists(key string) (bool, error) {
 	if _, err := p.Get(key); err != nil {
-		if errors.Is(err, pebble.ErrNotFound) {
+		if errors.Is(err, ErrNotFound) {
 			return false, nil
 		}
 		return false, fmt.Errorf("check key exists: %w", err)
@@ -144,6 +311,9 @@ func (p *PebbleStore) Exists(key string) (bool, error) {
 // Get fetches data by key
 func (p *PebbleStore) Get(key string) ([]byte, error) {
 	val, closer, err := p.db.Get([]byte(key))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, fmt.Errorf("get key %q: %w", key, ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("get key %q: %w", key, err)
 	}
@@ -173,7 +343,17 @@ func (p *PebbleStore) List() iter.Seq2[string, error] {
 
 // ListFunc processes each key-value pair with provided function
 func (p *PebbleStore) ListFunc(handle func(key string, data []byte) error) error {
-	iter, err := p.db.NewIter(nil)
+	return p.ListPrefix("", handle)
+}
+
+// ListPrefix processes each key-value pair whose key starts with prefix, bounding
+// the iterator so the rest of the keyspace is not read
+func (p *PebbleStore) ListPrefix(prefix string, handle func(key string, data []byte) error) error {
+	var opts *pebble.IterOptions
+	if prefix != "" {
+		opts = &pebble.IterOptions{LowerBound: []byte(prefix), UpperBound: prefixUpperBound([]byte(prefix))}
+	}
+	iter, err := p.db.NewIter(opts)
 	if err != nil {
 		return fmt.Errorf("list keys: %w", err)
 	}
@@ -189,6 +369,19 @@ func (p *PebbleStore) ListFunc(handle func(key string, data []byte) error) error
 	return nil
 }
 
+// prefixUpperBound returns the smallest key greater than every key starting with
+// prefix, or nil when there is none
+func prefixUpperBound(prefix []byte) []byte {
+	end := slices.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		end[i]++
+		if end[i] != 0 {
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
 // Put adds or replaces a key with its data
 func (p *PebbleStore) Put(key string, data []byte) error {
 	if err := p.db.Set([]byte(key), data, pebble.Sync); err != nil {
@@ -204,3 +397,37 @@ func (p *PebbleStore) Delete(key string) error {
 	}
 	return nil
 }
+
+// Batch commits the writes made by apply in a single synced pebble batch
+func (p *PebbleStore) Batch(apply func(w Writer) error) error {
+	batch := p.db.NewBatch()
+	defer batch.Close()
+	if err := apply(pebbleBatch{batch}); err != nil {
+		return err
+	}
+	if err := batch.Commit(pebble.Sync); err != nil {
+		return fmt.Errorf("commit batch: %w", err)
+	}
+	return nil
+}
+
+// pebbleBatch is a Writer staging writes in a pebble batch until it is committed
+type pebbleBatch struct {
+	batch *pebble.Batch
+}
+
+// Put stages adding or replacing a key with its data
+func (b pebbleBatch) Put(key string, data []byte) error {
+	if err := b.batch.Set([]byte(key), data, nil); err != nil {
+		return fmt.Errorf("put key %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete stages removing key with its data
+func (b pebbleBatch) Delete(key string) error {
+	if err := b.batch.Delete([]byte(key), nil); err != nil {
+		return fmt.Errorf("delete key %q: %w", key, err)
+	}
+	return nil
+}
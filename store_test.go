@@ -1,7 +1,9 @@
 package main_test
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 
 	main "github.com/chargeflux/scratch"
@@ -43,3 +45,158 @@ func TestDefaultDataDir(t *testing.T) {
 		require.Contains(t, dir, tdir)
 	})
 }
+
+func TestJSONStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	store, err := main.NewJSONStore(path)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put("python:b", []byte(`{"Name":"b"}`)))
+	require.NoError(t, store.Put("python:a", []byte(`{"Name":"a"}`)))
+	require.Error(t, store.Put("python:c", []byte("not json")))
+
+	exists, err := store.Exists("python:a")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	_, err = store.Get("python:c")
+	require.ErrorIs(t, err, main.ErrNotFound)
+
+	require.NoError(t, store.Close())
+	reopened, err := main.NewJSONStore(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { reopened.Close() })
+	data, err := reopened.Get("python:b")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Name":"b"}`, string(data))
+
+	keys := []string{}
+	for key, err := range reopened.List() {
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	require.Equal(t, []string{"python:a", "python:b"}, keys)
+
+	require.NoError(t, reopened.Delete("python:a"))
+	exists, err = reopened.Exists("python:a")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestJSONStore_Locked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	store, err := main.NewJSONStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Put("python:a", []byte(`{"Name":"a"}`)))
+
+	_, err = main.NewJSONStore(path)
+	require.ErrorIs(t, err, main.ErrStoreLocked)
+
+	// Read-only stores skip the lock but cannot be written
+	snapshot, err := main.ReadJSONStore(path)
+	require.NoError(t, err)
+	exists, err := snapshot.Exists("python:a")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Error(t, snapshot.Put("python:b", []byte(`{"Name":"b"}`)))
+
+	require.NoError(t, store.Close())
+	reopened, err := main.NewJSONStore(path)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Close())
+}
+
+func TestCopyStore(t *testing.T) {
+	tdir := t.TempDir()
+	src, err := main.NewJSONStore(filepath.Join(tdir, "src.json"))
+	require.NoError(t, err)
+	require.NoError(t, src.Put("python:a", []byte(`{"Name":"a"}`)))
+	require.NoError(t, src.Put("python:b", []byte(`{"Name":"b"}`)))
+
+	dst, err := main.NewJSONStore(filepath.Join(tdir, "dst.json"))
+	require.NoError(t, err)
+	count, err := main.CopyStore(src, dst)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	data, err := dst.Get("python:a")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Name":"a"}`, string(data))
+}
+
+// stores opens an empty store of each persistent backend
+var stores = map[string]func(t *testing.T) main.Storer{
+	"json": func(t *testing.T) main.Storer {
+		store, err := main.NewJSONStore(filepath.Join(t.TempDir(), "store.json"))
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+		return store
+	},
+	"pebble": func(t *testing.T) main.Storer {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		store, err := main.NewPebbleStore()
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+		return store
+	},
+}
+
+func TestStore_Batch(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			require.NoError(t, store.Put("python:a", []byte(`{"Name":"a"}`)))
+
+			err := store.Batch(func(w main.Writer) error {
+				require.NoError(t, w.Delete("python:a"))
+				require.NoError(t, w.Put("python:b", []byte(`{"Name":"b"}`)))
+				return errors.New("interrupted")
+			})
+			require.EqualError(t, err, "interrupted")
+			exists, err := store.Exists("python:a")
+			require.NoError(t, err)
+			require.True(t, exists)
+			exists, err = store.Exists("python:b")
+			require.NoError(t, err)
+			require.False(t, exists)
+
+			require.NoError(t, store.Batch(func(w main.Writer) error {
+				if err := w.Delete("python:a"); err != nil {
+					return err
+				}
+				return w.Put("python:b", []byte(`{"Name":"b"}`))
+			}))
+			keys := []string{}
+			for key, err := range store.List() {
+				require.NoError(t, err)
+				keys = append(keys, key)
+			}
+			require.Equal(t, []string{"python:b"}, keys)
+		})
+	}
+}
+
+func TestStore_ListPrefix(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			for _, key := range []string{"name/deno:a/1", "name/python:a/2", "name/python:b/3", "spec/2"} {
+				require.NoError(t, store.Put(key, []byte(`{}`)))
+			}
+
+			keys := []string{}
+			require.NoError(t, store.ListPrefix("name/python:", func(key string, data []byte) error {
+				keys = append(keys, key)
+				return nil
+			}))
+			require.Equal(t, []string{"name/python:a/2", "name/python:b/3"}, keys)
+
+			count := 0
+			require.NoError(t, store.ListPrefix("", func(key string, data []byte) error {
+				count++
+				return nil
+			}))
+			require.Equal(t, 4, count)
+		})
+	}
+}
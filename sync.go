@@ -0,0 +1,495 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// dataDirToken replaces the data directory in synced paths so they can be
+// resolved on every machine
+const dataDirToken = "${data}"
+
+// Registry is the set of specs shared between machines
+type Registry struct {
+	Machine string          `json:"machine"`
+	Updated time.Time       `json:"updated"`
+	Specs   map[string]Spec `json:"specs"`
+	// Workspaces holds the specs of workspaces other than the default one
+	Workspaces map[string]map[string]Spec `json:"workspaces,omitempty"`
+}
+
+// WorkspaceSpecs returns the specs of workspace name
+func (r Registry) WorkspaceSpecs(name string) map[string]Spec {
+	if name == DefaultWorkspace {
+		return r.Specs
+	}
+	if specs, ok := r.Workspaces[name]; ok {
+		return specs
+	}
+	return map[string]Spec{}
+}
+
+// SetWorkspaceSpecs replaces the specs of workspace name, keeping the other workspaces
+func (r *Registry) SetWorkspaceSpecs(name string, specs map[string]Spec) {
+	if name == DefaultWorkspace {
+		r.Specs = specs
+		return
+	}
+	if r.Workspaces == nil {
+		r.Workspaces = map[string]map[string]Spec{}
+	}
+	r.Workspaces[name] = specs
+}
+
+// Remote is a location the registry is synced with
+type Remote interface {
+	// Pull fetches the registry, returning an empty one if none was pushed yet
+	Pull() (Registry, error)
+	Push(r Registry) error
+}
+
+// NewRemote returns the Remote for location: http(s) URLs are fetched with
+// GET and written with PUT, "git:<dir>" is a local clone of a git repository,
+// and anything else is a path to a JSON file
+func NewRemote(location string) (Remote, error) {
+	switch {
+	case location == "":
+		return nil, fmt.Errorf("no sync remote configured")
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return HTTPRemote{location}, nil
+	case strings.HasPrefix(location, "git:"):
+		return GitRemote{strings.TrimPrefix(location, "git:")}, nil
+	default:
+		return FileRemote{location}, nil
+	}
+}
+
+func decodeRegistry(data []byte) (Registry, error) {
+	var r Registry
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Registry{}, fmt.Errorf("unmarshal registry: %w", err)
+	}
+	if r.Specs == nil {
+		r.Specs = map[string]Spec{}
+	}
+	return r, nil
+}
+
+// FileRemote syncs with a JSON file, e.g. in a folder synced by another tool
+type FileRemote struct {
+	Path string
+}
+
+// Pull reads the registry file
+func (f FileRemote) Pull() (Registry, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Registry{Specs: map[string]Spec{}}, nil
+	}
+	if err != nil {
+		return Registry{}, fmt.Errorf("read registry: %w", err)
+	}
+	return decodeRegistry(data)
+}
+
+// Push writes the registry file
+func (f FileRemote) Push(r Registry) error {
+	data, err := json.MarshalIndent(r, "", " ")
+	if err != nil {
+		return fmt.Errorf("marshal registry: %w", err)
+	}
+	if err := EnsureDirectory(filepath.Dir(f.Path)); err != nil {
+		return err
+	}
+	if err := os.WriteFile(f.Path, data, 0644); err != nil {
+		return fmt.Errorf("write registry: %w", err)
+	}
+	return nil
+}
+
+// GitRemote syncs with registry.json in a local clone of a git repository
+type GitRemote struct {
+	Dir string
+}
+
+func (g GitRemote) file() FileRemote {
+	return FileRemote{filepath.Join(g.Dir, "registry.json")}
+}
+
+// Pull fast-forwards the clone and reads the registry
+func (g GitRemote) Pull() (Registry, error) {
+	if err := RunCommand(g.Dir, "git", "pull", "--ff-only"); err != nil {
+		return Registry{}, fmt.Errorf("git pull: %w", err)
+	}
+	return g.file().Pull()
+}
+
+// Push commits the registry and pushes the clone
+func (g GitRemote) Push(r Registry) error {
+	if err := g.file().Push(r); err != nil {
+		return err
+	}
+	if err := RunCommand(g.Dir, "git", "add", "registry.json"); err != nil {
+		return fmt.Errorf("git add: %w", err)
+	}
+	// Nothing to commit when the registry is unchanged
+	if err := RunCommand(g.Dir, "git", "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+	msg := fmt.Sprintf("Sync scratch registry from %s", r.Machine)
+	if err := RunCommand(g.Dir, "git", "commit", "-m", msg); err != nil {
+		return fmt.Errorf("git commit: %w", err)
+	}
+	if err := RunCommand(g.Dir, "git", "push"); err != nil {
+		return fmt.Errorf("git push: %w", err)
+	}
+	return nil
+}
+
+// HTTPRemote syncs with a simple HTTP endpoint
+type HTTPRemote struct {
+	URL string
+}
+
+// syncClient gives up on HTTP remotes that stop responding instead of hanging the sync
+var syncClient = &http.Client{Timeout: time.Minute}
+
+// Pull fetches the registry with GET
+func (h HTTPRemote) Pull() (Registry, error) {
+	resp, err := syncClient.Get(h.URL)
+	if err != nil {
+		return Registry{}, fmt.Errorf("get registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Registry{Specs: map[string]Spec{}}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Registry{}, fmt.Errorf("get registry: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Registry{}, fmt.Errorf("read registry: %w", err)
+	}
+	return decodeRegistry(data)
+}
+
+// Push uploads the registry with PUT
+func (h HTTPRemote) Push(r Registry) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshal registry: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, h.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := syncClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("put registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("put registry: %s", resp.Status)
+	}
+	return nil
+}
+
+// PathMapper translates environment paths between this machine and the registry
+type PathMapper struct {
+	DataDir string
+	// Paths maps registry path prefixes to local path prefixes
+	Paths map[string]string
+}
+
+// ToRegistry makes path portable for the registry
+func (m PathMapper) ToRegistry(path string) string {
+	if rel, ok := trimPathPrefix(path, m.DataDir); ok {
+		return dataDirToken + rel
+	}
+	for remote, local := range m.Paths {
+		if rel, ok := trimPathPrefix(path, local); ok {
+			return remote + rel
+		}
+	}
+	return path
+}
+
+// ToLocal resolves a registry path on this machine
+func (m PathMapper) ToLocal(path string) string {
+	if rel, ok := strings.CutPrefix(path, dataDirToken); ok {
+		return filepath.Join(m.DataDir, filepath.FromSlash(rel))
+	}
+	for remote, local := range m.Paths {
+		if rel, ok := trimPathPrefix(path, remote); ok {
+			return filepath.Join(local, filepath.FromSlash(rel))
+		}
+	}
+	return path
+}
+
+// trimPathPrefix removes prefix from path if path is prefix or below it,
+// returning the remainder with forward slashes
+func trimPathPrefix(path string, prefix string) (string, bool) {
+	prefix = strings.TrimRight(prefix, `/\`)
+	if prefix == "" {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(path, prefix)
+	if !ok {
+		return "", false
+	}
+	if rest != "" && rest[0] != '/' && rest[0] != '\\' {
+		return "", false
+	}
+	return strings.ReplaceAll(rest, `\`, "/"), true
+}
+
+// SyncPreference decides conflicts where both sides changed the same environment
+type SyncPreference string
+
+var (
+	PreferNone   SyncPreference = ""
+	PreferLocal  SyncPreference = "local"
+	PreferRemote SyncPreference = "remote"
+)
+
+// sameSpec reports whether a and b are equal apart from their usage, which changes
+// whenever an environment is opened or measured and is merged separately
+func sameSpec(a, b Spec) bool {
+	a.Usage, a.LastUsed, a.Opens = nil, time.Time{}, 0
+	b.Usage, b.LastUsed, b.Opens = nil, time.Time{}, 0
+	return reflect.DeepEqual(a, b)
+}
+
+// mergeUsage returns spec with the latest use, most opens and most recent
+// measurement of l and r
+func mergeUsage(spec, l, r Spec) Spec {
+	spec.LastUsed = l.LastUsed
+	if r.LastUsed.After(l.LastUsed) {
+		spec.LastUsed = r.LastUsed
+	}
+	spec.Opens = max(l.Opens, r.Opens)
+	spec.Usage = l.Usage
+	if r.Usage != nil && (l.Usage == nil || r.Usage.Scanned.After(l.Usage.Scanned)) {
+		spec.Usage = r.Usage
+	}
+	return spec
+}
+
+// MergeRegistries merges local and remote specs against base, the specs at the
+// last sync. Additions and changes on either side are kept, environments
+// removed on one side and unchanged on the other are removed, and keys changed
+// on both sides are conflicts resolved by prefer, keeping local if unset.
+// Usage never conflicts, the latest of both sides is kept.
+func MergeRegistries(base, local, remote map[string]Spec, prefer SyncPreference) (map[string]Spec, []string) {
+	merged := map[string]Spec{}
+	conflicts := []string{}
+
+	keys := slices.Sorted(maps.Keys(local))
+	for key := range remote {
+		if _, ok := local[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		l, inLocal := local[key]
+		r, inRemote := remote[key]
+		b, inBase := base[key]
+
+		switch {
+		case inLocal && inRemote:
+			switch {
+			case sameSpec(l, r), inBase && sameSpec(r, b):
+				merged[key] = mergeUsage(l, l, r)
+			case inBase && sameSpec(l, b):
+				merged[key] = mergeUsage(r, l, r)
+			default:
+				conflicts = append(conflicts, key)
+				if prefer == PreferRemote {
+					merged[key] = mergeUsage(r, l, r)
+				} else {
+					merged[key] = mergeUsage(l, l, r)
+				}
+			}
+		case inLocal:
+			// Removed remotely unless changed locally since
+			if !inBase || !sameSpec(l, b) {
+				merged[key] = l
+			}
+		case inRemote:
+			// Removed locally unless changed remotely since
+			if !inBase || !sameSpec(r, b) {
+				merged[key] = r
+			}
+		}
+	}
+
+	slices.Sort(conflicts)
+	return merged, conflicts
+}
+
+// rekeyRegistry keys specs by ULID, assigning the ULID derived from the ID to specs
+// pushed before ULIDs the same way MigrateSpecKeys does
+func rekeyRegistry(specs map[string]Spec) map[string]Spec {
+	rekeyed := make(map[string]Spec, len(specs))
+	for _, spec := range specs {
+		spec = spec.withUID()
+		rekeyed[spec.UID] = spec
+	}
+	return rekeyed
+}
+
+// syncStatePath is where the registry of the current workspace at the last sync is kept
+func syncStatePath() (string, error) {
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	name := "sync-state.json"
+	if workspace != DefaultWorkspace {
+		name = fmt.Sprintf("sync-state-%s.json", workspace)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// SyncCmd represents the command to sync the environment registry with a remote
+type SyncCmd struct {
+	Remote string         `help:"Remote to sync with, overriding the configured one (file path, git:<clone dir> or http(s) URL)"`
+	Prefer SyncPreference `help:"Side that wins when an environment changed on both (local or remote)" enum:"local,remote," default:""`
+	DryRun bool           `help:"Show changes without applying them"`
+	// Plaintext allows syncing an encrypted store, whose environments other machines
+	// could not decrypt without sharing its key
+	Plaintext bool `help:"Sync an encrypted store, pushing its environments unencrypted"`
+}
+
+// Run pulls the remote registry, merges the current workspace with the local store
+// and pushes the result, leaving the other workspaces of the registry as pulled
+func (s SyncCmd) Run(ctx *CLIContext) error {
+	config, err := ctx.Config()
+	if err != nil {
+		return err
+	}
+	location := s.Remote
+	if location == "" {
+		location = config.Sync.Remote
+	}
+	remote, err := NewRemote(location)
+	if err != nil {
+		return err
+	}
+
+	dataDir, err := DefaultDataDir()
+	if err != nil {
+		return err
+	}
+	mapper := PathMapper{DataDir: dataDir, Paths: config.Sync.Paths}
+
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	if specCipher != nil && !s.Plaintext {
+		return WithHint(errors.New("store is encrypted, refusing to push its environments unencrypted"), "pass --plaintext to sync anyway")
+	}
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return err
+	}
+	local := map[string]Spec{}
+	for _, spec := range specs {
+		spec.Path = mapper.ToRegistry(spec.Path)
+		local[spec.UID] = spec
+	}
+
+	statePath, err := syncStatePath()
+	if err != nil {
+		return err
+	}
+	base := map[string]Spec{}
+	if state, err := (FileRemote{statePath}).Pull(); err != nil {
+		return fmt.Errorf("load sync state: %w", err)
+	} else {
+		base = rekeyRegistry(state.Specs)
+	}
+
+	slog.Debug("Pulling registry", slog.String("remote", location))
+	pulled, err := remote.Pull()
+	if err != nil {
+		return err
+	}
+
+	merged, conflicts := MergeRegistries(base, local, rekeyRegistry(pulled.WorkspaceSpecs(workspace)), s.Prefer)
+	for _, key := range conflicts {
+		output.Warn("%s changed on both machines, keeping the %s version", merged[key].ID(), cmp.Or(string(s.Prefer), "local"))
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(merged)) {
+		spec := merged[key]
+		if current, ok := local[key]; ok && reflect.DeepEqual(current, spec) {
+			continue
+		}
+		spec.Path = mapper.ToLocal(spec.Path)
+		fmt.Printf("update %s\n", spec.ID())
+		if !s.DryRun {
+			if err := spec.Save(store); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(local)) {
+		if _, ok := merged[key]; ok {
+			continue
+		}
+		// Only the record is removed, directories are never deleted by sync
+		fmt.Printf("remove %s\n", local[key].ID())
+		if !s.DryRun {
+			if err := local[key].Delete(store); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.DryRun {
+		return nil
+	}
+
+	machine, err := os.Hostname()
+	if err != nil {
+		machine = "unknown"
+	}
+	now := time.Now().UTC()
+	pushed := Registry{Machine: machine, Updated: now, Specs: pulled.Specs, Workspaces: pulled.Workspaces}
+	pushed.SetWorkspaceSpecs(workspace, merged)
+	slog.Debug("Pushing registry", slog.String("remote", location), slog.String("workspace", workspace))
+	if err := remote.Push(pushed); err != nil {
+		return err
+	}
+	state := Registry{Machine: machine, Updated: now, Specs: merged}
+	if err := (FileRemote{statePath}).Push(state); err != nil {
+		return fmt.Errorf("save sync state: %w", err)
+	}
+
+	output.Success("Synced %d environments with %d conflicts", len(merged), len(conflicts))
+	return nil
+}
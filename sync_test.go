@@ -0,0 +1,187 @@
+package main_test
+
+import (
+	"bytes"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeRegistries(t *testing.T) {
+	spec := func(name string, tags ...string) main.Spec {
+		return main.Spec{Name: name, Type: main.PythonSpec, Path: "${data}/" + name, Tags: tags}
+	}
+	base := map[string]main.Spec{
+		"python:same":          spec("same"),
+		"python:deleted-local": spec("deleted-local"),
+		"python:edited-remote": spec("edited-remote"),
+		"python:conflict":      spec("conflict"),
+	}
+	local := map[string]main.Spec{
+		"python:same":          spec("same"),
+		"python:edited-remote": spec("edited-remote"),
+		"python:conflict":      spec("conflict", "local"),
+		"python:new-local":     spec("new-local"),
+	}
+	remote := map[string]main.Spec{
+		"python:same":          spec("same"),
+		"python:deleted-local": spec("deleted-local"),
+		"python:edited-remote": spec("edited-remote", "remote"),
+		"python:conflict":      spec("conflict", "remote"),
+		"python:new-remote":    spec("new-remote"),
+	}
+
+	merged, conflicts := main.MergeRegistries(base, local, remote, main.PreferNone)
+	assert.Equal(t, []string{"python:conflict"}, conflicts)
+	assert.Equal(t, map[string]main.Spec{
+		"python:same":          spec("same"),
+		"python:edited-remote": spec("edited-remote", "remote"),
+		"python:conflict":      spec("conflict", "local"),
+		"python:new-local":     spec("new-local"),
+		"python:new-remote":    spec("new-remote"),
+	}, merged)
+
+	merged, _ = main.MergeRegistries(base, local, remote, main.PreferRemote)
+	assert.Equal(t, spec("conflict", "remote"), merged["python:conflict"])
+}
+
+func TestMergeRegistries_Usage(t *testing.T) {
+	now := time.Now().UTC()
+	spec := main.Spec{Name: "used", Type: main.PythonSpec, Path: "${data}/used"}
+	base := map[string]main.Spec{"used": spec, "deleted-remote": spec}
+
+	// Only usage changed on both sides, which is merged instead of a conflict
+	l, r := spec, spec
+	l.LastUsed, l.Opens = now.Add(-time.Hour), 3
+	l.Usage = &main.DiskUsage{Size: 10, Scanned: now}
+	r.LastUsed, r.Opens = now, 2
+	r.Usage = &main.DiskUsage{Size: 20, Scanned: now.Add(-time.Hour)}
+
+	merged, conflicts := main.MergeRegistries(base, map[string]main.Spec{"used": l, "deleted-remote": l}, map[string]main.Spec{"used": r}, main.PreferNone)
+	assert.Empty(t, conflicts)
+	want := spec
+	want.LastUsed, want.Opens, want.Usage = now, 3, l.Usage
+	// Using an environment does not keep it from being removed on the other side
+	assert.Equal(t, map[string]main.Spec{"used": want}, merged)
+}
+
+func TestPathMapper(t *testing.T) {
+	data := filepath.Join(t.TempDir(), "scratch")
+	mapper := main.PathMapper{
+		DataDir: data,
+		Paths:   map[string]string{"/Users/me/code": "/home/me/src"},
+	}
+
+	portable := mapper.ToRegistry(filepath.Join(data, "foo"))
+	require.Equal(t, "${data}/foo", portable)
+	require.Equal(t, filepath.Join(data, "foo"), mapper.ToLocal(portable))
+
+	require.Equal(t, "/Users/me/code/bar", mapper.ToRegistry("/home/me/src/bar"))
+	require.Equal(t, filepath.Join("/home/me/src", "bar"), mapper.ToLocal("/Users/me/code/bar"))
+
+	require.Equal(t, "/home/me/srcfoo", mapper.ToRegistry("/home/me/srcfoo"))
+}
+
+func TestSyncCmd_Workspaces(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	config := main.Config{Workspaces: []string{"work"}}
+	require.NoError(t, config.Save())
+	t.Cleanup(func() { main.UseWorkspace(config, main.DefaultWorkspace) })
+	remote := filepath.Join(t.TempDir(), "registry.json")
+
+	// sync saves spec in workspace name and syncs it, returning the names synced back
+	sync := func(name string, spec main.Spec) []string {
+		require.NoError(t, main.UseWorkspace(config, name))
+		ctx := &main.CLIContext{}
+		defer ctx.Close()
+		store, err := ctx.Store()
+		require.NoError(t, err)
+		require.NoError(t, spec.Save(store))
+		require.NoError(t, main.SyncCmd{Remote: remote}.Run(ctx))
+
+		specs, err := main.LoadSpecs(store)
+		require.NoError(t, err)
+		names := []string{}
+		for _, spec := range specs {
+			names = append(names, spec.Name)
+		}
+		return names
+	}
+
+	home := main.NewSpec("home", main.NotesSpec, "/home")
+	job := main.NewSpec("job", main.NotesSpec, "/work")
+	require.Equal(t, []string{"home"}, sync(main.DefaultWorkspace, home))
+	require.Equal(t, []string{"job"}, sync("work", job))
+	// Syncing the default workspace again neither pulls job nor loses home
+	require.Equal(t, []string{"home"}, sync(main.DefaultWorkspace, home))
+
+	registry, err := main.FileRemote{Path: remote}.Pull()
+	require.NoError(t, err)
+	require.Equal(t, []string{home.UID}, slices.Collect(maps.Keys(registry.WorkspaceSpecs(main.DefaultWorkspace))))
+	require.Equal(t, []string{job.UID}, slices.Collect(maps.Keys(registry.WorkspaceSpecs("work"))))
+}
+
+func TestSyncCmd_Conflict(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	remote := main.FileRemote{Path: filepath.Join(t.TempDir(), "registry.json")}
+	var out bytes.Buffer
+	previous := main.SetOutput(main.NewPlainOutput(&out))
+	t.Cleanup(func() { main.SetOutput(previous) })
+
+	ctx := &main.CLIContext{}
+	t.Cleanup(func() { ctx.Close() })
+	store, err := ctx.Store()
+	require.NoError(t, err)
+	spec := main.NewSpec("test", main.NotesSpec, "/test")
+	require.NoError(t, spec.Save(store))
+	require.NoError(t, main.SyncCmd{Remote: remote.Path}.Run(ctx))
+
+	// Both sides tag the environment differently
+	registry, err := remote.Pull()
+	require.NoError(t, err)
+	pushed := registry.Specs[spec.UID]
+	pushed.Tags = []string{"remote"}
+	registry.Specs[spec.UID] = pushed
+	require.NoError(t, remote.Push(registry))
+	spec.Tags = []string{"local"}
+	require.NoError(t, spec.Save(store))
+
+	require.NoError(t, main.SyncCmd{Remote: remote.Path}.Run(ctx))
+	require.Contains(t, out.String(), spec.ID()+" changed on both machines, keeping the local version")
+	saved, err := main.LookupSpec(store, spec.Key())
+	require.NoError(t, err)
+	require.Equal(t, []string{"local"}, saved.Tags)
+}
+
+func TestSyncCmd_Encrypted(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("SCRATCH_PASSPHRASE", "hunter2")
+	// Later tests must not inherit the key
+	t.Cleanup(func() { main.UnlockStore(NewMemoryStore()) })
+	remote := main.FileRemote{Path: filepath.Join(t.TempDir(), "registry.json")}
+
+	ctx := &main.CLIContext{}
+	t.Cleanup(func() { ctx.Close() })
+	store, err := ctx.Store()
+	require.NoError(t, err)
+	spec := main.NewSpec("secret", main.NotesSpec, "/secret")
+	require.NoError(t, spec.Save(store))
+	require.NoError(t, main.StoreEncryptCmd{Passphrase: true}.Run(ctx))
+
+	require.ErrorContains(t, main.SyncCmd{Remote: remote.Path}.Run(ctx), "encrypted")
+	require.NoFileExists(t, remote.Path)
+
+	require.NoError(t, main.SyncCmd{Remote: remote.Path, Plaintext: true}.Run(ctx))
+	registry, err := remote.Pull()
+	require.NoError(t, err)
+	require.Equal(t, "secret", registry.Specs[spec.UID].Name)
+}
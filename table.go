@@ -0,0 +1,76 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Table is tabular output that is aligned for terminals and tab separated otherwise
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Append adds a row to the table
+func (t *Table) Append(row ...string) {
+	t.Rows = append(t.Rows, row)
+}
+
+// Write writes the table to w as aligned columns under an upper case header when aligned
+// is set, otherwise as one tab separated line per row without a header
+func (t Table) Write(w io.Writer, aligned bool) error {
+	if !aligned {
+		for _, row := range t.Rows {
+			if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, len(t.Header))
+	for i, h := range t.Header {
+		header[i] = strings.ToUpper(h)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// WriteGroups writes the rows of each group as a section under a heading with its key and
+// number of rows when aligned, otherwise with the key as the first field of each row.
+// Groups are ordered by key, and the rows without one come last, headed by none.
+func WriteGroups(w io.Writer, header []string, groups map[string][][]string, none string, aligned bool) error {
+	keys := slices.Sorted(maps.Keys(groups))
+	if len(keys) > 0 && keys[0] == "" {
+		keys = append(keys[1:], "")
+	}
+	for i, key := range keys {
+		rows := groups[key]
+		if !aligned {
+			for _, row := range rows {
+				if _, err := fmt.Fprintln(w, strings.Join(append([]string{key}, row...), "\t")); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%d)\n", cmp.Or(key, none), len(rows))
+		if err := (Table{Header: header, Rows: rows}).Write(w, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main_test
+
+import (
+	"bytes"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable_Write(t *testing.T) {
+	table := main.Table{Header: []string{"name", "type"}}
+	table.Append("demo", "python")
+	table.Append("a-longer-name", "python")
+
+	var aligned bytes.Buffer
+	require.NoError(t, table.Write(&aligned, true))
+	require.Equal(t, "NAME           TYPE\ndemo           python\na-longer-name  python\n", aligned.String())
+
+	var plain bytes.Buffer
+	require.NoError(t, table.Write(&plain, false))
+	require.Equal(t, "demo\tpython\na-longer-name\tpython\n", plain.String())
+}
+
+func TestWriteGroups(t *testing.T) {
+	groups := map[string][][]string{
+		"python": {{"demo"}, {"api"}},
+		"":       {{"loose"}},
+		"deno":   {{"site"}},
+	}
+
+	var aligned bytes.Buffer
+	require.NoError(t, main.WriteGroups(&aligned, []string{"name"}, groups, "untagged", true))
+	require.Equal(t, "deno (1)\nNAME\nsite\n\npython (2)\nNAME\ndemo\napi\n\nuntagged (1)\nNAME\nloose\n", aligned.String())
+
+	var plain bytes.Buffer
+	require.NoError(t, main.WriteGroups(&plain, []string{"name"}, groups, "untagged", false))
+	require.Equal(t, "deno\tsite\npython\tdemo\npython\tapi\n\tloose\n", plain.String())
+}
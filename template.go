@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateSuffix marks files in a template directory that are rendered rather than copied
+const templateSuffix = ".tmpl"
+
+// vcsDirs hold version control history, which is not copied from templates
+var vcsDirs = []string{".git", ".hg", ".svn"}
+
+// TemplateData holds the variables available to scaffolded file templates
+type TemplateData map[string]string
+
+// NewTemplateData returns the built-in variables for spec merged with user variables
+func NewTemplateData(spec Spec, vars map[string]string, now time.Time) (TemplateData, error) {
+	data := TemplateData{
+		"Name": spec.Name,
+		"Type": string(spec.Type),
+		"Date": now.Format(time.DateOnly),
+	}
+	for key, value := range vars {
+		if _, ok := data[key]; ok {
+			return nil, fmt.Errorf("variable %q is reserved", key)
+		}
+		data[key] = value
+	}
+	return data, nil
+}
+
+// RenderTemplate copies the template directory src into dst, replacing existing files and
+// rendering files ending in .tmpl with data and stripping the suffix
+func RenderTemplate(src, dst string, data TemplateData) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir() && slices.Contains(vcsDirs, d.Name()):
+			return fs.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case !info.Mode().IsRegular():
+			return nil
+		case strings.HasSuffix(path, templateSuffix):
+			return renderFile(path, strings.TrimSuffix(target, templateSuffix), info.Mode().Perm(), data)
+		default:
+			if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			_, err := copyFile(path, target, info.Mode().Perm(), ReflinkAuto)
+			return err
+		}
+	})
+}
+
+// CheckTemplate renders every template in src without writing, so missing variables
+// are reported before an environment is provisioned
+func CheckTemplate(src string, data TemplateData) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && slices.Contains(vcsDirs, d.Name()) {
+			return fs.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, templateSuffix) {
+			return nil
+		}
+		tmpl, err := parseTemplate(path)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(io.Discard, data); err != nil {
+			return fmt.Errorf("render %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// parseTemplate parses the template file at path, failing on missing variables when executed
+func parseTemplate(path string) (*template.Template, error) {
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderFile executes the template at src and writes the result to dst
+func renderFile(src, dst string, perm fs.FileMode, data TemplateData) error {
+	tmpl, err := parseTemplate(src)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	defer f.Close()
+
+	if err := tmpl.Execute(f, data); err != nil {
+		return fmt.Errorf("render %s: %w", src, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,49 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTemplateData(t *testing.T) {
+	spec := main.Spec{Name: "demo", Type: main.PythonSpec}
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	data, err := main.NewTemplateData(spec, map[string]string{"author": "me"}, now)
+	require.NoError(t, err)
+	require.Equal(t, main.TemplateData{"Name": "demo", "Type": "python", "Date": "2026-03-04", "author": "me"}, data)
+
+	_, err = main.NewTemplateData(spec, map[string]string{"Name": "other"}, now)
+	require.Error(t, err)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "README.md.tmpl"), []byte("# {{.Name}} by {{.author}}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "docs", "raw.txt"), []byte("{{.Name}}"), 0644))
+
+	dst := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "README.md"), []byte("provisioned"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "main.py"), []byte("print()"), 0644))
+
+	data := main.TemplateData{"Name": "demo", "author": "me"}
+	require.NoError(t, main.RenderTemplate(src, dst, data))
+
+	readme, err := os.ReadFile(filepath.Join(dst, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, "# demo by me\n", string(readme))
+	raw, err := os.ReadFile(filepath.Join(dst, "docs", "raw.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "{{.Name}}", string(raw))
+	require.FileExists(t, filepath.Join(dst, "main.py"))
+
+	require.NoError(t, main.CheckTemplate(src, data))
+	require.Error(t, main.CheckTemplate(src, main.TemplateData{"Name": "demo"}))
+	require.Error(t, main.RenderTemplate(src, t.TempDir(), main.TemplateData{"Name": "demo"}))
+}
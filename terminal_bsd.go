@@ -0,0 +1,12 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import "golang.org/x/sys/unix"
+
+const (
+	// ioctlReadTermios is the ioctl request reading terminal attributes
+	ioctlReadTermios = unix.TIOCGETA
+	// ioctlWriteTermios is the ioctl request changing terminal attributes
+	ioctlWriteTermios = unix.TIOCSETA
+)
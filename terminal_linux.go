@@ -0,0 +1,10 @@
+package main
+
+import "golang.org/x/sys/unix"
+
+const (
+	// ioctlReadTermios is the ioctl request reading terminal attributes
+	ioctlReadTermios = unix.TCGETS
+	// ioctlWriteTermios is the ioctl request changing terminal attributes
+	ioctlWriteTermios = unix.TCSETS
+)
@@ -0,0 +1,19 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd || windows)
+
+package main
+
+import "os"
+
+// IsTerminal checks if f is a character device since terminals cannot be detected
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// hideInput cannot stop echoing on this platform, so input stays visible
+func hideInput(f *os.File) (restore func(), err error) {
+	return func() {}, nil
+}
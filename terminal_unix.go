@@ -0,0 +1,31 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// IsTerminal checks if f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetTermios(int(f.Fd()), ioctlReadTermios)
+	return err == nil
+}
+
+// hideInput stops the terminal f from echoing what is typed until restore is called
+func hideInput(f *os.File) (restore func(), err error) {
+	fd := int(f.Fd())
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, fmt.Errorf("read terminal attributes: %w", err)
+	}
+	hidden := *termios
+	hidden.Lflag &^= unix.ECHO
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, &hidden); err != nil {
+		return nil, fmt.Errorf("hide input: %w", err)
+	}
+	return func() { unix.IoctlSetTermios(fd, ioctlWriteTermios, termios) }, nil
+}
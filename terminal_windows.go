@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// IsTerminal checks if f is an interactive console
+func IsTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
+
+// hideInput stops the console f from echoing what is typed until restore is called
+func hideInput(f *os.File) (restore func(), err error) {
+	handle := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return nil, fmt.Errorf("read console mode: %w", err)
+	}
+	if err := windows.SetConsoleMode(handle, mode&^windows.ENABLE_ECHO_INPUT); err != nil {
+		return nil, fmt.Errorf("hide input: %w", err)
+	}
+	return func() { windows.SetConsoleMode(handle, mode) }, nil
+}
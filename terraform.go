@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+var terraformTool = Tool{
+	Name: "terraform",
+	Installers: map[string][]Installer{
+		"linux":   {{Requires: "snap", Command: "sudo snap install terraform --classic"}},
+		"darwin":  {{Requires: "brew", Command: "brew tap hashicorp/tap && brew install hashicorp/tap/terraform"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=Hashicorp.Terraform -e"}},
+	},
+}
+
+var tofuTool = Tool{
+	Name: "tofu",
+	Installers: map[string][]Installer{
+		"linux":   {{Requires: "snap", Command: "sudo snap install opentofu --classic"}},
+		"darwin":  {{Requires: "brew", Command: "brew install opentofu"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=OpenTofu.Tofu -e"}},
+	},
+}
+
+// terraformFiles are written to new Terraform environments. The backend override
+// keeps the state in the sandbox even when a backend is added to main.tf.
+var terraformFiles = map[string]string{
+	"main.tf": `terraform {
+  required_providers {
+    random = {
+      source = "hashicorp/random"
+    }
+  }
+}
+
+resource "random_pet" "name" {
+  prefix = var.prefix
+}
+
+output "name" {
+  value = random_pet.name.id
+}
+`,
+	"variables.tf": `variable "prefix" {
+  description = "Prefix of the generated name"
+  type        = string
+  default     = "scratch"
+}
+`,
+	"backend_override.tf": `terraform {
+  backend "local" {
+    path = "terraform.tfstate"
+  }
+}
+`,
+}
+
+// TerraformEnvironment represents an infrastructure sandbox with local state,
+// run with terraform or OpenTofu when only tofu is installed
+type TerraformEnvironment struct{}
+
+// binary returns terraform, or tofu when terraform is missing
+func (p TerraformEnvironment) binary() string {
+	if CommandsExist("terraform") != nil && CommandsExist("tofu") == nil {
+		return "tofu"
+	}
+	return "terraform"
+}
+
+// Ready checks if terraform or tofu is installed
+func (p TerraformEnvironment) Ready() error {
+	if err := CommandsExist(p.binary()); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	return nil
+}
+
+// init initializes the working directory, installing the providers, with args like -upgrade
+func (p TerraformEnvironment) init(dir string, args ...string) error {
+	binary := p.binary()
+	if err := RunCommand(dir, binary, append([]string{"init", "-input=false", "-no-color"}, args...)...); err != nil {
+		return fmt.Errorf("%s init: %w", binary, err)
+	}
+	return nil
+}
+
+// Provision creates the environment at provided directory
+func (p TerraformEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	if err := writeMissingFiles(dir, terraformFiles); err != nil {
+		return err
+	}
+	if err := p.init(dir); err != nil {
+		return err
+	}
+
+	slog.Debug("Provisioned terraform environment", slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes initialized configurations, and likely any with .tf files
+func (p TerraformEnvironment) Detect(dir string) float64 {
+	confidence := detectFiles(dir, map[string]float64{
+		".terraform.lock.hcl": StrongMatch,
+		".terraform":          StrongMatch,
+	})
+	return max(confidence, detectGlob(dir, "*.tf", LikelyMatch))
+}
+
+// Describe summarizes terraform environments
+func (p TerraformEnvironment) Describe() Description {
+	return Description{Summary: "An infrastructure sandbox for terraform or OpenTofu with local state", Examples: []string{"scratch new infra -t terraform"}}
+}
+
+// Tools returns terraform, or tofu when it is the one installed
+func (p TerraformEnvironment) Tools() []Tool {
+	if p.binary() == "tofu" {
+		return []Tool{tofuTool}
+	}
+	return []Tool{terraformTool}
+}
+
+// Check verifies the configuration files exist
+func (p TerraformEnvironment) Check(dir string) error {
+	return FilesExist(dir, slices.Sorted(maps.Keys(terraformFiles))...)
+}
+
+// Ignore returns the providers and modules installed by init, and the local state
+// that describes resources of the original sandbox
+func (p TerraformEnvironment) Ignore() []string {
+	return []string{".terraform", "*.tfstate", "*.tfstate.backup"}
+}
+
+// Rebuild installs the providers again
+func (p TerraformEnvironment) Rebuild(dir string) error {
+	return p.init(dir)
+}
+
+// Reprovision writes the configuration files that are missing and initializes
+// the directory if providers are missing
+func (p TerraformEnvironment) Reprovision(dir string) error {
+	if err := writeMissingFiles(dir, terraformFiles); err != nil {
+		return err
+	}
+	if FilesExist(dir, ".terraform") != nil {
+		slog.Debug("Initializing missing providers", slog.String("dir", dir))
+		return p.init(dir)
+	}
+	return nil
+}
+
+// Upgrade upgrades the providers to the newest versions the configuration allows
+func (p TerraformEnvironment) Upgrade(dir string) error {
+	return p.init(dir, "-upgrade")
+}
@@ -0,0 +1,44 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTofu records its arguments and creates the provider directory like init
+const fakeTofu = `#!/bin/sh
+echo "$@" >> tofu.log
+/bin/mkdir -p .terraform
+`
+
+func TestTerraformEnvironment_Provision(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("tofu is faked with a shell script")
+	}
+	// Only OpenTofu is installed
+	t.Setenv("PATH", fakeBin(t, map[string]string{"tofu": fakeTofu}))
+
+	dir := filepath.Join(t.TempDir(), "infra")
+	p := main.TerraformEnvironment{}
+	require.NoError(t, p.Ready())
+	require.NoError(t, p.Provision(dir))
+	require.NoError(t, p.Check(dir))
+
+	backend, err := os.ReadFile(filepath.Join(dir, "backend_override.tf"))
+	require.NoError(t, err)
+	require.Contains(t, string(backend), `backend "local"`)
+
+	specType, err := main.DetectType(dir)
+	require.NoError(t, err)
+	require.Equal(t, main.TerraformSpec, specType)
+
+	require.NoError(t, p.Upgrade(dir))
+	log, err := os.ReadFile(filepath.Join(dir, "tofu.log"))
+	require.NoError(t, err)
+	require.Equal(t, "init -input=false -no-color\ninit -input=false -no-color -upgrade\n", string(log))
+}
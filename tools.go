@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tool is a program a provisioner needs, with the official ways to install it
+type Tool struct {
+	Name string
+	// Installers maps GOOS to install commands in order of preference
+	Installers map[string][]Installer
+}
+
+// Installer is a shell command installing a tool
+type Installer struct {
+	// Requires is the program the command needs, like brew or winget, if any
+	Requires string
+	Command  string
+}
+
+// ToolUser is implemented by provisioners that know how to install the programs they need
+type ToolUser interface {
+	Tools() []Tool
+}
+
+var uvTool = Tool{
+	Name: "uv",
+	Installers: map[string][]Installer{
+		"linux":   {{Command: "curl -LsSf https://astral.sh/uv/install.sh | sh"}},
+		"darwin":  {{Requires: "brew", Command: "brew install uv"}, {Command: "curl -LsSf https://astral.sh/uv/install.sh | sh"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=astral-sh.uv -e"}, {Command: "irm https://astral.sh/uv/install.ps1 | iex"}},
+	},
+}
+
+// Installer returns the preferred way to install the tool on this machine
+func (t Tool) Installer() (Installer, bool) {
+	for _, installer := range t.Installers[runtime.GOOS] {
+		if installer.Requires == "" || CommandsExist(installer.Requires) == nil {
+			return installer, true
+		}
+	}
+	return Installer{}, false
+}
+
+// Hint describes how to install the tool
+func (t Tool) Hint() string {
+	installer, ok := t.Installer()
+	if !ok {
+		return fmt.Sprintf("install %s and make sure it is in PATH", t.Name)
+	}
+	return fmt.Sprintf("install %s with: %s", t.Name, installer.Command)
+}
+
+// MissingTools returns the tools p needs that are not in PATH
+func MissingTools(p Provisioner) []Tool {
+	user, ok := p.(ToolUser)
+	if !ok {
+		return nil
+	}
+	missing := []Tool{}
+	for _, tool := range user.Tools() {
+		if CommandsExist(tool.Name) != nil {
+			missing = append(missing, tool)
+		}
+	}
+	return missing
+}
+
+// ToolsHint describes how to install the tools p is missing
+func ToolsHint(p Provisioner) string {
+	hints := []string{}
+	for _, tool := range MissingTools(p) {
+		hints = append(hints, tool.Hint())
+	}
+	return strings.Join(hints, "; ")
+}
+
+// checkReady checks p is ready, describing how to install the tools it is missing
+func checkReady(p Provisioner) error {
+	err := p.Ready()
+	if err == nil {
+		return nil
+	}
+	return WithHint(fmt.Errorf("%w: %w", ErrProvisioner, err), ToolsHint(p))
+}
+
+// DefaultReadyTimeout bounds how long a provisioner may take to check it is ready,
+// like looking up programs on a PATH with slow network mounts
+const DefaultReadyTimeout = 5 * time.Second
+
+// checkReadyWithin checks p is ready like checkReady, failing when it takes longer than
+// timeout, or DefaultReadyTimeout if unset. The check keeps running in the background
+// but is not waited for.
+func checkReadyWithin(p Provisioner, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+	done := make(chan error, 1)
+	go func() { done <- checkReady(p) }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("%w: readiness check did not finish within %s", ErrProvisioner, timeout)
+	}
+}
+
+// CheckReadiness checks the provisioners of types are ready concurrently, each within
+// timeout or DefaultReadyTimeout if unset, and returns their errors in the order of
+// types, nil for the ready ones
+func CheckReadiness(types []SpecType, timeout time.Duration) []error {
+	defer timePhase("check readiness")()
+	errs := make([]error, len(types))
+	var wg sync.WaitGroup
+	for i, t := range types {
+		wg.Go(func() {
+			p, err := NewProvisioner(t)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = checkReadyWithin(p, timeout)
+		})
+	}
+	wg.Wait()
+	return errs
+}
+
+// installMissingTools installs the tools p is missing after confirming each one
+func installMissingTools(p Provisioner) error {
+	for _, tool := range MissingTools(p) {
+		installer, ok := tool.Installer()
+		if !ok {
+			return fmt.Errorf("no installer for %s on %s", tool.Name, runtime.GOOS)
+		}
+		if !canPrompt() {
+			return fmt.Errorf("%w: cannot ask to install %s, run %q", ErrProvisioner, tool.Name, installer.Command)
+		}
+		ok, err := askForConfirmation(fmt.Sprintf("%s is missing, install it with %q?", tool.Name, installer.Command))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := InstallTool(tool); err != nil {
+			return err
+		}
+		if CommandsExist(tool.Name) != nil {
+			return fmt.Errorf("%s was installed but is not in PATH yet, open a new shell and try again", tool.Name)
+		}
+		output.Success("Installed %s", tool.Name)
+	}
+	return nil
+}
+
+// InstallTool runs the preferred installer of tool, showing its output
+func InstallTool(tool Tool) error {
+	installer, ok := tool.Installer()
+	if !ok {
+		return fmt.Errorf("no installer for %s on %s", tool.Name, runtime.GOOS)
+	}
+
+	cmd := shellCommand(installer.Command)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("install %s: %w", tool.Name, err)
+	}
+	return nil
+}
+
+// shellCommand runs command with the shell of the platform
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("powershell", "-NoProfile", "-ExecutionPolicy", "ByPass", "-Command", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
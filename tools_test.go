@@ -0,0 +1,63 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTool_Installer(t *testing.T) {
+	tool := main.Tool{
+		Name: "foo",
+		Installers: map[string][]main.Installer{
+			runtime.GOOS: {{Requires: "scratch-missing-manager", Command: "missing install foo"}, {Command: "install foo"}},
+		},
+	}
+	installer, ok := tool.Installer()
+	require.True(t, ok)
+	require.Equal(t, "install foo", installer.Command)
+	require.Equal(t, "install foo with: install foo", tool.Hint())
+
+	tool.Installers = nil
+	_, ok = tool.Installer()
+	require.False(t, ok)
+	require.Equal(t, "install foo and make sure it is in PATH", tool.Hint())
+}
+
+func TestCheckReadiness(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "scratch-provision-slow"), []byte("#!/bin/sh\nsleep 2\necho '{}'\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "scratch-provision-fast"), []byte("#!/bin/sh\necho '{}'\n"), 0755))
+	t.Setenv("PATH", bin+string(filepath.ListSeparator)+os.Getenv("PATH"))
+
+	start := time.Now()
+	errs := main.CheckReadiness([]main.SpecType{"slow", "fast", "missing", "slow"}, 200*time.Millisecond)
+	require.Less(t, time.Since(start), time.Second)
+	require.Len(t, errs, 4)
+	require.ErrorIs(t, errs[0], main.ErrProvisioner)
+	require.ErrorContains(t, errs[0], "did not finish within 200ms")
+	require.NoError(t, errs[1])
+	require.ErrorIs(t, errs[2], main.ErrUnknownType)
+	require.Error(t, errs[3])
+}
+
+func TestCheckReadiness_DefaultTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "scratch-provision-fast"), []byte("#!/bin/sh\necho '{}'\n"), 0755))
+	t.Setenv("PATH", bin+string(filepath.ListSeparator)+os.Getenv("PATH"))
+
+	// A zero timeout, like from a command struct built without kong, falls back to the default
+	errs := main.CheckReadiness([]main.SpecType{"fast"}, 0)
+	require.NoError(t, errs[0])
+}
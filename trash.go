@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// trashKeyPrefix prefixes the store keys of deleted environments kept in the trash
+const trashKeyPrefix = "trash/"
+
+// defaultTrashDays is how long deleted environments are kept by default
+const defaultTrashDays = 7
+
+// TrashConfig configures how deleted environments are kept
+type TrashConfig struct {
+	// Days is how long deleted environments are kept before they are purged
+	Days int `json:"days,omitempty"`
+}
+
+// retention returns how long deleted environments are kept, defaulting to a week
+func (c TrashConfig) retention() time.Duration {
+	days := c.Days
+	if days <= 0 {
+		days = defaultTrashDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DefaultTrashDir returns the directory deleted environments are moved to
+func DefaultTrashDir() (string, error) {
+	dir, err := DefaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ".trash"), nil
+}
+
+// trashKey returns the store key of the spec while it is in the trash
+func (s Spec) trashKey() string {
+	return trashKeyPrefix + s.UID
+}
+
+// IsDeleted checks if the environment is in the trash
+func (s Spec) IsDeleted() bool {
+	return !s.Deleted.IsZero()
+}
+
+// TrashEnvironment moves the directory of spec into trashDir and keeps the spec
+// in the trash so it can be restored
+func TrashEnvironment(store Writer, spec Spec, trashDir string, now time.Time) (Spec, error) {
+	key, indexed := spec.Key(), spec.UID != ""
+	spec = spec.withUID()
+	trashed := spec
+	trashed.Deleted = now
+	trashed.Trash = filepath.Join(trashDir, spec.UID)
+
+	if _, err := os.Stat(spec.Location()); err == nil {
+		if err := MoveTree(spec.Location(), trashed.Trash); err != nil {
+			return Spec{}, fmt.Errorf("move %s to trash: %w", spec.ID(), err)
+		}
+	} else {
+		trashed.Trash = ""
+	}
+
+	data, err := marshalSpec(trashed)
+	if err != nil {
+		return Spec{}, fmt.Errorf("marshal spec to json: %w", err)
+	}
+	if err := store.Put(trashed.trashKey(), data); err != nil {
+		return Spec{}, err
+	}
+	// The provisioning log stays until the environment is purged
+	if err := store.Delete(key); err != nil {
+		return Spec{}, err
+	}
+	if indexed {
+		if err := store.Delete(spec.nameKey()); err != nil && !errors.Is(err, ErrNotFound) {
+			return Spec{}, err
+		}
+	}
+	return trashed, nil
+}
+
+// LoadTrash loads every environment in the trash
+func LoadTrash(lister Lister) ([]Spec, error) {
+	specs := []Spec{}
+	err := lister.ListFunc(func(key string, data []byte) error {
+		if !strings.HasPrefix(key, trashKeyPrefix) {
+			return nil
+		}
+		spec, err := LoadSpec(data)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", key, err)
+		}
+		specs = append(specs, spec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// RestoreFromTrash moves the directory of a deleted environment back and tracks it again
+func RestoreFromTrash(store Storer, spec Spec) (Spec, error) {
+	if err := checkDuplicate(store, spec); err != nil {
+		return Spec{}, err
+	}
+
+	restored := spec
+	restored.Deleted = time.Time{}
+	restored.Trash = ""
+	if spec.Trash != "" {
+		if _, err := os.Stat(restored.Location()); err == nil {
+			return Spec{}, fmt.Errorf("directory %s %w", restored.Location(), ErrExists)
+		}
+		if err := MoveTree(spec.Trash, restored.Location()); err != nil {
+			return Spec{}, fmt.Errorf("restore %s from trash: %w", spec.ID(), err)
+		}
+	}
+
+	if err := restored.Save(store); err != nil {
+		return Spec{}, err
+	}
+	if err := store.Delete(spec.trashKey()); err != nil {
+		return Spec{}, err
+	}
+	return restored, nil
+}
+
+// purgeEnvironment permanently removes a deleted environment from the trash
+func purgeEnvironment(store Writer, spec Spec) error {
+	event := NewEvent(ActionDelete, spec)
+	if spec.Trash != "" {
+		// The size is best effort since the directory may already be gone
+		if size, err := DirSize(spec.Trash); err == nil {
+			event.Size = size
+		}
+		remove := removeTree
+		if spec.IsArchived() {
+			remove = removeArchive
+		}
+		if err := remove(spec.Trash); err != nil {
+			return fmt.Errorf("purge %s: %w", spec.ID(), err)
+		}
+	}
+
+	if err := store.Delete(spec.trashKey()); err != nil {
+		return err
+	}
+	if err := store.Delete(spec.logKey()); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err := store.Delete(spec.manifestKey()); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	recordEvent(event)
+	return nil
+}
+
+// PurgeTrash permanently removes environments deleted before cutoff
+func PurgeTrash(store Storer, cutoff time.Time) (int, error) {
+	specs, err := LoadTrash(store)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, spec := range specs {
+		if !spec.Deleted.Before(cutoff) {
+			continue
+		}
+		if err := purgeEnvironment(store, spec); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeTrash removes environments that have been in the trash longer than configured
+func (c *CLIContext) purgeTrash(store Storer) error {
+	config, err := c.Config()
+	if err != nil {
+		return err
+	}
+	purged, err := PurgeTrash(store, time.Now().Add(-config.Trash.retention()))
+	if err != nil {
+		return fmt.Errorf("purge trash: %w", err)
+	}
+	if purged > 0 {
+		output.Info("Purged %d environments from the trash", purged)
+	}
+	return nil
+}
+
+// trashEnvironment moves the environment to the trash and reports how to restore it
+func trashEnvironment(store Writer, spec Spec) error {
+	// Only the tracking is removed, as the directory is kept
+	if spec.InPlace {
+		return removeEnvironment(store, spec)
+	}
+	trashDir, err := DefaultTrashDir()
+	if err != nil {
+		return err
+	}
+	event := NewEvent(ActionTrash, spec).With("description", redactSealed(spec).Description).With("tags", strings.Join(spec.Tags, ",")).WithContents(spec.Location())
+	if _, err := TrashEnvironment(store, spec, trashDir, time.Now()); err != nil {
+		return err
+	}
+	recordEvent(event)
+	output.Success("Deleted %s, restore it with scratch undelete %s", spec.ID(), spec.Name)
+	return nil
+}
+
+// UndeleteCmd represents the command to restore environments from the trash
+type UndeleteCmd struct {
+	Query string `arg:"" optional:"" help:"All or part of the name of the deleted environment"`
+	List  bool   `short:"l" help:"List environments in the trash"`
+	Empty bool   `help:"Permanently remove every environment in the trash"`
+	Force bool   `short:"f" help:"Empty the trash without confirmation"`
+}
+
+// Validate checks a query is given unless listing or emptying the trash
+func (u UndeleteCmd) Validate() error {
+	if (u.List || u.Empty) == (u.Query != "") {
+		return fmt.Errorf("must specify a name query, --list or --empty")
+	}
+	if u.List && u.Empty {
+		return fmt.Errorf("--list cannot be used with --empty")
+	}
+	return nil
+}
+
+// Run restores the deleted environment, lists the trash or empties it
+func (u UndeleteCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	if err := ctx.purgeTrash(store); err != nil {
+		return err
+	}
+
+	specs, err := LoadTrash(store)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case u.List:
+		for _, spec := range specs {
+			fmt.Printf("%s\tdeleted %s\n", spec, spec.Deleted.Local().Format(time.DateTime))
+		}
+		return nil
+	case u.Empty:
+		return u.empty(store, specs)
+	}
+
+	matches := MatchSpecs(specs, u.Query)
+	if len(matches) == 0 {
+		return fmt.Errorf("no deleted environment matches %q: %w", u.Query, ErrNotFound)
+	}
+	spec, err := chooseSpec(fmt.Sprintf("deleted environments match %q", u.Query), matches)
+	if err != nil {
+		return err
+	}
+
+	restored, err := RestoreFromTrash(store, spec)
+	if err != nil {
+		return err
+	}
+	recordEvent(NewEvent(ActionUndelete, restored))
+	output.Success("Restored %s at %s", restored.ID(), restored.Location())
+	return nil
+}
+
+// empty permanently removes specs from the trash after confirmation
+func (u UndeleteCmd) empty(store Writer, specs []Spec) error {
+	if len(specs) == 0 {
+		output.Info("The trash is empty")
+		return nil
+	}
+	if !u.Force {
+		items := make([]string, len(specs))
+		for i, spec := range specs {
+			items[i] = spec.ID()
+		}
+		ok, err := confirmAll(fmt.Sprintf("Permanently remove these %d environments?", len(specs)), items)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			output.Info("Not emptying the trash")
+			return nil
+		}
+	}
+
+	for _, spec := range specs {
+		if err := purgeEnvironment(store, spec); err != nil {
+			return err
+		}
+	}
+	output.Success("Permanently removed %d environments", len(specs))
+	return nil
+}
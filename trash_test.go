@@ -0,0 +1,77 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashEnvironment(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	wd, trashDir := t.TempDir(), t.TempDir()
+	store := NewMemoryStore()
+	spec := main.NewSpec("test", main.PythonSpec, wd)
+	require.NoError(t, os.MkdirAll(spec.Path, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(spec.Path, "main.py"), []byte("print()"), 0644))
+	require.NoError(t, spec.Save(store))
+
+	now := time.Now()
+	trashed, err := main.TrashEnvironment(store, spec, trashDir, now)
+	require.NoError(t, err)
+	require.True(t, trashed.IsDeleted())
+	require.NoDirExists(t, spec.Path)
+	require.FileExists(t, filepath.Join(trashed.Trash, "main.py"))
+
+	// Deleted environments are no longer tracked
+	specs, err := main.LoadSpecs(store)
+	require.NoError(t, err)
+	require.Empty(t, specs)
+	found, err := main.FindSpecs(store, main.PythonSpec, "test")
+	require.NoError(t, err)
+	require.Empty(t, found)
+
+	trash, err := main.LoadTrash(store)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+
+	restored, err := main.RestoreFromTrash(store, trash[0])
+	require.NoError(t, err)
+	require.Equal(t, spec, restored)
+	require.FileExists(t, filepath.Join(spec.Path, "main.py"))
+	trash, err = main.LoadTrash(store)
+	require.NoError(t, err)
+	require.Empty(t, trash)
+
+	// Environments deleted before the cutoff are purged
+	trashed, err = main.TrashEnvironment(store, spec, trashDir, now.Add(-48*time.Hour))
+	require.NoError(t, err)
+	purged, err := main.PurgeTrash(store, now.Add(-72*time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, purged)
+	purged, err = main.PurgeTrash(store, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+	require.NoDirExists(t, trashed.Trash)
+	require.Empty(t, store.Data)
+}
+
+func TestRestoreFromTrash_Conflict(t *testing.T) {
+	wd, trashDir := t.TempDir(), t.TempDir()
+	store := NewMemoryStore()
+	spec := main.NewSpec("test", main.PythonSpec, wd)
+	require.NoError(t, os.MkdirAll(spec.Path, 0755))
+	require.NoError(t, spec.Save(store))
+
+	trashed, err := main.TrashEnvironment(store, spec, trashDir, time.Now())
+	require.NoError(t, err)
+
+	// A new environment took its place
+	require.NoError(t, os.MkdirAll(spec.Path, 0755))
+	_, err = main.RestoreFromTrash(store, trashed)
+	require.ErrorIs(t, err, main.ErrExists)
+	require.DirExists(t, trashed.Trash)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+// crockford is the base32 alphabet of ULIDs, without I, L, O and U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidLength is the length of an encoded ULID
+const ulidLength = 26
+
+// NewULID returns a ULID for t: a 48 bit millisecond timestamp followed by 80 random bits,
+// so IDs sort by creation time
+func NewULID(t time.Time) string {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(t.UnixMilli())<<16)
+	_, _ = rand.Read(id[6:])
+	return encodeULID(id)
+}
+
+// legacyULID derives a stable ULID from the ID of an environment created before ULIDs,
+// so every machine migrating the same environment assigns it the same ULID
+func legacyULID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	var ulid [16]byte
+	// The timestamp is left zero so legacy environments sort first
+	copy(ulid[6:], sum[:10])
+	return encodeULID(ulid)
+}
+
+// IsULID checks if s is an encoded ULID
+func IsULID(s string) bool {
+	if len(s) != ulidLength || s[0] > '7' {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune(crockford, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeULID encodes the 128 bits of id as 26 base32 characters
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	var out [ulidLength]byte
+	for i := ulidLength - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// undoPlan is how to reverse an event, confirmed with prompt before apply runs
+type undoPlan struct {
+	prompt string
+	apply  func() error
+}
+
+// lastChange returns the most recent event that changed an environment, as
+// opening one changes nothing to undo
+func lastChange(events []Event) (Event, bool) {
+	for _, event := range slices.Backward(events) {
+		if event.Action == ActionOpen {
+			continue
+		}
+		return event, true
+	}
+	return Event{}, false
+}
+
+// planUndo returns how to reverse event, refusing operations that cannot be reversed
+func planUndo(store Storer, event Event) (undoPlan, error) {
+	when := event.Time.Local().Format(time.DateTime)
+	switch event.Action {
+	case ActionTrash:
+		trash, err := LoadTrash(store)
+		if err != nil {
+			return undoPlan{}, err
+		}
+		i := slices.IndexFunc(trash, func(spec Spec) bool { return spec.UID == event.UID })
+		if i < 0 {
+			return undoPlan{}, fmt.Errorf("%s deleted at %s is no longer in the trash: %w", event.ID, when, ErrNotFound)
+		}
+		return undoPlan{
+			prompt: fmt.Sprintf("Restore %s deleted at %s?", event.ID, when),
+			apply: func() error {
+				restored, err := RestoreFromTrash(store, trash[i])
+				if err != nil {
+					return err
+				}
+				recordEvent(NewEvent(ActionUndelete, restored))
+				output.Success("Restored %s at %s", restored.ID(), restored.Location())
+				return nil
+			},
+		}, nil
+
+	case ActionRename:
+		from, ok := event.Params["from"]
+		if !ok {
+			return undoPlan{}, fmt.Errorf("the previous name of %s was not recorded, rename it back with scratch rename", event.ID)
+		}
+		_, name, err := ParseSpecID(from)
+		if err != nil {
+			return undoPlan{}, err
+		}
+		spec, err := LookupID(store, event.UID)
+		if err != nil {
+			return undoPlan{}, fmt.Errorf("%s renamed at %s: %w", event.ID, when, err)
+		}
+		return undoPlan{
+			prompt: fmt.Sprintf("Rename %s back to %s?", spec.ID(), from),
+			apply: func() error {
+				renamed, err := RenameSpec(store, spec, name)
+				if err != nil {
+					return err
+				}
+				recordEvent(NewEvent(ActionRename, renamed).With("from", spec.ID()))
+				output.Success("Renamed %s back to %s", spec.ID(), renamed.ID())
+				return nil
+			},
+		}, nil
+
+	case ActionCreate, ActionClone, ActionUndelete:
+		spec, err := LookupID(store, event.UID)
+		if err != nil {
+			return undoPlan{}, fmt.Errorf("%s created at %s: %w", event.ID, when, err)
+		}
+		if spec.Locked {
+			return undoPlan{}, fmt.Errorf("%s is locked, unlock it with 'scratch unlock %s' first: %w", spec.ID(), spec.Name, ErrProtected)
+		}
+		verb := map[HistoryAction]string{ActionCreate: "created", ActionClone: "cloned", ActionUndelete: "restored"}[event.Action]
+		return undoPlan{
+			prompt: fmt.Sprintf("Delete %s %s at %s? It can be restored with scratch undo", spec.ID(), verb, when),
+			apply: func() error {
+				return trashEnvironment(store, spec)
+			},
+		}, nil
+
+	case ActionDelete, ActionExpire:
+		switch {
+		case event.Params["keep_files"] == "true":
+			return undoPlan{}, fmt.Errorf("cannot undo the %s of %s at %s, track its directory again with 'scratch adopt %s'", event.Action, event.ID, when, event.Path)
+		case event.Params["files_only"] == "true":
+			return undoPlan{}, fmt.Errorf("cannot undo removing the files of %s at %s, recreate them with scratch reprovision", event.ID, when)
+		case event.Params["trash"] == "system":
+			return undoPlan{}, fmt.Errorf("cannot undo the %s of %s at %s, restore its directory from the trash of the system and adopt it", event.Action, event.ID, when)
+		}
+		return undoPlan{}, fmt.Errorf("cannot undo the %s of %s at %s, as it was removed permanently", event.Action, event.ID, when)
+	case ActionAdopt:
+		return undoPlan{}, fmt.Errorf("cannot undo adopting %s at %s, delete it to remove the directory too", event.ID, when)
+	}
+	return undoPlan{}, fmt.Errorf("cannot undo %s of %s", event.Action, event.ID)
+}
+
+// UndoCmd represents the command to reverse the last operation
+type UndoCmd struct {
+	Force bool `short:"f" help:"Undo without confirmation"`
+}
+
+// Run reverses the most recent operation in the history log of the workspace
+func (u UndoCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	path, err := DefaultHistoryPath()
+	if err != nil {
+		return err
+	}
+	events, err := LoadEvents(path)
+	if err != nil {
+		return err
+	}
+	events = slices.DeleteFunc(events, func(event Event) bool {
+		return cmp.Or(event.Workspace, DefaultWorkspace) != workspace
+	})
+
+	event, ok := lastChange(events)
+	if !ok {
+		output.Info("Nothing to undo")
+		return nil
+	}
+	plan, err := planUndo(store, event)
+	if err != nil {
+		return err
+	}
+
+	if !u.Force {
+		ok, err := askForConfirmation(plan.prompt)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			output.Info("Not undoing %s of %s", event.Action, event.ID)
+			return nil
+		}
+	}
+	return plan.apply()
+}
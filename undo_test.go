@@ -0,0 +1,51 @@
+package main_test
+
+import (
+	"os"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoCmd(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	ctx := &main.CLIContext{}
+	store, err := ctx.Store()
+	require.NoError(t, err)
+	undo := main.UndoCmd{Force: true}
+
+	require.NoError(t, undo.Run(ctx))
+
+	// Environments outside the data directory cannot be deleted without --unsafe
+	dataDir, err := main.DefaultDataDir()
+	require.NoError(t, err)
+	spec := main.NewSpec("undone", main.PythonSpec, dataDir)
+	require.NoError(t, os.MkdirAll(spec.Path, 0755))
+	require.NoError(t, spec.Save(store))
+	history, err := main.DefaultHistoryPath()
+	require.NoError(t, err)
+	require.NoError(t, main.AppendEvent(history, main.NewEvent(main.ActionCreate, spec)))
+
+	require.NoError(t, main.RenameCmd{Query: "undone", To: "renamed"}.Run(ctx))
+	require.NoError(t, undo.Run(ctx))
+	spec, err = main.LookupID(store, spec.UID)
+	require.NoError(t, err)
+	require.Equal(t, "undone", spec.Name)
+	require.DirExists(t, spec.Path)
+
+	require.NoError(t, main.DeleteCmd{IdentifyFlags: main.IdentifyFlags{ID: spec.UID}, Force: true}.Run(ctx))
+	require.NoDirExists(t, spec.Path)
+	require.NoError(t, undo.Run(ctx))
+	require.DirExists(t, spec.Path)
+
+	// Undoing the restore deletes the environment again
+	require.NoError(t, undo.Run(ctx))
+	require.NoDirExists(t, spec.Path)
+	require.NoError(t, undo.Run(ctx))
+	require.DirExists(t, spec.Path)
+
+	require.NoError(t, main.DeleteCmd{IdentifyFlags: main.IdentifyFlags{ID: spec.UID}, Force: true, Permanent: true}.Run(ctx))
+	require.ErrorContains(t, undo.Run(ctx), "removed permanently")
+}
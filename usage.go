@@ -0,0 +1,187 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// usageCacheTTL is how long a measured disk usage is reused before rescanning
+const usageCacheTTL = time.Hour
+
+// DiskUsage is a cached measurement of the size of an environment directory
+type DiskUsage struct {
+	Size    ByteSize
+	Scanned time.Time
+}
+
+// DirSize sums the size of all regular files under dir, counting hard linked files once
+func DirSize(dir string) (ByteSize, error) {
+	var size ByteSize
+	seen := map[any]bool{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if id, ok := fileID(info); ok {
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+		}
+		size += ByteSize(info.Size())
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("measure %s: %w", dir, err)
+	}
+	return size, nil
+}
+
+// Location returns where the environment currently lives on disk
+func (s Spec) Location() string {
+	if s.IsArchived() {
+		return s.Archive
+	}
+	return s.Path
+}
+
+// MeasureUsage returns the spec with its disk usage, reusing the cached
+// measurement unless it is stale or refresh is set, and whether it was rescanned.
+// Deprovisioned environments have no directory and use no space.
+func (s Spec) MeasureUsage(refresh bool) (Spec, bool, error) {
+	if s.IsDeprovisioned() {
+		s.Usage = &DiskUsage{}
+		return s, false, nil
+	}
+	if !refresh && s.Usage != nil && time.Since(s.Usage.Scanned) < usageCacheTTL {
+		return s, false, nil
+	}
+
+	size, err := DirSize(s.Location())
+	if err != nil {
+		return s, false, err
+	}
+	s.Usage = &DiskUsage{Size: size, Scanned: time.Now().UTC()}
+	return s, true, nil
+}
+
+// cacheUsage saves the usage measured for spec into its record in store, read again
+// so changes other commands made while measuring are kept and deleted environments
+// are not saved back
+func cacheUsage(store Storer, spec Spec) error {
+	current, err := LookupSpec(store, spec.Key())
+	if err != nil {
+		return err
+	}
+	current.Usage = spec.Usage
+	return current.Save(store)
+}
+
+// measureSpecs measures every spec and caches new measurements in store
+// when possible, e.g. not when reading from a snapshot
+func measureSpecs(store Storer, specs []Spec, refresh bool) []Spec {
+	measured := make([]Spec, 0, len(specs))
+	for _, spec := range specs {
+		spec, scanned, err := spec.MeasureUsage(refresh)
+		if err != nil {
+			output.Warn("Could not measure %s: %s", spec.ID(), err)
+			continue
+		}
+		if scanned {
+			if err := cacheUsage(store, spec); err != nil {
+				slog.Debug("Could not cache disk usage", slog.String("id", spec.ID()), slog.Any("error", err))
+			}
+		}
+		measured = append(measured, spec)
+	}
+	return measured
+}
+
+// DuCmd represents the command to report disk usage of environments
+type DuCmd struct {
+	Refresh bool `help:"Rescan directories instead of using cached sizes"`
+}
+
+// Run prints the size of each environment, largest first, and totals
+// for live and archived environments and reclaimable space
+func (d DuCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return err
+	}
+
+	specs = measureSpecs(store, specs, d.Refresh)
+	slices.SortFunc(specs, func(a, b Spec) int {
+		return cmp.Compare(b.Usage.Size, a.Usage.Size)
+	})
+
+	var live ByteSize
+	for _, spec := range specs {
+		state := "live"
+		if spec.IsArchived() {
+			state = "archived"
+		} else {
+			live += spec.Usage.Size
+		}
+		fmt.Printf("%10s  %-8s  %s\n", spec.Usage.Size, state, spec.ID())
+	}
+
+	// Archives share deduplicated files so they are measured together
+	archiveDir, err := DefaultArchiveDir()
+	if err != nil {
+		return err
+	}
+	var archived ByteSize
+	if _, err := os.Stat(archiveDir); err == nil {
+		archived, err = DirSize(archiveDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	items, err := PlanGC(archiveDir, specs)
+	if err != nil {
+		return err
+	}
+	var reclaimable ByteSize
+	for _, item := range items {
+		reclaimable += item.Size
+	}
+
+	fmt.Println()
+	fmt.Printf("%10s  live\n", live)
+	fmt.Printf("%10s  archived\n", archived)
+	fmt.Printf("%10s  reclaimable with 'scratch gc --apply'\n", reclaimable)
+	fmt.Printf("%10s  total\n", live+archived)
+
+	config, err := ctx.Config()
+	if err != nil {
+		return err
+	}
+	if quota := config.Disk.Quota; quota > 0 {
+		usage := MeasureQuota(specs, quota)
+		fmt.Printf("%10s  quota, %d%% used\n", quota, usage.Used*100/quota)
+		if usage.Over() {
+			output.Warn("Environments exceed the quota, largest are %s", usage.Offenders())
+		}
+	}
+	return nil
+}
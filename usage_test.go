@@ -0,0 +1,80 @@
+package main_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirSize(t *testing.T) {
+	tdir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tdir, "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tdir, "a"), make([]byte, 100), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tdir, "pkg", "b"), make([]byte, 24), 0644))
+
+	size, err := main.DirSize(tdir)
+	require.NoError(t, err)
+	require.Equal(t, main.ByteSize(124), size)
+
+	_, err = main.DirSize(filepath.Join(tdir, "missing"))
+	require.Error(t, err)
+}
+
+func TestSpec_MeasureUsage(t *testing.T) {
+	tdir := t.TempDir()
+	spec := main.NewSpec("test", main.PythonSpec, tdir)
+	require.NoError(t, os.MkdirAll(spec.Path, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(spec.Path, "a"), make([]byte, 10), 0644))
+
+	measured, scanned, err := spec.MeasureUsage(false)
+	require.NoError(t, err)
+	require.True(t, scanned)
+	require.Equal(t, main.ByteSize(10), measured.Usage.Size)
+
+	require.NoError(t, os.WriteFile(filepath.Join(spec.Path, "b"), make([]byte, 10), 0644))
+	cached, scanned, err := measured.MeasureUsage(false)
+	require.NoError(t, err)
+	require.False(t, scanned)
+	require.Equal(t, main.ByteSize(10), cached.Usage.Size)
+
+	refreshed, scanned, err := measured.MeasureUsage(true)
+	require.NoError(t, err)
+	require.True(t, scanned)
+	require.Equal(t, main.ByteSize(20), refreshed.Usage.Size)
+}
+
+func TestMeasure_Deprovisioned(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	var out bytes.Buffer
+	previous := main.SetOutput(main.NewPlainOutput(&out))
+	t.Cleanup(func() { main.SetOutput(previous) })
+	config := main.Config{Disk: main.DiskConfig{Quota: main.GB}}
+	require.NoError(t, config.Save())
+
+	ctx := &main.CLIContext{}
+	t.Cleanup(func() { ctx.Close() })
+	store, err := ctx.Store()
+	require.NoError(t, err)
+	dataDir, err := main.DefaultDataDir()
+	require.NoError(t, err)
+	// The directory of a deprovisioned environment was removed on purpose
+	spec := main.NewSpec("kept", main.NotesSpec, dataDir)
+	spec.Deprovisioned = time.Now()
+	require.NoError(t, spec.Save(store))
+
+	measured, scanned, err := spec.MeasureUsage(true)
+	require.NoError(t, err)
+	require.False(t, scanned)
+	require.Zero(t, measured.Usage.Size)
+
+	require.NoError(t, main.DuCmd{}.Run(ctx))
+	_, err = main.NewCmd{Name: "new", Type: main.NotesSpec, NoOpen: true}.Create(ctx)
+	require.NoError(t, err)
+	require.NotContains(t, out.String(), "Could not measure")
+}
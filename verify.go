@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Drift is a difference between an environment and what its spec expects on disk
+type Drift struct {
+	Spec    Spec
+	Problem string
+	// Missing is set when the directory or archive of the environment is gone
+	Missing bool
+}
+
+// VerifySpec compares spec with the disk, returning nil when they match. Unlike
+// CheckSpec, programs the type needs do not have to be installed.
+func VerifySpec(spec Spec) *Drift {
+	if spec.IsArchived() {
+		if _, err := os.Stat(spec.Archive); err != nil {
+			return &Drift{Spec: spec, Problem: fmt.Sprintf("archive %s does not exist", spec.Archive), Missing: true}
+		}
+		return nil
+	}
+	if !spec.Exists() {
+		return &Drift{Spec: spec, Problem: fmt.Sprintf("directory %s does not exist", spec.Path), Missing: true}
+	}
+
+	p, err := NewProvisioner(spec.Type)
+	if err != nil {
+		return &Drift{Spec: spec, Problem: err.Error()}
+	}
+	// A directory replaced by another kind of project explains more than missing files
+	if problem := detectMismatch(spec); problem != "" {
+		return &Drift{Spec: spec, Problem: problem}
+	}
+	if c, ok := p.(Checker); ok {
+		if err := c.Check(spec.Path); err != nil {
+			return &Drift{Spec: spec, Problem: err.Error()}
+		}
+	}
+	return nil
+}
+
+// FindDrift returns the drift of every spec that does not match the disk
+func FindDrift(specs []Spec) []Drift {
+	drifts := []Drift{}
+	for _, spec := range specs {
+		if drift := VerifySpec(spec); drift != nil {
+			drifts = append(drifts, *drift)
+		}
+	}
+	return drifts
+}
+
+// VerifyCmd represents the command to compare environments with their directories
+type VerifyCmd struct {
+	FilterFlags
+	Type  SpecType `short:"t" help:"Only environments of this type"`
+	Fix   string   `help:"Repair drift by re-running provisioners or by forgetting the environments (reprovision or deregister)"`
+	Force bool     `short:"f" help:"Deregister without confirmation"`
+	// Content compares files with the hashes recorded when environments were created
+	Content bool `help:"Report which scaffolded files were modified, deleted or left pristine, and which were added"`
+}
+
+// Validate checks the repair is known
+func (v VerifyCmd) Validate() error {
+	switch v.Fix {
+	case "", "reprovision", "deregister":
+		return nil
+	}
+	return fmt.Errorf("--fix must be reprovision or deregister, not %q", v.Fix)
+}
+
+// Run reports environments that drifted from their specs and repairs them with --fix
+func (v VerifyCmd) Run(ctx *CLIContext) error {
+	store, err := ctx.Store()
+	if err != nil {
+		return err
+	}
+	specs, err := LoadSpecs(store)
+	if err != nil {
+		return err
+	}
+	specs = v.Filter(v.Type).Apply(specs, time.Now())
+
+	drifts := FindDrift(specs)
+	if v.Content {
+		if err := reportContent(store, specs); err != nil {
+			return err
+		}
+	}
+	if len(drifts) == 0 {
+		output.Success("All %d environments match their directories", len(specs))
+		return nil
+	}
+
+	switch v.Fix {
+	case "reprovision":
+		drifted := []Spec{}
+		for _, d := range drifts {
+			drifted = append(drifted, d.Spec)
+		}
+		return ReportBatch("reprovision", RunBatch(drifted, 1, ReprovisionSpec))
+	case "deregister":
+		return v.deregister(store, drifts)
+	}
+
+	for _, d := range drifts {
+		fmt.Printf("DRIFT %s: %s\n", d.Spec.ID(), d.Problem)
+	}
+	return fmt.Errorf("%d of %d environments drifted, repair them with --fix reprovision or --fix deregister", len(drifts), len(specs))
+}
+
+// maxListedPaths is how many paths of each kind of change are listed
+const maxListedPaths = 5
+
+// listPaths joins paths for a report line, shortening long lists
+func listPaths(change string, paths []string) string {
+	if len(paths) <= maxListedPaths {
+		return fmt.Sprintf("%s %s", change, strings.Join(paths, ", "))
+	}
+	return fmt.Sprintf("%s %s and %d more", change, strings.Join(paths[:maxListedPaths], ", "), len(paths)-maxListedPaths)
+}
+
+// reportContent prints how the files of each live environment changed since it was created
+func reportContent(store Reader, specs []Spec) error {
+	unrecorded := 0
+	for _, spec := range specs {
+		if spec.IsArchived() || !spec.Exists() {
+			continue
+		}
+		manifest, err := LoadManifest(store, spec)
+		if errors.Is(err, ErrNotFound) {
+			unrecorded++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var ignore []string
+		if p, err := NewProvisioner(spec.Type); err == nil {
+			ignore = ignoredFiles(p)
+		}
+		report, err := CompareContent(spec.Path, manifest, ignore...)
+		if err != nil {
+			return err
+		}
+		if report.Untouched() {
+			fmt.Printf("PRISTINE %s (%d files)\n", spec.ID(), len(report.Pristine))
+			continue
+		}
+		changes := []string{}
+		for _, c := range []struct {
+			change string
+			paths  []string
+		}{{"modified", report.Modified}, {"deleted", report.Deleted}, {"added", report.Added}} {
+			if len(c.paths) > 0 {
+				changes = append(changes, listPaths(c.change, c.paths))
+			}
+		}
+		fmt.Printf("CHANGED  %s: %s\n", spec.ID(), strings.Join(changes, "; "))
+	}
+	if unrecorded > 0 {
+		output.Info("%d environments were created before file hashes were recorded", unrecorded)
+	}
+	return nil
+}
+
+// deregister forgets the drifted environments, keeping whatever is left of their files
+func (v VerifyCmd) deregister(store Writer, drifts []Drift) error {
+	if !v.Force {
+		items := []string{}
+		for _, d := range drifts {
+			items = append(items, fmt.Sprintf("%s: %s", d.Spec.ID(), d.Problem))
+		}
+		ok, err := confirmAll(fmt.Sprintf("Deregister %d environments?", len(drifts)), items)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			output.Info("Not deregistering environments")
+			return nil
+		}
+	}
+
+	for _, d := range drifts {
+		if err := d.Spec.Delete(store); err != nil {
+			return err
+		}
+		if d.Missing {
+			output.Success("Deregistered %s", d.Spec.ID())
+		} else {
+			output.Success("Deregistered %s, its files at %s were kept", d.Spec.ID(), d.Spec.Location())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDrift(t *testing.T) {
+	dataDir := t.TempDir()
+	intact := filepath.Join(dataDir, "intact")
+	require.NoError(t, os.MkdirAll(intact, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(intact, "deno.json"), []byte("{}"), 0644))
+	broken := filepath.Join(dataDir, "broken")
+	require.NoError(t, os.MkdirAll(broken, 0755))
+
+	specs := []main.Spec{
+		{Name: "intact", Type: main.DenoSpec, Path: intact},
+		{Name: "broken", Type: main.DenoSpec, Path: broken},
+		{Name: "gone", Type: main.PythonSpec, Path: filepath.Join(dataDir, "gone")},
+		{Name: "archived", Type: main.PythonSpec, Path: filepath.Join(dataDir, "archived"), Archive: intact},
+		{Name: "lost", Type: main.PythonSpec, Path: filepath.Join(dataDir, "lost"), Archive: filepath.Join(dataDir, "lost.tar")},
+	}
+
+	drifts := main.FindDrift(specs)
+	require.Len(t, drifts, 3)
+	require.Equal(t, "deno:broken", drifts[0].Spec.ID())
+	require.Equal(t, "missing deno.json", drifts[0].Problem)
+	require.False(t, drifts[0].Missing)
+	require.Equal(t, "python:gone", drifts[1].Spec.ID())
+	require.True(t, drifts[1].Missing)
+	require.Equal(t, "python:lost", drifts[2].Spec.ID())
+	require.Contains(t, drifts[2].Problem, "archive")
+}
+
+func TestVerifyCmd_Validate(t *testing.T) {
+	require.NoError(t, main.VerifyCmd{}.Validate())
+	require.NoError(t, main.VerifyCmd{Fix: "deregister"}.Validate())
+	require.Error(t, main.VerifyCmd{Fix: "delete"}.Validate())
+}
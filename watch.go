@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DirWatcher checks a directory for new subdirectories, like projects created by other tools
+type DirWatcher struct {
+	dir string
+	// settle is how long a new directory is left alone so the tool creating it can finish
+	settle time.Duration
+	// seen holds when each subdirectory was first seen
+	seen map[string]time.Time
+	// done holds subdirectories already handled, or there before watching
+	done map[string]bool
+}
+
+// NewDirWatcher returns a watcher of dir ignoring the subdirectories it already has
+func NewDirWatcher(dir string, settle time.Duration) (*DirWatcher, error) {
+	w := &DirWatcher{dir: dir, settle: settle, seen: map[string]time.Time{}, done: map[string]bool{}}
+	dirs, err := w.subdirs()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		w.done[dir] = true
+	}
+	return w, nil
+}
+
+// subdirs lists the subdirectories of the watched directory, skipping hidden ones
+// like the trash and archive
+func (w *DirWatcher) subdirs() ([]string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", w.dir, err)
+	}
+	dirs := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			dirs = append(dirs, filepath.Join(w.dir, entry.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// Poll returns the new subdirectories first seen at least settle before now that
+// are not done yet. Subdirectories that were removed are forgotten, so they are
+// new again if they come back.
+func (w *DirWatcher) Poll(now time.Time) ([]string, error) {
+	dirs, err := w.subdirs()
+	if err != nil {
+		return nil, err
+	}
+	present := map[string]bool{}
+	settled := []string{}
+	for _, dir := range dirs {
+		present[dir] = true
+		if w.done[dir] {
+			continue
+		}
+		first, ok := w.seen[dir]
+		if !ok {
+			w.seen[dir] = now
+			first = now
+		}
+		if now.Sub(first) >= w.settle {
+			settled = append(settled, dir)
+		}
+	}
+	for dir := range w.seen {
+		if !present[dir] {
+			delete(w.seen, dir)
+		}
+	}
+	for dir := range w.done {
+		if !present[dir] {
+			delete(w.done, dir)
+		}
+	}
+	return settled, nil
+}
+
+// Done marks dir as handled so it is not returned again
+func (w *DirWatcher) Done(dir string) {
+	w.done[dir] = true
+	delete(w.seen, dir)
+}
+
+// Next returns when the first new subdirectory that is not done yet settles,
+// and false if there is none
+func (w *DirWatcher) Next() (time.Time, bool) {
+	var next time.Time
+	for _, first := range w.seen {
+		if settles := first.Add(w.settle); next.IsZero() || settles.Before(next) {
+			next = settles
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// WatchCmd represents the command to adopt directories created in a directory by other tools
+type WatchCmd struct {
+	Dir      string   `arg:"" help:"The directory to watch for new project folders" type:"existingdir"`
+	Interval Duration `help:"How often the directory is checked when changes cannot be watched, and adopting is retried" default:"2s"`
+	Settle   Duration `help:"How long a new folder is left alone before adopting it, so the tool creating it can finish" default:"10s"`
+}
+
+// Validate checks the interval is positive
+func (w WatchCmd) Validate() error {
+	if w.Interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	return nil
+}
+
+// adopt tracks dir as an environment, asking for its type when it cannot be
+// detected, and reports if it is done with dir or should try again later
+func (w WatchCmd) adopt(ctx *CLIContext, dir string) (bool, error) {
+	// The store is only held while adopting, so other commands can run in between
+	defer ctx.Close()
+	store, err := ctx.Store()
+	if errors.Is(err, ErrStoreLocked) {
+		slog.Debug("Store is locked, trying again later", slog.String("dir", dir))
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// Environments created by scratch itself are already tracked
+	tracked, ok, err := FindSpecByPath(store, dir)
+	if err != nil {
+		return false, err
+	}
+	if ok && tracked.Path == dir {
+		slog.Debug("Directory is tracked", slog.String("dir", dir), slog.String("id", tracked.ID()))
+		return true, nil
+	}
+
+	recorded, err := recordedSpec(dir)
+	if err != nil {
+		return true, err
+	}
+	specType := recorded.Type
+	if specType == "" {
+		specType, err = DetectType(dir)
+	}
+	if err != nil {
+		if !canPrompt() {
+			return true, fmt.Errorf("%w, adopt it with 'scratch adopt %s --type <type>'", err, dir)
+		}
+		types := AllSpecTypes()
+		options := make([]string, len(types)+1)
+		for i, t := range types {
+			options[i] = string(t)
+		}
+		options[len(types)] = "skip"
+		choice, err := askForChoice(fmt.Sprintf("Could not detect the type of %s, adopt it as", dir), options)
+		if err != nil {
+			return true, err
+		}
+		if choice == len(types) {
+			output.Info("Skipped %s", dir)
+			return true, nil
+		}
+		specType = types[choice]
+	}
+	return true, AdoptCmd{Path: dir, Type: specType}.Run(ctx)
+}
+
+// Run adopts new folders in the directory until interrupted. The directory is checked
+// when the OS reports it changed, or polled every interval where it cannot, like on
+// some network file systems or once the inotify limit is reached.
+func (w WatchCmd) Run(ctx *CLIContext) error {
+	watcher, err := NewDirWatcher(w.Dir, time.Duration(w.Settle))
+	if err != nil {
+		return err
+	}
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var events <-chan fsnotify.Event
+	var notifyErrs <-chan error
+	var poll <-chan time.Time
+	notifier, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer notifier.Close()
+		err = notifier.Add(w.Dir)
+	}
+	if err != nil {
+		output.Warn("Cannot watch %s for changes, checking it every %s: %s", w.Dir, w.Interval, err)
+		ticker := time.NewTicker(time.Duration(w.Interval))
+		defer ticker.Stop()
+		poll = ticker.C
+	} else {
+		events, notifyErrs = notifier.Events, notifier.Errors
+	}
+	output.Info("Watching %s for new folders", w.Dir)
+
+	for {
+		dirs, err := watcher.Poll(time.Now())
+		if err != nil {
+			return err
+		}
+		for _, dir := range dirs {
+			done, err := w.adopt(ctx, dir)
+			if err != nil {
+				output.Warn("%s", err)
+			}
+			if done {
+				watcher.Done(dir)
+			}
+		}
+
+		// Folders are checked again once they settle, or after an interval when
+		// adopting them has to be retried
+		var settled <-chan time.Time
+		if next, ok := watcher.Next(); ok {
+			wait := time.Until(next)
+			if wait <= 0 {
+				wait = time.Duration(w.Interval)
+			}
+			settled = time.After(wait)
+		}
+		select {
+		case <-sigCtx.Done():
+			output.Info("Stopping watch")
+			return nil
+		case <-poll:
+		case <-settled:
+		case event, ok := <-events:
+			if !ok {
+				return errors.New("watching stopped")
+			}
+			slog.Debug("Directory changed", slog.String("event", event.String()))
+		case err := <-notifyErrs:
+			// Events may have been dropped, which checking the directory catches up on
+			slog.Debug("Watching failed", slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirWatcher(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "existing"), 0755))
+	watcher, err := main.NewDirWatcher(dir, time.Minute)
+	require.NoError(t, err)
+
+	now := time.Now()
+	created := filepath.Join(dir, "created")
+	require.NoError(t, os.Mkdir(created, 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".hidden"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), nil, 0644))
+
+	// New directories are left alone until they settle
+	dirs, err := watcher.Poll(now)
+	require.NoError(t, err)
+	require.Empty(t, dirs)
+	dirs, err = watcher.Poll(now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, []string{created}, dirs)
+
+	// Until done, it is returned again so adopting can be retried
+	dirs, err = watcher.Poll(now.Add(2 * time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, []string{created}, dirs)
+	watcher.Done(created)
+	dirs, err = watcher.Poll(now.Add(3 * time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, dirs)
+
+	// A directory removed and created again is new again
+	require.NoError(t, os.Remove(created))
+	_, err = watcher.Poll(now.Add(4 * time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(created, 0755))
+	_, err = watcher.Poll(now.Add(5 * time.Minute))
+	require.NoError(t, err)
+	dirs, err = watcher.Poll(now.Add(6 * time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, []string{created}, dirs)
+}
+
+func TestDirWatcher_Next(t *testing.T) {
+	dir := t.TempDir()
+	watcher, err := main.NewDirWatcher(dir, time.Minute)
+	require.NoError(t, err)
+	_, ok := watcher.Next()
+	require.False(t, ok)
+
+	now := time.Now()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "first"), 0755))
+	_, err = watcher.Poll(now)
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "second"), 0755))
+	_, err = watcher.Poll(now.Add(time.Second))
+	require.NoError(t, err)
+
+	next, ok := watcher.Next()
+	require.True(t, ok)
+	require.Equal(t, now.Add(time.Minute), next)
+
+	watcher.Done(filepath.Join(dir, "first"))
+	next, ok = watcher.Next()
+	require.True(t, ok)
+	require.Equal(t, now.Add(time.Minute+time.Second), next)
+}
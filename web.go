@@ -0,0 +1,133 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+var npmTool = Tool{
+	Name: "npm",
+	Installers: map[string][]Installer{
+		"linux": {
+			{Requires: "apt-get", Command: "sudo apt-get install -y nodejs npm"},
+			{Requires: "dnf", Command: "sudo dnf install -y nodejs npm"},
+			{Requires: "pacman", Command: "sudo pacman -S --needed nodejs npm"},
+		},
+		"darwin":  {{Requires: "brew", Command: "brew install node"}},
+		"windows": {{Requires: "winget", Command: "winget install --id=OpenJS.NodeJS.LTS -e"}},
+	},
+}
+
+// WebFrameworks lists the frameworks web environments can be created with
+var WebFrameworks = []string{"vanilla", "react", "vue", "svelte"}
+
+// DefaultFramework is used by web environments created without --framework
+const DefaultFramework = "vanilla"
+
+// WebEnvironment represents a frontend project created with Vite
+type WebEnvironment struct {
+	// Framework is one of WebFrameworks, vanilla when empty
+	Framework string
+}
+
+// Ready checks if the environment is ready to be created
+func (p WebEnvironment) Ready() error {
+	if p.Framework != "" && !slices.Contains(WebFrameworks, p.Framework) {
+		return fmt.Errorf("unknown framework %q, expected one of %v", p.Framework, WebFrameworks)
+	}
+	if err := CommandsExist("npm"); err != nil {
+		return fmt.Errorf("missing required commands: %w", err)
+	}
+	return nil
+}
+
+// Options returns the framework
+func (p WebEnvironment) Options() []Option {
+	return []Option{{Name: "framework", Help: "The framework of the Vite template", Values: WebFrameworks, Default: DefaultFramework}}
+}
+
+// WithOptions returns the environment with the framework of opts
+func (p WebEnvironment) WithOptions(opts map[string]string) Provisioner {
+	p.Framework = cmp.Or(opts["framework"], p.Framework)
+	return p
+}
+
+// install installs the dependencies of the project in dir
+func (p WebEnvironment) install(dir string) error {
+	if err := RunCommand(dir, "npm", "install"); err != nil {
+		return fmt.Errorf("npm install: %w", err)
+	}
+	return nil
+}
+
+// Provision creates the environment at provided directory with the TypeScript
+// template of the framework
+func (p WebEnvironment) Provision(dir string) error {
+	if err := EnsureDirectory(dir); err != nil {
+		return err
+	}
+
+	template := cmp.Or(p.Framework, DefaultFramework) + "-ts"
+	if err := RunCommand(dir, "npm", "create", "vite@latest", ".", "--", "--template", template, "--no-interactive"); err != nil {
+		return fmt.Errorf("npm create vite: %w", err)
+	}
+	if err := p.install(dir); err != nil {
+		return err
+	}
+
+	slog.Debug("Provisioned web environment", slog.String("framework", template), slog.String("dir", dir))
+	return nil
+}
+
+// Detect recognizes projects with a Vite config
+func (p WebEnvironment) Detect(dir string) float64 {
+	return detectFiles(dir, map[string]float64{
+		"vite.config.ts": StrongMatch,
+		"vite.config.js": StrongMatch,
+		"index.html":     WeakMatch,
+	})
+}
+
+// Describe summarizes web environments
+func (p WebEnvironment) Describe() Description {
+	return Description{Summary: "A frontend project created with Vite", Examples: []string{"scratch new site -t web", "scratch new site -t web --opt framework=react"}}
+}
+
+// Tools returns npm, which comes with Node.js
+func (p WebEnvironment) Tools() []Tool {
+	return []Tool{npmTool}
+}
+
+// Check verifies the project file and page exist
+func (p WebEnvironment) Check(dir string) error {
+	return FilesExist(dir, "package.json", "index.html")
+}
+
+// Ignore returns installed packages and the build output
+func (p WebEnvironment) Ignore() []string {
+	return []string{"node_modules", "dist"}
+}
+
+// Rebuild reinstalls dependencies
+func (p WebEnvironment) Rebuild(dir string) error {
+	return p.install(dir)
+}
+
+// Reprovision installs dependencies if node_modules is missing
+func (p WebEnvironment) Reprovision(dir string) error {
+	if FilesExist(dir, "node_modules") != nil {
+		slog.Debug("Installing missing dependencies", slog.String("dir", dir))
+		return p.install(dir)
+	}
+	return nil
+}
+
+// Upgrade updates dependencies within the ranges of package.json
+func (p WebEnvironment) Upgrade(dir string) error {
+	if err := RunCommand(dir, "npm", "update"); err != nil {
+		return fmt.Errorf("npm update: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNpm records its arguments and writes the files create-vite would
+const fakeNpm = `#!/bin/sh
+echo "$@" >> npm.log
+echo '{}' > package.json
+echo '<!doctype html>' > index.html
+: > vite.config.ts
+`
+
+func TestWebEnvironment_Provision(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("npm is faked with a shell script")
+	}
+	t.Setenv("PATH", fakeBin(t, map[string]string{"npm": fakeNpm}))
+
+	dir := filepath.Join(t.TempDir(), "site")
+	p := main.WebEnvironment{Framework: "react"}
+	require.NoError(t, p.Ready())
+	require.NoError(t, p.Provision(dir))
+	require.NoError(t, p.Check(dir))
+
+	log, err := os.ReadFile(filepath.Join(dir, "npm.log"))
+	require.NoError(t, err)
+	require.Equal(t, "create vite@latest . -- --template react-ts --no-interactive\ninstall\n", string(log))
+
+	specType, err := main.DetectType(dir)
+	require.NoError(t, err)
+	require.Equal(t, main.WebSpec, specType)
+
+	require.ErrorContains(t, main.WebEnvironment{Framework: "angular"}.Ready(), `unknown framework "angular"`)
+}
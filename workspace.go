@@ -0,0 +1,257 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultWorkspace holds environments created without a workspace. Its keys are
+// unprefixed so stores from before workspaces keep working.
+const DefaultWorkspace = "default"
+
+// workspaceKeyPrefix prefixes the store keys of environments in other workspaces
+const workspaceKeyPrefix = "ws/"
+
+// workspace is the workspace commands operate in, set once flags and config are read
+var workspace = DefaultWorkspace
+
+// validWorkspace matches workspace names, which are used in keys and directory names
+var validWorkspace = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateWorkspace checks name can be used as a workspace
+func ValidateWorkspace(name string) error {
+	if !validWorkspace.MatchString(name) {
+		return fmt.Errorf("invalid workspace name %q: use lowercase letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// AllWorkspaces returns the default workspace followed by the created ones
+func (c Config) AllWorkspaces() []string {
+	return append([]string{DefaultWorkspace}, c.Workspaces...)
+}
+
+// UseWorkspace makes name the workspace of this invocation, falling back to the
+// configured default when empty
+func UseWorkspace(config Config, name string) error {
+	name = cmp.Or(name, config.Workspace, DefaultWorkspace)
+	if !slices.Contains(config.AllWorkspaces(), name) {
+		return fmt.Errorf("unknown workspace %q, create it with scratch workspace create %s: %w", name, name, ErrNotFound)
+	}
+	workspace = name
+	return nil
+}
+
+// workspaceDir returns the variant of dir for the current workspace. Other
+// workspaces live next to the default one, like scratch-work next to scratch.
+func workspaceDir(dir string) string {
+	return workspaceDirOf(dir, workspace)
+}
+
+// workspaceDirOf returns the variant of dir for the workspace name
+func workspaceDirOf(dir string, name string) string {
+	if name == DefaultWorkspace {
+		return dir
+	}
+	return dir + "-" + name
+}
+
+// WorkspaceStore scopes a Storer to the keys of one workspace
+type WorkspaceStore struct {
+	Storer
+	prefix string
+}
+
+// NewWorkspaceStore scopes store to workspace name
+func NewWorkspaceStore(store Storer, name string) *WorkspaceStore {
+	s := &WorkspaceStore{Storer: store}
+	if name != DefaultWorkspace {
+		s.prefix = workspaceKeyPrefix + name + "/"
+	}
+	return s
+}
+
+// key returns the underlying key of key in the workspace
+func (s *WorkspaceStore) key(key string) string {
+	return s.prefix + key
+}
+
+// own returns the key in the workspace of an underlying key and whether it belongs to the workspace
+func (s *WorkspaceStore) own(key string) (string, bool) {
+	if s.prefix == "" {
+		return key, !strings.HasPrefix(key, workspaceKeyPrefix)
+	}
+	return strings.CutPrefix(key, s.prefix)
+}
+
+// Get fetches data by key
+func (s *WorkspaceStore) Get(key string) ([]byte, error) {
+	return s.Storer.Get(s.key(key))
+}
+
+// Exists checks if a key exists
+func (s *WorkspaceStore) Exists(key string) (bool, error) {
+	return s.Storer.Exists(s.key(key))
+}
+
+// Put adds or replaces a key with its data
+func (s *WorkspaceStore) Put(key string, data []byte) error {
+	return s.Storer.Put(s.key(key), data)
+}
+
+// Delete removes key with its data
+func (s *WorkspaceStore) Delete(key string) error {
+	return s.Storer.Delete(s.key(key))
+}
+
+// Batch commits the writes made by apply in the workspace atomically
+func (s *WorkspaceStore) Batch(apply func(w Writer) error) error {
+	return s.Storer.Batch(func(w Writer) error {
+		return apply(workspaceWriter{Writer: w, prefix: s.prefix})
+	})
+}
+
+// workspaceWriter scopes the writes of a batch to the keys of one workspace
+type workspaceWriter struct {
+	Writer
+	prefix string
+}
+
+// Put adds or replaces a key with its data
+func (w workspaceWriter) Put(key string, data []byte) error {
+	return w.Writer.Put(w.prefix+key, data)
+}
+
+// Delete removes key with its data
+func (w workspaceWriter) Delete(key string) error {
+	return w.Writer.Delete(w.prefix + key)
+}
+
+// List lists the keys in the workspace
+func (s *WorkspaceStore) List() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for key, err := range s.Storer.List() {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if key, ok := s.own(key); ok && !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ListFunc processes each key-value pair in the workspace with provided function
+func (s *WorkspaceStore) ListFunc(handle func(key string, data []byte) error) error {
+	return s.ListPrefix("", handle)
+}
+
+// ListPrefix processes each key-value pair in the workspace whose key starts with
+// prefix. Other workspaces are skipped by the scan, except for the default one
+// whose keys are unprefixed.
+func (s *WorkspaceStore) ListPrefix(prefix string, handle func(key string, data []byte) error) error {
+	return s.Storer.ListPrefix(s.key(prefix), func(key string, data []byte) error {
+		if key, ok := s.own(key); ok {
+			return handle(key, data)
+		}
+		return nil
+	})
+}
+
+// WorkspaceCmd represents the commands to manage workspaces
+type WorkspaceCmd struct {
+	List   WorkspaceListCmd   `cmd:"" default:"1" help:"List workspaces"`
+	Create WorkspaceCreateCmd `cmd:"" help:"Create a workspace"`
+	Switch WorkspaceSwitchCmd `cmd:"" help:"Use a workspace by default"`
+}
+
+// WorkspaceListCmd represents the command to list workspaces
+type WorkspaceListCmd struct{}
+
+// Run prints every workspace, marking the current one
+func (w WorkspaceListCmd) Run(ctx *CLIContext) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	for _, name := range config.AllWorkspaces() {
+		mark := " "
+		if name == workspace {
+			mark = "*"
+		}
+		fmt.Printf("%s %s\n", mark, name)
+	}
+	return nil
+}
+
+// WorkspaceCreateCmd represents the command to create a workspace
+type WorkspaceCreateCmd struct {
+	Name string `arg:"" help:"The name of workspace"`
+}
+
+// Run records the workspace in the config
+func (w WorkspaceCreateCmd) Run(ctx *CLIContext) error {
+	if err := ValidateWorkspace(w.Name); err != nil {
+		return err
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(config.AllWorkspaces(), w.Name) {
+		return fmt.Errorf("workspace %q %w", w.Name, ErrExists)
+	}
+
+	config.Workspaces = append(config.Workspaces, w.Name)
+	if err := config.Save(); err != nil {
+		return err
+	}
+	output.Success("Created workspace %s, use it with --workspace %s or scratch workspace switch %s", w.Name, w.Name, w.Name)
+	return nil
+}
+
+// WorkspaceSwitchCmd represents the command to change the default workspace
+type WorkspaceSwitchCmd struct {
+	Name string `arg:"" help:"The name of workspace"`
+}
+
+// Run makes the workspace the default in the config
+func (w WorkspaceSwitchCmd) Run(ctx *CLIContext) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(config.AllWorkspaces(), w.Name) {
+		return fmt.Errorf("unknown workspace %q, create it with scratch workspace create %s: %w", w.Name, w.Name, ErrNotFound)
+	}
+
+	config.Workspace = w.Name
+	if w.Name == DefaultWorkspace {
+		config.Workspace = ""
+	}
+	if err := config.Save(); err != nil {
+		return err
+	}
+
+	dir, err := appDataDir()
+	if err != nil {
+		return err
+	}
+	workspace = w.Name
+	output.Success("Switched to workspace %s, new environments go to %s", w.Name, workspaceDir(dir))
+	return nil
+}
+
+// backupDirName returns the directory name of backups of the current workspace
+func backupDirName() string {
+	if workspace == DefaultWorkspace {
+		return "backups"
+	}
+	return filepath.Join("backups", workspace)
+}
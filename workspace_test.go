@@ -0,0 +1,50 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/chargeflux/scratch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceStore(t *testing.T) {
+	store := NewMemoryStore()
+	personal := main.NewWorkspaceStore(store, main.DefaultWorkspace)
+	work := main.NewWorkspaceStore(store, "work")
+
+	home := main.NewSpec("test", main.PythonSpec, "/home")
+	job := main.NewSpec("test", main.PythonSpec, "/work")
+	require.NoError(t, home.Save(personal))
+	require.NoError(t, job.Save(work))
+
+	// The default workspace keeps unprefixed keys
+	require.Contains(t, store.Data, home.Key())
+	require.Contains(t, store.Data, "ws/work/"+job.Key())
+
+	for _, c := range []struct {
+		store *main.WorkspaceStore
+		want  main.Spec
+	}{{personal, home}, {work, job}} {
+		specs, err := main.LoadSpecs(c.store)
+		require.NoError(t, err)
+		require.Equal(t, []main.Spec{c.want}, specs)
+
+		found, err := main.FindSpecs(c.store, main.PythonSpec, "test")
+		require.NoError(t, err)
+		require.Equal(t, []main.Spec{c.want}, found)
+	}
+
+	require.NoError(t, job.Delete(work))
+	specs, err := main.LoadSpecs(work)
+	require.NoError(t, err)
+	require.Empty(t, specs)
+	require.Len(t, store.Data, 2)
+}
+
+func TestValidateWorkspace(t *testing.T) {
+	require.NoError(t, main.ValidateWorkspace("work"))
+	require.NoError(t, main.ValidateWorkspace("side_project-2"))
+	require.Error(t, main.ValidateWorkspace("Work"))
+	require.Error(t, main.ValidateWorkspace("a/b"))
+	require.Error(t, main.ValidateWorkspace(""))
+}